@@ -0,0 +1,130 @@
+// Package ble decodes the BLE advertisements of popular wireless thermometers into the readings of a
+// spienvironment.WirelessThermometer.  Receiving the advertisements is left to the caller.
+package ble
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// ErrUnsupported is returned for advertisements of unsupported sensors, or ones that don't carry readings.
+var ErrUnsupported = errors.New("unsupported advertisement")
+
+// ErrEncrypted is returned for encrypted advertisements.
+var ErrEncrypted = errors.New("encrypted advertisement")
+
+// ErrNotThermometer is returned for advertisements of supported formats that don't include a temperature.
+var ErrNotThermometer = errors.New("advertisement has no temperature")
+
+// Service UUIDs and company ids of the supported formats
+const (
+	environmentalSensingUUID uint16 = 0x181a
+	bthomeUUID               uint16 = 0xfcd2
+	goveeCompanyId           uint16 = 0xec88
+	ruuviCompanyId           uint16 = 0x0499
+)
+
+// Advertisement is the data of a received BLE advertisement.
+type Advertisement struct {
+	LocalName string
+
+	// ServiceData maps 16-bit service UUIDs to their data.
+	ServiceData map[uint16][]byte
+
+	// ManufacturerData is the manufacturer specific data, starting with the little-endian company id.
+	ManufacturerData []byte
+
+	// RSSI is the received signal strength in dBm, or 0 if unknown.
+	RSSI int
+}
+
+// Reading is a decoded advertisement.  BatteryData and RSSIData are empty when the advertisement doesn't include
+// them.
+type Reading struct {
+	Model string
+
+	// Address is the MAC address included in the payload by some formats.
+	Address string
+
+	SensorData  spienvironment.SensorData
+	BatteryData spienvironment.BatteryData
+	RSSIData    spienvironment.RSSIData
+
+	// Pressure is in hPa.
+	HasPressure bool
+	Pressure    float32
+}
+
+// WirelessThermometer returns the reading as the state of a wireless thermometer.
+func (r Reading) WirelessThermometer(name string) spienvironment.WirelessThermometer {
+	return spienvironment.WirelessThermometer{
+		Name:        name,
+		SensorData:  r.SensorData,
+		BatteryData: r.BatteryData,
+		RSSIData:    r.RSSIData,
+	}
+}
+
+// Decode decodes advertisement, received at now.
+func Decode(advertisement Advertisement, now time.Time) (Reading, error) {
+	reading, err := decode(advertisement)
+
+	if err != nil {
+		return Reading{}, err
+	}
+
+	reading.SensorData.LastUpdateTime = now
+
+	if !reading.BatteryData.IsEmpty() {
+		reading.BatteryData.LastUpdateTime = now
+	}
+
+	if advertisement.RSSI != 0 {
+		reading.RSSIData = spienvironment.RSSIData{RSSI: advertisement.RSSI, LastUpdateTime: now}
+	}
+
+	return reading, nil
+}
+
+func decode(advertisement Advertisement) (Reading, error) {
+	if data, ok := advertisement.ServiceData[environmentalSensingUUID]; ok {
+		return decodeXiaomiCustom(data)
+	}
+
+	if data, ok := advertisement.ServiceData[bthomeUUID]; ok {
+		return decodeBTHome(data)
+	}
+
+	manufacturerData := advertisement.ManufacturerData
+
+	// Inkbird sensors put the temperature where the company id belongs, so they're recognized by name
+	if advertisement.LocalName == "sps" {
+		return decodeInkbird(manufacturerData)
+	}
+
+	if len(manufacturerData) < 2 {
+		return Reading{}, ErrUnsupported
+	}
+
+	switch binary.LittleEndian.Uint16(manufacturerData) {
+	case goveeCompanyId:
+		return decodeGovee(manufacturerData[2:])
+	case ruuviCompanyId:
+		return decodeRuuvi(manufacturerData[2:])
+	}
+
+	return Reading{}, ErrUnsupported
+}
+
+func formatAddress(address []byte) string {
+	return fmt.Sprintf("%02X:%02X:%02X:%02X:%02X:%02X",
+		address[0], address[1], address[2], address[3], address[4], address[5])
+}
+
+func invalidLength(model string, data []byte) error {
+	return fmt.Errorf("%w: %s payload of %d bytes", ErrUnsupported, model, len(data))
+}
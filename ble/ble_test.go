@@ -0,0 +1,227 @@
+package ble
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func bytes(t *testing.T, vector string) []byte {
+	result, err := hex.DecodeString(strings.ReplaceAll(vector, " ", ""))
+
+	if err != nil {
+		t.Fatalf("invalid vector %s: %v", vector, err)
+	}
+
+	return result
+}
+
+func near(a float32, b float32) bool {
+	return math.Abs(float64(a-b)) < 0.001
+}
+
+type expectedReading struct {
+	model        string
+	address      string
+	temperature  float32
+	hasHumidity  bool
+	humidity     float32
+	batteryLevel int
+	hasPressure  bool
+	pressure     float32
+}
+
+func assertReading(t *testing.T, reading Reading, err error, expected expectedReading) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if reading.Model != expected.model || reading.Address != expected.address ||
+		!near(reading.SensorData.Temperature, expected.temperature) ||
+		reading.SensorData.HasHumidity != expected.hasHumidity ||
+		!near(reading.SensorData.Humidity, expected.humidity) ||
+		reading.BatteryData.Level != expected.batteryLevel ||
+		reading.HasPressure != expected.hasPressure || !near(reading.Pressure, expected.pressure) {
+		t.Fatalf("expected %+v, got %+v", expected, reading)
+	}
+
+	if !reading.SensorData.LastUpdateTime.Equal(now) {
+		t.Fatalf("expected the reading to be timestamped, got %v", reading.SensorData.LastUpdateTime)
+	}
+}
+
+func TestDecode_XiaomiAtc(t *testing.T) {
+	for _, test := range []struct {
+		vector   string
+		expected expectedReading
+	}{
+		{"a4c138112233 00eb 2d 57 0b86 12",
+			expectedReading{"LYWSD03MMC (ATC)", "A4:C1:38:11:22:33", 23.5, true, 45, 87, false, 0}},
+		{"a4c138112233 ffcc 5a 0a 0a3c 13",
+			expectedReading{"LYWSD03MMC (ATC)", "A4:C1:38:11:22:33", -5.2, true, 90, 10, false, 0}},
+	} {
+		// Act
+		reading, err := Decode(Advertisement{
+			ServiceData: map[uint16][]byte{0x181a: bytes(t, test.vector)},
+			RSSI:        -67,
+		}, now)
+
+		// Assert
+		assertReading(t, reading, err, test.expected)
+
+		if reading.RSSIData.RSSI != -67 || reading.BatteryData.LastUpdateTime != now {
+			t.Fatalf("unexpected RSSI or battery data %+v %+v", reading.RSSIData, reading.BatteryData)
+		}
+	}
+}
+
+func TestDecode_XiaomiPvvx(t *testing.T) {
+	// Act
+	reading, err := Decode(Advertisement{
+		ServiceData: map[uint16][]byte{0x181a: bytes(t, "33221138c1a4 2909 d711 860b 57 12 04")},
+	}, now)
+
+	// Assert
+	assertReading(t, reading, err, expectedReading{"LYWSD03MMC (pvvx)", "A4:C1:38:11:22:33", 23.45, true, 45.67, 87, false, 0})
+
+	if !reading.RSSIData.IsEmpty() {
+		t.Fatalf("expected no RSSI data, got %+v", reading.RSSIData)
+	}
+}
+
+func TestDecode_Govee(t *testing.T) {
+	for _, test := range []struct {
+		vector   string
+		expected expectedReading
+	}{
+		// H5075
+		{"88ec 00 0341d0 5a 00", expectedReading{"H5075", "", 21.3, true, 45.6, 90, false, 0}},
+		{"88ec 00 8064c9 5a 00", expectedReading{"H5075", "", -2.5, true, 80.1, 90, false, 0}},
+		// H5074
+		{"88ec 00 dd07 a015 64 02", expectedReading{"H5074", "", 20.13, true, 55.36, 100, false, 0}},
+		{"88ec 00 06ff 2823 32 02", expectedReading{"H5074", "", -2.50, true, 90.00, 50, false, 0}},
+	} {
+		// Act
+		reading, err := Decode(Advertisement{ManufacturerData: bytes(t, test.vector)}, now)
+
+		// Assert
+		assertReading(t, reading, err, test.expected)
+	}
+}
+
+func TestDecode_Inkbird(t *testing.T) {
+	// Act
+	reading, err := Decode(Advertisement{LocalName: "sps", ManufacturerData: bytes(t, "ba08 0014 00 7d4e 50 08")}, now)
+
+	// Assert
+	assertReading(t, reading, err, expectedReading{"IBS-TH", "", 22.34, true, 51.2, 80, false, 0})
+}
+
+func TestDecode_RuuviRawV2(t *testing.T) {
+	for _, test := range []struct {
+		vector   string
+		expected expectedReading
+	}{
+		// The valid, maximum and minimum test vectors of the RAWv2 specification
+		{"9904 0512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F",
+			expectedReading{"RuuviTag", "CB:B8:33:4C:88:4F", 24.3, true, 53.49, 98, true, 1000.44}},
+		{"9904 057FFFFFFEFFFE7FFF7FFF7FFFFFDEFEFFFECBB8334C884F",
+			expectedReading{"RuuviTag", "CB:B8:33:4C:88:4F", 163.835, true, 163.835, 100, true, 1155.34}},
+		{"9904 058001000000008001800180010000000000CBB8334C884F",
+			expectedReading{"RuuviTag", "CB:B8:33:4C:88:4F", -163.835, true, 0, 0, true, 500}},
+	} {
+		// Act
+		reading, err := Decode(Advertisement{ManufacturerData: bytes(t, test.vector)}, now)
+
+		// Assert
+		assertReading(t, reading, err, test.expected)
+	}
+}
+
+func TestDecode_RuuviRawV2InvalidValues(t *testing.T) {
+	// Act
+	_, err := Decode(Advertisement{
+		ManufacturerData: bytes(t, "9904 058000FFFFFFFF800080008000FFFFFFFFFFFFFFFFFFFFFF"),
+	}, now)
+
+	// Assert
+	if !errors.Is(err, ErrNotThermometer) {
+		t.Fatalf("expected ErrNotThermometer, got %v", err)
+	}
+}
+
+func TestDecode_BTHome(t *testing.T) {
+	for _, test := range []struct {
+		vector   string
+		expected expectedReading
+	}{
+		// Packet id, battery, temperature and humidity
+		{"40 0007 015d 02c409 03bf13", expectedReading{"BTHome", "", 25, true, 50.55, 93, false, 0}},
+		// Coarse temperature and humidity, a door sensor and pressure
+		{"40 45eaff 2e3c 1a01 04138a01", expectedReading{"BTHome", "", -2.2, true, 60, 0, true, 1008.83}},
+		// Integer temperature and voltage
+		{"44 57f6 0c020c", expectedReading{"BTHome", "", -10, false, 0, 0, false, 0}},
+		// A Shelly BLU H&T: packet id, battery, humidity, button, temperature and firmware version
+		{"44 0053 0164 2e37 3a00 45d200 f1000a0001", expectedReading{"BTHome", "", 21.0, true, 55, 100, false, 0}},
+		// Readings before an unknown object are kept
+		{"40 02c409 03bf13 fe0102", expectedReading{"BTHome", "", 25, true, 50.55, 0, false, 0}},
+	} {
+		// Act
+		reading, err := Decode(Advertisement{ServiceData: map[uint16][]byte{0xfcd2: bytes(t, test.vector)}}, now)
+
+		// Assert
+		assertReading(t, reading, err, test.expected)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		advertisement Advertisement
+		expected      error
+	}{
+		{"encrypted BTHome", Advertisement{ServiceData: map[uint16][]byte{0xfcd2: bytes(t, "41 02c409")}}, ErrEncrypted},
+		{"BTHome v1", Advertisement{ServiceData: map[uint16][]byte{0xfcd2: bytes(t, "20 02c409")}}, ErrUnsupported},
+		{"temperature after an unknown BTHome object",
+			Advertisement{ServiceData: map[uint16][]byte{0xfcd2: bytes(t, "40 fe01 02c409")}}, ErrNotThermometer},
+		{"truncated BTHome object", Advertisement{ServiceData: map[uint16][]byte{0xfcd2: bytes(t, "40 02c4")}}, ErrUnsupported},
+		{"BTHome without temperature", Advertisement{ServiceData: map[uint16][]byte{0xfcd2: bytes(t, "40 0164")}}, ErrNotThermometer},
+		{"short Xiaomi", Advertisement{ServiceData: map[uint16][]byte{0x181a: bytes(t, "a4c138112233")}}, ErrUnsupported},
+		{"short Govee", Advertisement{ManufacturerData: bytes(t, "88ec 00")}, ErrUnsupported},
+		{"Ruuvi RAWv1", Advertisement{ManufacturerData: bytes(t, "9904 03291a1ece1efc18f94202ca0b53")}, ErrUnsupported},
+		{"unknown manufacturer", Advertisement{ManufacturerData: bytes(t, "4c00 0215")}, ErrUnsupported},
+		{"empty", Advertisement{}, ErrUnsupported},
+	} {
+		// Act
+		_, err := Decode(test.advertisement, now)
+
+		// Assert
+		if !errors.Is(err, test.expected) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestReading_WirelessThermometer(t *testing.T) {
+	// Arrange
+	reading, _ := Decode(Advertisement{
+		ServiceData: map[uint16][]byte{0x181a: bytes(t, "a4c138112233 00eb 2d 57 0b86 12")},
+		RSSI:        -67,
+	}, now)
+
+	// Act
+	state := reading.WirelessThermometer("Bedroom")
+
+	// Assert
+	if state.Name != "Bedroom" || state.SensorData != reading.SensorData || state.BatteryData.Level != 87 ||
+		state.RSSIData.RSSI != -67 {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
@@ -0,0 +1,168 @@
+package ble
+
+import (
+	"fmt"
+)
+
+// The BTHome v2 objects used for readings
+const (
+	bthomeBattery            byte = 0x01
+	bthomeTemperature        byte = 0x02
+	bthomeHumidity           byte = 0x03
+	bthomePressure           byte = 0x04
+	bthomeHumidityCoarse     byte = 0x2e
+	bthomeTemperatureCoarse  byte = 0x45
+	bthomeTemperatureInteger byte = 0x57
+)
+
+// bthomeObjectLengths are the value lengths of the BTHome v2 objects, which are needed to skip the ones that aren't
+// used.  Objects that aren't listed end decoding, since the rest of the payload can't be located; objects are sent in
+// ascending id order, so the readings before them are kept.
+var bthomeObjectLengths = map[byte]int{
+	0x00: 1, // packet id
+	0x01: 1, // battery
+	0x02: 2, // temperature
+	0x03: 2, // humidity
+	0x04: 3, // pressure
+	0x05: 3, // illuminance
+	0x06: 2, // mass (kg)
+	0x07: 2, // mass (lb)
+	0x08: 2, // dew point
+	0x09: 1, // count
+	0x0a: 3, // energy
+	0x0b: 3, // power
+	0x0c: 2, // voltage
+	0x0d: 2, // PM2.5
+	0x0e: 2, // PM10
+	0x0f: 1, // generic boolean
+	0x10: 1, // power (on/off)
+	0x11: 1, // opening
+	0x12: 2, // CO2
+	0x13: 2, // VOC
+	0x14: 2, // moisture
+	0x2e: 1, // humidity
+	0x2f: 1, // moisture
+	0x3a: 1, // button
+	0x3c: 2, // dimmer
+	0x3d: 2, // count
+	0x3e: 4, // count
+	0x3f: 2, // rotation
+	0x40: 2, // distance (mm)
+	0x41: 2, // distance (m)
+	0x42: 3, // duration
+	0x43: 2, // current
+	0x44: 2, // speed
+	0x45: 2, // temperature
+	0x46: 1, // UV index
+	0x47: 2, // volume
+	0x48: 2, // volume
+	0x49: 2, // volume flow rate
+	0x4a: 2, // voltage
+	0x4b: 3, // gas
+	0x4c: 4, // gas
+	0x4d: 4, // energy
+	0x4e: 4, // volume
+	0x4f: 4, // water
+	0x50: 4, // timestamp
+	0x51: 2, // acceleration
+	0x52: 2, // gyroscope
+	0x55: 4, // volume storage
+	0x56: 2, // conductivity
+	0x57: 1, // temperature
+	0x58: 1, // temperature
+	0x59: 1, // count
+	0x5a: 2, // count
+	0x5b: 4, // count
+	0x5c: 4, // power
+	0x5d: 2, // current
+	0x5e: 2, // direction
+	0x5f: 2, // precipitation
+	0x60: 1, // channel
+	0x61: 2, // rotational speed
+	0xf0: 2, // device type id
+	0xf1: 4, // firmware version
+	0xf2: 3, // firmware version
+}
+
+// decodeBTHome decodes BTHome v2 service data: a device information byte followed by objects, each an id and a
+// little-endian value.  Encrypted payloads aren't supported.
+func decodeBTHome(data []byte) (Reading, error) {
+	if len(data) == 0 {
+		return Reading{}, invalidLength("BTHome", data)
+	}
+
+	deviceInfo := data[0]
+
+	if deviceInfo>>5 != 2 {
+		return Reading{}, fmt.Errorf("%w: BTHome version %d", ErrUnsupported, deviceInfo>>5)
+	}
+
+	if deviceInfo&0x01 != 0 {
+		return Reading{}, ErrEncrypted
+	}
+
+	reading := Reading{Model: "BTHome"}
+	hasTemperature := false
+
+	for i := 1; i < len(data); {
+		objectId := data[i]
+		length, ok := bthomeObjectLengths[objectId]
+
+		// The binary sensors, 0x15 to 0x2d, are all one byte
+		if !ok && objectId >= 0x15 && objectId <= 0x2d {
+			length, ok = 1, true
+		}
+
+		if !ok {
+			break
+		}
+
+		if i+1+length > len(data) {
+			return Reading{}, fmt.Errorf("%w: truncated BTHome object 0x%02x", ErrUnsupported, objectId)
+		}
+
+		value := data[i+1 : i+1+length]
+
+		switch objectId {
+		case bthomeBattery:
+			reading.BatteryData.Level = int(value[0])
+		case bthomeTemperature:
+			hasTemperature = true
+			reading.SensorData.Temperature = float32(int16(littleEndian(value))) / 100
+		case bthomeTemperatureCoarse:
+			hasTemperature = true
+			reading.SensorData.Temperature = float32(int16(littleEndian(value))) / 10
+		case bthomeTemperatureInteger:
+			hasTemperature = true
+			reading.SensorData.Temperature = float32(int8(value[0]))
+		case bthomeHumidity:
+			reading.SensorData.HasHumidity = true
+			reading.SensorData.Humidity = float32(littleEndian(value)) / 100
+		case bthomeHumidityCoarse:
+			reading.SensorData.HasHumidity = true
+			reading.SensorData.Humidity = float32(value[0])
+		case bthomePressure:
+			reading.HasPressure = true
+			reading.Pressure = float32(littleEndian(value)) / 100
+		}
+
+		i = i + 1 + length
+	}
+
+	if !hasTemperature {
+		return Reading{}, ErrNotThermometer
+	}
+
+	return reading, nil
+}
+
+// littleEndian decodes an unsigned little-endian value of up to 4 bytes.
+func littleEndian(value []byte) uint32 {
+	var result uint32 = 0
+
+	for i := len(value) - 1; i >= 0; i = i - 1 {
+		result = result<<8 | uint32(value[i])
+	}
+
+	return result
+}
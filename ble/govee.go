@@ -0,0 +1,53 @@
+package ble
+
+import (
+	"encoding/binary"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// decodeGovee decodes the manufacturer data of Govee thermometers, after the company id.
+//
+// The H5075 (and H5072) sends 6 bytes: 0x00, then temperature and humidity packed in a big-endian 24-bit value, then
+// battery uint8 %, then 0x00.  The value is temperature * 10000 + humidity * 10, with the top bit set for negative
+// temperatures.
+//
+// The H5074 sends 7 bytes: 0x00, temperature int16 0.01°C, humidity uint16 0.01%, battery uint8 %, then 0x02, all
+// little-endian.
+func decodeGovee(data []byte) (Reading, error) {
+	switch len(data) {
+	case 6:
+		packed := uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+		negative := packed&0x800000 != 0
+		packed = packed &^ 0x800000
+
+		// The humidity shares the lowest digits with the temperature, which is truncated to 0.1°C
+		temperature := float32(packed/1000) / 10
+
+		if negative {
+			temperature = -temperature
+		}
+
+		return Reading{
+			Model: "H5075",
+			SensorData: spienvironment.SensorData{
+				Temperature: temperature,
+				HasHumidity: true,
+				Humidity:    float32(packed%1000) / 10,
+			},
+			BatteryData: spienvironment.BatteryData{Level: int(data[4])},
+		}, nil
+	case 7:
+		return Reading{
+			Model: "H5074",
+			SensorData: spienvironment.SensorData{
+				Temperature: float32(int16(binary.LittleEndian.Uint16(data[1:3]))) / 100,
+				HasHumidity: true,
+				Humidity:    float32(binary.LittleEndian.Uint16(data[3:5])) / 100,
+			},
+			BatteryData: spienvironment.BatteryData{Level: int(data[5])},
+		}, nil
+	default:
+		return Reading{}, invalidLength("Govee", data)
+	}
+}
@@ -0,0 +1,27 @@
+package ble
+
+import (
+	"encoding/binary"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// decodeInkbird decodes the manufacturer data of the Inkbird IBS-TH, which doesn't start with a company id.  It's 9
+// bytes, little-endian:
+//
+//	temperature int16 0.01°C, humidity uint16 0.01%, probe uint8, CRC uint16, battery uint8 %, type uint8
+func decodeInkbird(data []byte) (Reading, error) {
+	if len(data) != 9 {
+		return Reading{}, invalidLength("IBS-TH", data)
+	}
+
+	return Reading{
+		Model: "IBS-TH",
+		SensorData: spienvironment.SensorData{
+			Temperature: float32(int16(binary.LittleEndian.Uint16(data[0:2]))) / 100,
+			HasHumidity: true,
+			Humidity:    float32(binary.LittleEndian.Uint16(data[2:4])) / 100,
+		},
+		BatteryData: spienvironment.BatteryData{Level: int(data[7])},
+	}, nil
+}
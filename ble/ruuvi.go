@@ -0,0 +1,70 @@
+package ble
+
+import (
+	"encoding/binary"
+	"math"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// The battery voltages taken as empty and full.  RuuviTags only report the voltage of their CR2477.
+const (
+	ruuviBatteryEmptyMillivolts = 2000
+	ruuviBatteryFullMillivolts  = 3000
+)
+
+// decodeRuuvi decodes the manufacturer data of a RuuviTag, after the company id.  Only data format 5 (RAWv2) is
+// supported.  It's 24 bytes, big-endian:
+//
+//	format uint8 (5), temperature int16 0.005°C, humidity uint16 0.0025%, pressure uint16 Pa - 50000,
+//	acceleration x, y and z int16 mG, power uint16 (11 bits battery mV - 1600, 5 bits TX power), movement uint8,
+//	sequence uint16, MAC (6)
+//
+// Fields that aren't available are sent as their maximum value, or minimum for signed values.
+func decodeRuuvi(data []byte) (Reading, error) {
+	if len(data) == 0 || data[0] != 5 {
+		return Reading{}, ErrUnsupported
+	}
+
+	if len(data) != 24 {
+		return Reading{}, invalidLength("RuuviTag RAWv2", data)
+	}
+
+	rawTemperature := int16(binary.BigEndian.Uint16(data[1:3]))
+
+	if rawTemperature == math.MinInt16 {
+		return Reading{}, ErrNotThermometer
+	}
+
+	reading := Reading{
+		Model:   "RuuviTag",
+		Address: formatAddress(data[18:24]),
+		SensorData: spienvironment.SensorData{
+			Temperature: float32(rawTemperature) * 0.005,
+		},
+	}
+
+	if rawHumidity := binary.BigEndian.Uint16(data[3:5]); rawHumidity != math.MaxUint16 {
+		reading.SensorData.HasHumidity = true
+		reading.SensorData.Humidity = float32(rawHumidity) * 0.0025
+	}
+
+	if rawPressure := binary.BigEndian.Uint16(data[5:7]); rawPressure != math.MaxUint16 {
+		reading.HasPressure = true
+		reading.Pressure = (float32(rawPressure) + 50000) / 100
+	}
+
+	if rawBattery := binary.BigEndian.Uint16(data[13:15]) >> 5; rawBattery != 0x7ff {
+		reading.BatteryData.Level = batteryLevel(int(rawBattery) + 1600)
+	}
+
+	return reading, nil
+}
+
+// batteryLevel estimates the level of a RuuviTag battery, in percent.
+func batteryLevel(millivolts int) int {
+	level := float64(millivolts-ruuviBatteryEmptyMillivolts) * 100 /
+		(ruuviBatteryFullMillivolts - ruuviBatteryEmptyMillivolts)
+
+	return int(math.Round(math.Max(0, math.Min(100, level))))
+}
@@ -0,0 +1,45 @@
+package ble
+
+import (
+	"encoding/binary"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// decodeXiaomiCustom decodes the environmental sensing service data of the custom firmware for the Xiaomi
+// LYWSD03MMC.  The atc1441 format is 13 bytes, big-endian:
+//
+//	MAC (6), temperature int16 0.1°C, humidity uint8 %, battery uint8 %, battery uint16 mV, counter uint8
+//
+// The pvvx format is 15 bytes, little-endian:
+//
+//	MAC (6, reversed), temperature int16 0.01°C, humidity uint16 0.01%, battery uint16 mV, battery uint8 %,
+//	counter uint8, flags uint8
+func decodeXiaomiCustom(data []byte) (Reading, error) {
+	switch len(data) {
+	case 13:
+		return Reading{
+			Model:   "LYWSD03MMC (ATC)",
+			Address: formatAddress(data[0:6]),
+			SensorData: spienvironment.SensorData{
+				Temperature: float32(int16(binary.BigEndian.Uint16(data[6:8]))) / 10,
+				HasHumidity: true,
+				Humidity:    float32(data[8]),
+			},
+			BatteryData: spienvironment.BatteryData{Level: int(data[9])},
+		}, nil
+	case 15:
+		return Reading{
+			Model:   "LYWSD03MMC (pvvx)",
+			Address: formatAddress([]byte{data[5], data[4], data[3], data[2], data[1], data[0]}),
+			SensorData: spienvironment.SensorData{
+				Temperature: float32(int16(binary.LittleEndian.Uint16(data[6:8]))) / 100,
+				HasHumidity: true,
+				Humidity:    float32(binary.LittleEndian.Uint16(data[8:10])) / 100,
+			},
+			BatteryData: spienvironment.BatteryData{Level: int(data[12])},
+		}, nil
+	default:
+		return Reading{}, invalidLength("LYWSD03MMC", data)
+	}
+}
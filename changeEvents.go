@@ -0,0 +1,34 @@
+package environment
+
+import (
+	"github.com/avanha/pmaas-plugin-environment/internal/deadband"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+)
+
+// setEventGates configures the change events of a thermometer, using its override if there is one.  Thermometers
+// without any limits keep publishing every change.
+func (c *PluginConfig) setEventGates(wt *thermometer.WirelessThermometer) {
+	config, ok := c.ChangeEvents[wt.Name]
+
+	if !ok {
+		config = c.ChangeEvent
+	}
+
+	if config == (ChangeEventConfig{}) {
+		return
+	}
+
+	wt.SetEventGates(
+		deadband.Config{
+			Absolute:    config.TemperatureDeadband,
+			Relative:    config.RelativeDeadband,
+			MinInterval: config.MinInterval,
+			MaxInterval: config.HeartbeatInterval,
+		},
+		deadband.Config{
+			Absolute:    config.HumidityDeadband,
+			Relative:    config.RelativeDeadband,
+			MinInterval: config.MinInterval,
+			MaxInterval: config.HeartbeatInterval,
+		})
+}
@@ -0,0 +1,33 @@
+package environment
+
+import (
+	"html/template"
+	"time"
+
+	"github.com/avanha/pmaas-spi"
+)
+
+func (p *plugin) now() time.Time {
+	return p.state.clock.Now()
+}
+
+// withClock returns a copy of templateInfo whose time dependent functions use the plugin's clock instead of the
+// system clock.
+func (p *plugin) withClock(templateInfo *spi.TemplateInfo) *spi.TemplateInfo {
+	result := *templateInfo
+	result.FuncMap = make(template.FuncMap, len(templateInfo.FuncMap))
+
+	for name, fn := range templateInfo.FuncMap {
+		result.FuncMap[name] = fn
+	}
+
+	if _, ok := result.FuncMap["RelativeTime"]; ok {
+		result.FuncMap["RelativeTime"] = RelativeTimeFunc(p.state.clock)
+	}
+
+	if _, ok := result.FuncMap["Sparkline"]; ok {
+		result.FuncMap["Sparkline"] = SparklineFunc(p.state.clock)
+	}
+
+	return &result
+}
@@ -0,0 +1,75 @@
+// Package clock abstracts the current time, so the plugin and its tests can control it.  Pass a Fake as
+// PluginConfig.Clock to drive the daily extremes, relative times and controllers from a test.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock returns the current time.  Code that depends on the time takes a Clock, so tests can control it.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+// Fake is a Clock that only moves when told to.  It's safe for concurrent use.
+type Fake struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) Set(now time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.now = now
+}
+
+func (f *Fake) Advance(duration time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.now = f.now.Add(duration)
+}
+
+// SameDay reports whether b falls on the same calendar day as a, in a's location.
+func SameDay(a time.Time, b time.Time) bool {
+	aYear, aMonth, aDay := a.Date()
+	bYear, bMonth, bDay := b.In(a.Location()).Date()
+
+	return aYear == bYear && aMonth == bMonth && aDay == bDay
+}
+
+// DayStart returns the start of the day that now falls in, for days that start offset after local midnight.  The
+// offset is applied as a wall clock time, so a day that starts at 06:00 does so on both sides of a DST change.
+func DayStart(now time.Time, offset time.Duration) time.Time {
+	hour := int(offset / time.Hour)
+	minute := int(offset % time.Hour / time.Minute)
+	second := int(offset % time.Minute / time.Second)
+	year, month, day := now.Date()
+	start := time.Date(year, month, day, hour, minute, second, 0, now.Location())
+
+	if now.Before(start) {
+		start = time.Date(year, month, day-1, hour, minute, second, 0, now.Location())
+	}
+
+	return start
+}
@@ -0,0 +1,58 @@
+// Command environment-replay replays a recording of wireless thermometer state changes, made with the plugin's
+// RecordingPath option, and prints the published events and the final state of each thermometer.  Pass the plugin's
+// configuration, as the JSON encoding of an environment.PluginConfig, to replay with the same input filters,
+// smoothing and change event gates.  Durations are in nanoseconds, like their JSON encoding.
+//
+// Usage:
+//
+//	environment-replay [-events=false] [-config config.json] recording.jsonl
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	environment "github.com/avanha/pmaas-plugin-environment"
+)
+
+func main() {
+	printEvents := flag.Bool("events", true, "print the published events")
+	configPath := flag.String("config", "", "the plugin configuration, as JSON")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: environment-replay [-events=false] [-config config.json] recording.jsonl")
+		os.Exit(2)
+	}
+
+	config := environment.NewPluginConfig()
+
+	if *configPath != "" {
+		err := readConfig(*configPath, &config)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read %s: %v\n", *configPath, err)
+			os.Exit(1)
+		}
+	}
+
+	err := config.ReplayRecording(flag.Arg(0), *printEvents, os.Stdout)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to replay %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+// readConfig decodes the JSON file over the passed configuration, so fields the file leaves out keep their defaults.
+func readConfig(path string, config *environment.PluginConfig) error {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, config)
+}
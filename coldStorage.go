@@ -0,0 +1,121 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/coldstorage"
+	"github.com/avanha/pmaas-spi"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+var ColdStorageMonitorTemplate = spi.TemplateInfo{
+	Name: "environment_cold_storage_monitor",
+	FuncMap: template.FuncMap{
+		"FormatDuration": FormatDuration,
+		"RelativeTime":   RelativeTime,
+	},
+	Paths:  []string{"templates/cold_storage_monitor.htmlt"},
+	Styles: []string{"css/cold_storage_monitor.css"},
+}
+
+func (p *plugin) createColdStorageMonitors() {
+	for _, coldStorageConfig := range p.config.ColdStorage {
+		instance := coldstorage.CreateColdStorageMonitor(
+			p.state.nextEntityId(),
+			coldstorage.Settings{
+				Name:           coldStorageConfig.Name,
+				Input:          coldStorageConfig.InputThermometer,
+				MinTemperature: coldStorageConfig.MinTemperature,
+				MaxTemperature: coldStorageConfig.MaxTemperature,
+				DoorOpenRise:   coldStorageConfig.DoorOpenRise,
+				DoorOpenWindow: coldStorageConfig.DoorOpenWindow,
+				LogDays:        coldStorageConfig.LogDays,
+			},
+			tracking.Config{
+				TrackingMode:        tracking.ModePoll,
+				PollIntervalSeconds: 300,
+				Name:                buildTrackingName("ColdStorageMonitor", coldStorageConfig.Name),
+				Schema: tracking.Schema{
+					DataStructType:     data.ColdStorageDataType,
+					InsertArgFactoryFn: data.ColdStorageDataToInsertArgs,
+				},
+			})
+
+		var stubFactoryFn spi.EntityStubFactoryFunc = func() (any, error) {
+			return instance.GetStub(p.state.container), nil
+		}
+		p.state.controllers[instance.Id] = instance
+		pmaasEntityId, err := p.state.container.RegisterEntity(
+			instance.Id,
+			entities.ColdStorageMonitorType,
+			instance.Name,
+			stubFactoryFn)
+
+		if err == nil {
+			instance.PmaasEntityId = pmaasEntityId
+		} else {
+			fmt.Printf("ColdStorageMonitor %s could not be registered: %v\n", instance.Id, err)
+		}
+	}
+}
+
+func (p *plugin) coldStorageMonitorRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&ColdStorageMonitorTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*coldstorage.ColdStorageMonitor)
+			return ok
+		},
+		"*ColdStorageMonitor")
+}
+
+// handleHttpColdStorageLogRequest exports the daily compliance log of a cold storage monitor as CSV.
+func (p *plugin) handleHttpColdStorageLogRequest(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+
+	type result struct {
+		name string
+		log  []coldstorage.DailyLogEntry
+		err  error
+	}
+
+	logResult, err := spi.ExecValueFunctionOnPluginGoRoutine(
+		p.state.container,
+		func() result {
+			monitor, err := findController[*coldstorage.ColdStorageMonitor](p, id)
+
+			if err != nil {
+				return result{err: err}
+			}
+
+			return result{name: monitor.Name, log: slices.Clone(monitor.Log)}
+		},
+		func() result { return result{err: errors.New("unable to retrieve log")} },
+		"handleHttpColdStorageLogRequest")
+
+	if err == nil {
+		err = logResult.err
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	fileName := strings.ReplaceAll(buildTrackingName("ColdStorageLog", logResult.name), "\"", "")
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", fileName))
+	err = coldstorage.WriteLogCsv(w, logResult.log)
+
+	if err != nil {
+		fmt.Printf("%T handleHttpColdStorageLogRequest: Error writing log: %v\n", p, err)
+	}
+}
@@ -1,8 +1,677 @@
 package environment
 
+import (
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+)
+
+type Aggregation int
+
+const (
+	AggregationAverage Aggregation = iota
+	AggregationMinimum
+	AggregationMaximum
+)
+
+type ThermostatConfig struct {
+	// Name of the thermostat entity
+	Name string
+
+	// InputThermometers lists the names of the thermometers whose readings drive the thermostat.  Multiple inputs are
+	// combined into a single virtual reading using InputAggregation.
+	InputThermometers []string
+	InputAggregation  Aggregation
+
+	Mode         entities.ThermostatMode
+	HeatSetpoint float32
+	CoolSetpoint float32
+
+	// Deadband is the width of the band, centred on each setpoint, within which the outputs don't change.
+	Deadband float32
+
+	// MinOnTime and MinOffTime protect the equipment from short cycling.
+	MinOnTime  time.Duration
+	MinOffTime time.Duration
+
+	// HeatSwitchEntityId and CoolSwitchEntityId are the PMAAS IDs of the entities.Switch entities driving the
+	// heating and cooling equipment.  Leave empty if the equipment isn't present.
+	HeatSwitchEntityId string
+	CoolSwitchEntityId string
+
+	// Schedule is the name of the ScheduleConfig that drives the setpoints.  Leave empty to set them manually.
+	Schedule string
+}
+
+func NewThermostatConfig(name string, inputThermometers ...string) ThermostatConfig {
+	return ThermostatConfig{
+		Name:              name,
+		InputThermometers: inputThermometers,
+		InputAggregation:  AggregationAverage,
+		Mode:              entities.ThermostatModeOff,
+		HeatSetpoint:      20,
+		CoolSetpoint:      25,
+		Deadband:          1,
+		MinOnTime:         3 * time.Minute,
+		MinOffTime:        5 * time.Minute,
+	}
+}
+
+type HumidistatConfig struct {
+	// Name of the humidistat entity
+	Name string
+
+	// InputThermometer is the name of the thermometer whose humidity drives the humidistat.
+	InputThermometer string
+
+	Mode entities.HumidistatMode
+
+	// ControlVariable selects between controlling relative humidity, in percent, or dew point, in Celsius.
+	ControlVariable entities.HumidityVariable
+	Setpoint        float32
+
+	// Hysteresis is the width of the band, centred on the setpoint, within which the output doesn't change.
+	Hysteresis float32
+
+	MinOnTime time.Duration
+
+	// MinOffTime protects compressor-based equipment, by giving refrigerant pressures time to equalize before the
+	// next start.
+	MinOffTime time.Duration
+
+	// MaxRunTime limits how long the output runs continuously.  Once reached, the output is turned off for
+	// CooldownTime.  Zero disables the limit.  A MinOnTime longer than MaxRunTime is shortened to MaxRunTime.
+	MaxRunTime   time.Duration
+	CooldownTime time.Duration
+
+	// SwitchEntityId is the PMAAS ID of the entities.Switch entity powering the humidifier or dehumidifier.
+	SwitchEntityId string
+
+	// Schedule is the name of the ScheduleConfig that drives the setpoint.  Leave empty to set it manually.
+	Schedule string
+}
+
+func NewHumidistatConfig(name string, inputThermometer string) HumidistatConfig {
+	return HumidistatConfig{
+		Name:             name,
+		InputThermometer: inputThermometer,
+		Mode:             entities.HumidistatModeDehumidify,
+		ControlVariable:  entities.HumidityVariableRelativeHumidity,
+		Setpoint:         60,
+		Hysteresis:       5,
+		MinOnTime:        5 * time.Minute,
+		MinOffTime:       10 * time.Minute,
+		MaxRunTime:       4 * time.Hour,
+		CooldownTime:     30 * time.Minute,
+	}
+}
+
+type VentilationAdvisorConfig struct {
+	// Name of the ventilation advisor entity
+	Name               string
+	IndoorThermometer  string
+	OutdoorThermometer string
+
+	// ReduceHumidity and ReduceTemperature select what ventilation should achieve.  Humidity is compared as absolute
+	// humidity, since relative humidity changes as outdoor air warms up indoors.
+	ReduceHumidity    bool
+	ReduceTemperature bool
+
+	// TargetTemperature is the indoor temperature below which ventilating to cool down has no benefit.
+	TargetTemperature float32
+
+	// HumidityMargin, in g/m3, and TemperatureMargin, in Celsius, are the smallest indoor/outdoor differences that
+	// affect the recommendation.
+	HumidityMargin    float32
+	TemperatureMargin float32
+
+	// FanSwitchEntityId is the PMAAS ID of an optional entities.Switch entity that runs an exhaust fan while
+	// ventilation is recommended.
+	FanSwitchEntityId string
+	MinOnTime         time.Duration
+	MinOffTime        time.Duration
+}
+
+func NewVentilationAdvisorConfig(
+	name string,
+	indoorThermometer string,
+	outdoorThermometer string) VentilationAdvisorConfig {
+	return VentilationAdvisorConfig{
+		Name:               name,
+		IndoorThermometer:  indoorThermometer,
+		OutdoorThermometer: outdoorThermometer,
+		ReduceHumidity:     true,
+		ReduceTemperature:  true,
+		TargetTemperature:  21,
+		HumidityMargin:     1,
+		TemperatureMargin:  1,
+		MinOnTime:          10 * time.Minute,
+		MinOffTime:         10 * time.Minute,
+	}
+}
+
+// ColdStorageConfig is a monitoring profile for a freezer or refrigerator thermometer.
+type ColdStorageConfig struct {
+	// Name of the monitor entity
+	Name             string
+	InputThermometer string
+
+	// MinTemperature and MaxTemperature define the safe range, in Celsius.
+	MinTemperature float32
+	MaxTemperature float32
+
+	// A rise of DoorOpenRise degrees within DoorOpenWindow is reported as a door opening.  Zero disables detection.
+	DoorOpenRise   float32
+	DoorOpenWindow time.Duration
+
+	// LogDays is the number of days kept in the compliance log.
+	LogDays int
+}
+
+// NewFreezerConfig returns a profile for a freezer, which must stay at or below -18 C.
+func NewFreezerConfig(name string, inputThermometer string) ColdStorageConfig {
+	return ColdStorageConfig{
+		Name:             name,
+		InputThermometer: inputThermometer,
+		MinTemperature:   -30,
+		MaxTemperature:   -18,
+		DoorOpenRise:     3,
+		DoorOpenWindow:   5 * time.Minute,
+		LogDays:          90,
+	}
+}
+
+// NewRefrigeratorConfig returns a profile for a refrigerator, which must stay between 0 C and 5 C.
+func NewRefrigeratorConfig(name string, inputThermometer string) ColdStorageConfig {
+	return ColdStorageConfig{
+		Name:             name,
+		InputThermometer: inputThermometer,
+		MinTemperature:   0,
+		MaxTemperature:   5,
+		DoorOpenRise:     2,
+		DoorOpenWindow:   5 * time.Minute,
+		LogDays:          90,
+	}
+}
+
+type SchedulePeriod struct {
+	Name string
+
+	// Start is the local time of day at which the period begins, as an offset from midnight.
+	Start        time.Duration
+	HeatSetpoint float32
+	CoolSetpoint float32
+
+	// HumiditySetpoint is used by humidistats, in the unit of their control variable.
+	HumiditySetpoint float32
+}
+
+// ScheduleConfig describes a weekly setpoint program.  Each period lasts until the next one starts.
+type ScheduleConfig struct {
+	Name    string
+	Weekday []SchedulePeriod
+	Weekend []SchedulePeriod
+
+	// Days replaces the weekday or weekend program for specific days of the week.
+	Days map[time.Weekday][]SchedulePeriod
+}
+
+func NewScheduleConfig(name string) ScheduleConfig {
+	return ScheduleConfig{
+		Name:    name,
+		Weekday: make([]SchedulePeriod, 0),
+		Weekend: make([]SchedulePeriod, 0),
+		Days:    make(map[time.Weekday][]SchedulePeriod),
+	}
+}
+
+// MqttConfig enables publishing entity state and events to an MQTT broker.
+type MqttConfig struct {
+	// Broker is the TCP address of the broker, as host:port.  Leave empty to disable MQTT.
+	Broker   string
+	ClientId string
+	Username string
+	Password string
+
+	// TopicPrefix is prepended to all topics.  State is published to <prefix>/<entity>/state, events to
+	// <prefix>/<entity>/event/<event type> and availability to <prefix>/status.
+	TopicPrefix string
+
+	// QoS is used for all published messages, the last will and subscriptions, 0 or 1.  Higher values are lowered
+	// to 1.
+	QoS byte
+
+	// RetainState makes the broker keep the latest state of each entity for new subscribers.
+	RetainState bool
+
+	KeepAlive         time.Duration
+	MinReconnectDelay time.Duration
+	MaxReconnectDelay time.Duration
+
+	// HomeAssistantDiscovery publishes Home Assistant discovery configs for each wireless thermometer under
+	// DiscoveryPrefix, and re-publishes them when Home Assistant announces itself on <DiscoveryPrefix>/status.  The
+	// thermometers' state is also published to <prefix>/device/<node ID>/state, which doesn't change on rename.
+	HomeAssistantDiscovery bool
+	DiscoveryPrefix        string
+}
+
+func NewMqttConfig(broker string) MqttConfig {
+	return MqttConfig{
+		Broker:            broker,
+		ClientId:          "pmaas-environment",
+		TopicPrefix:       "pmaas/environment",
+		QoS:               1,
+		RetainState:       true,
+		KeepAlive:         60 * time.Second,
+		MinReconnectDelay: time.Second,
+		MaxReconnectDelay: 2 * time.Minute,
+		DiscoveryPrefix:   "homeassistant",
+	}
+}
+
+// InfluxConfig enables exporting thermometer readings as InfluxDB line protocol, either to an HTTP write endpoint
+// or to a local file.
+type InfluxConfig struct {
+	// URL is the full write endpoint including its query, for example
+	// http://localhost:8086/api/v2/write?org=home&bucket=environment&precision=ns.
+	URL   string
+	Token string
+
+	// FilePath receives the lines when URL is empty.  Leave both empty to disable the export.
+	FilePath string
+
+	// BufferPath receives the lines that couldn't be written while the endpoint is down.
+	BufferPath string
+
+	// MaxBufferSize limits the size of the buffer in bytes, 64 MiB if zero.  Lines that don't fit are dropped.
+	MaxBufferSize int64
+
+	Measurement   string
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	RetryDelay    time.Duration
+}
+
+func NewInfluxConfig(url string, token string) InfluxConfig {
+	return InfluxConfig{
+		URL:           url,
+		Token:         token,
+		Measurement:   "environment",
+		BatchSize:     500,
+		FlushInterval: 10 * time.Second,
+		MaxRetries:    3,
+		RetryDelay:    time.Second,
+	}
+}
+
+// HistoryConfig controls the readings each thermometer keeps in memory.  The most recent readings are kept as
+// received, bounded by MaxReadings and MaxRawAge.  Older ones are averaged over DownsampleInterval and kept until
+// they're MaxAge old.
+type HistoryConfig struct {
+	MaxReadings        int
+	MaxRawAge          time.Duration
+	DownsampleInterval time.Duration
+	MaxAge             time.Duration
+}
+
+// DisplayConfig holds the default display preferences.  Each can be overridden per request with the unit, decimals,
+// clock and locale query parameters, or cookies of the same names prefixed with "environment_".
+type DisplayConfig struct {
+	// TemperatureUnit is C, F or K.
+	TemperatureUnit string
+	Decimals        int
+	Use24HourClock  bool
+
+	// Locale, like en-US or de-DE, selects the decimal separator and date order.
+	Locale string
+}
+
+// InputFilterConfig configures the filters thermometer readings pass through before they update the state, to reject
+// corrupted readings.  Zero values disable the individual checks.
+type InputFilterConfig struct {
+	// Readings outside the bounds are rejected.  The bounds are only applied when the minimum is below the maximum.
+	MinTemperature float32
+	MaxTemperature float32
+	MinHumidity    float32
+	MaxHumidity    float32
+
+	// Readings that changed faster than the maximum rate since the last accepted reading, in units per minute, are
+	// rejected.  After a gap of more than 15 minutes, a larger change is accepted once the next reading confirms it.
+	MaxTemperatureRate float32
+	MaxHumidityRate    float32
+
+	// Readings that changed by more than the confirm delta since the last accepted reading are held until the next
+	// reading confirms them, by being within the delta of the held reading.
+	TemperatureConfirmDelta float32
+	HumidityConfirmDelta    float32
+
+	// MedianOf replaces each accepted reading with the median of the last MedianOf accepted readings.
+	MedianOf int
+}
+
+// NewInputFilterConfig returns a filter that rejects readings beyond the range of common consumer sensors, and
+// implausibly fast changes.
+func NewInputFilterConfig() InputFilterConfig {
+	return InputFilterConfig{
+		MinTemperature:     -39.9,
+		MaxTemperature:     70,
+		MinHumidity:        0.1,
+		MaxHumidity:        99.9,
+		MaxTemperatureRate: 2,
+		MaxHumidityRate:    10,
+	}
+}
+
+// ChangeEventConfig limits the temperature and humidity change events of a thermometer to significant changes.  The
+// zero value publishes every change.
+type ChangeEventConfig struct {
+	// Changes smaller than the deadband, in Celsius or percent, or than RelativeDeadband times the last published
+	// value, aren't published.  Changes are measured from the last published value, so slow drift is published once
+	// it adds up.
+	TemperatureDeadband float32
+	HumidityDeadband    float32
+	RelativeDeadband    float32
+
+	// MinInterval is the minimum time between events of each metric.
+	MinInterval time.Duration
+
+	// HeartbeatInterval publishes the current value when no event was published for this long, so consumers know the
+	// sensor is still reporting.  Zero disables heartbeats.
+	HeartbeatInterval time.Duration
+}
+
+type SmoothingMethod int
+
+const (
+	SmoothingNone SmoothingMethod = iota
+	SmoothingExponential
+	SmoothingMovingAverage
+	SmoothingKalman
+)
+
+// SmoothingConfig configures the smoothing of a thermometer's temperature and humidity readings.  The smoothed values
+// are shown alongside the raw ones.
+type SmoothingConfig struct {
+	Method SmoothingMethod
+
+	// Alpha is the weight of each new reading with SmoothingExponential, between 0 and 1.
+	Alpha float32
+
+	// Window is the number of readings averaged with SmoothingMovingAverage.
+	Window int
+
+	// ProcessNoise and MeasurementNoise are the variances of the actual value between readings, and of the sensor,
+	// with SmoothingKalman.  Higher measurement noise relative to process noise means more smoothing.
+	ProcessNoise     float32
+	MeasurementNoise float32
+
+	// UseSmoothedValues makes the smoothed values drive events, the daily extremes and tracking, instead of the raw
+	// readings.
+	UseSmoothedValues bool
+}
+
+func NewSmoothingConfig(method SmoothingMethod) SmoothingConfig {
+	return SmoothingConfig{
+		Method:            method,
+		Alpha:             0.3,
+		Window:            5,
+		ProcessNoise:      0.01,
+		MeasurementNoise:  0.1,
+		UseSmoothedValues: true,
+	}
+}
+
+// SimulatorConfig configures simulator mode, which registers synthetic wireless thermometers for demos and load
+// tests.
+type SimulatorConfig struct {
+	// Count is the number of synthetic thermometers.  Zero disables simulator mode.
+	Count int
+
+	// Interval is how often the thermometers report.
+	Interval time.Duration
+
+	// Seed makes the thermometers and their readings repeatable.  Zero picks a seed from the clock.
+	Seed uint64
+
+	// DropoutRate is the probability, between 0 and 1, that a thermometer misses a report.
+	DropoutRate float64
+
+	// BatteryDrainPerDay is the battery percentage each thermometer loses per day.
+	BatteryDrainPerDay float64
+}
+
+// Rtl433Config configures the ingest of wireless thermometers received by rtl_433, in its JSON output format
+// ("rtl_433 -F json").
+type Rtl433Config struct {
+	// Inputs are where the JSON lines are read from: the path of a file or named pipe, "-" for standard input,
+	// "tcp://host:port" for a TCP stream, or the URL of a streaming HTTP endpoint like rtl_433's /stream.
+	Inputs []string
+
+	// Names maps devices, identified as "model/id" or "model/id/channel", to thermometer names.  Devices that aren't
+	// listed are named after their identifier.
+	Names map[string]string
+
+	// RetryInterval is how long to wait before reopening a stream or named pipe that failed or ended.
+	RetryInterval time.Duration
+}
+
+func (c *Rtl433Config) AddInput(input string) {
+	c.Inputs = append(c.Inputs, input)
+}
+
+func (c *Rtl433Config) SetName(device string, name string) {
+	if c.Names == nil {
+		c.Names = make(map[string]string)
+	}
+
+	c.Names[device] = name
+}
+
+// WeatherStationConfig configures the ingest of weather station uploads, in the Ecowitt "customized server" and
+// Weather Underground formats, at /plugins/environment/weather.
+type WeatherStationConfig struct {
+	// StationIds limits uploads to the stations with these Ecowitt PASSKEYs or Weather Underground IDs.  Leave empty
+	// to accept all stations.
+	StationIds []string
+
+	// Password is required in Weather Underground uploads, unless empty.
+	Password string
+
+	// Names maps station ids to the names that prefix the names of their entities, like "Garden" for
+	// "Garden Outdoor", "Garden Barometer", "Garden Wind", "Garden Rain", "Garden UV" and "Garden Solar Radiation".
+	// Stations that aren't listed are named "Weather Station".
+	Names map[string]string
+
+	// RainResetTime is the local time of day at which the daily rain totals reset, as an offset from midnight.  Rain
+	// is often reported for the day ending at 07:00 or 09:00.
+	RainResetTime time.Duration
+}
+
+// Clock is the source of the current time.  It's satisfied by clock.Real, the system clock, and by clock.Fake in
+// tests.
+type Clock interface {
+	Now() time.Time
+}
+
 type PluginConfig struct {
+	Thermostats         []ThermostatConfig
+	Humidistats         []HumidistatConfig
+	VentilationAdvisors []VentilationAdvisorConfig
+	ColdStorage         []ColdStorageConfig
+	Schedules           []ScheduleConfig
+
+	// ControllerEvaluationInterval is how often controllers are re-evaluated in the absence of new readings.
+	ControllerEvaluationInterval time.Duration
+
+	Mqtt MqttConfig
+
+	Influx InfluxConfig
+
+	History HistoryConfig
+
+	Display DisplayConfig
+
+	// InputFilter applies to all wireless thermometers, except the ones listed by name in InputFilters.
+	InputFilter  InputFilterConfig
+	InputFilters map[string]InputFilterConfig
+
+	// ChangeEvent applies to all wireless thermometers, except the ones listed by name in ChangeEvents.
+	ChangeEvent  ChangeEventConfig
+	ChangeEvents map[string]ChangeEventConfig
+
+	// Smoothing maps thermometer names to the smoothing of their readings.  Thermometers that aren't listed aren't
+	// smoothed.
+	Smoothing map[string]SmoothingConfig
+
+	// Zones maps thermometer names to the zone they're in, for example "Upstairs" or "Outdoor".
+	Zones map[string]string
+
+	// Tags maps entity names to free-form tags used to filter the list page.
+	Tags map[string][]string
+
+	// RecordingPath enables appending every received thermometer state change to a JSON-lines file, which can be
+	// replayed with cmd/environment-replay to reproduce problems.  Leave empty to disable recording.
+	RecordingPath string
+
+	// Thermometers are shown as offline when no state was received for OfflineAfter, and battery levels at or
+	// below LowBatteryLevel percent are alerts.
+	OfflineAfter    time.Duration
+	LowBatteryLevel int
+
+	Simulator SimulatorConfig
+
+	Rtl433 Rtl433Config
+
+	WeatherStation WeatherStationConfig
+
+	// Clock timestamps readings and drives the daily extremes, relative times and controllers.  Leave nil to use the
+	// system clock.
+	Clock Clock
 }
 
 func NewPluginConfig() PluginConfig {
-	return PluginConfig{}
+	return PluginConfig{
+		Thermostats:                  make([]ThermostatConfig, 0),
+		Humidistats:                  make([]HumidistatConfig, 0),
+		VentilationAdvisors:          make([]VentilationAdvisorConfig, 0),
+		ColdStorage:                  make([]ColdStorageConfig, 0),
+		Schedules:                    make([]ScheduleConfig, 0),
+		ControllerEvaluationInterval: 30 * time.Second,
+		InputFilters:                 make(map[string]InputFilterConfig),
+		ChangeEvents:                 make(map[string]ChangeEventConfig),
+		Smoothing:                    make(map[string]SmoothingConfig),
+		Zones:                        make(map[string]string),
+		Tags:                         make(map[string][]string),
+		OfflineAfter:                 30 * time.Minute,
+		LowBatteryLevel:              15,
+		Rtl433: Rtl433Config{
+			Inputs:        make([]string, 0),
+			Names:         make(map[string]string),
+			RetryInterval: 10 * time.Second,
+		},
+		WeatherStation: WeatherStationConfig{
+			StationIds: make([]string, 0),
+			Names:      make(map[string]string),
+		},
+		Simulator: SimulatorConfig{
+			Interval:           30 * time.Second,
+			DropoutRate:        0.02,
+			BatteryDrainPerDay: 0.5,
+		},
+		History: HistoryConfig{
+			MaxReadings:        10000,
+			MaxRawAge:          24 * time.Hour,
+			DownsampleInterval: 15 * time.Minute,
+			MaxAge:             31 * 24 * time.Hour,
+		},
+		Display: DisplayConfig{
+			TemperatureUnit: "C",
+			Decimals:        2,
+			Locale:          "en-US",
+		},
+	}
+}
+
+func (c *PluginConfig) AddThermostat(thermostatConfig ThermostatConfig) {
+	c.Thermostats = append(c.Thermostats, thermostatConfig)
+}
+
+func (c *PluginConfig) AddHumidistat(humidistatConfig HumidistatConfig) {
+	c.Humidistats = append(c.Humidistats, humidistatConfig)
+}
+
+func (c *PluginConfig) AddVentilationAdvisor(ventilationAdvisorConfig VentilationAdvisorConfig) {
+	c.VentilationAdvisors = append(c.VentilationAdvisors, ventilationAdvisorConfig)
+}
+
+func (c *PluginConfig) AddColdStorage(coldStorageConfig ColdStorageConfig) {
+	c.ColdStorage = append(c.ColdStorage, coldStorageConfig)
+}
+
+// SetZone assigns the named thermometers to a zone.
+func (c *PluginConfig) SetZone(zone string, thermometerNames ...string) {
+	if c.Zones == nil {
+		c.Zones = make(map[string]string)
+	}
+
+	for _, name := range thermometerNames {
+		c.Zones[name] = zone
+	}
+}
+
+// SetInputFilter overrides the input filter of the named thermometers.
+func (c *PluginConfig) SetInputFilter(inputFilterConfig InputFilterConfig, thermometerNames ...string) {
+	if c.InputFilters == nil {
+		c.InputFilters = make(map[string]InputFilterConfig)
+	}
+
+	for _, name := range thermometerNames {
+		c.InputFilters[name] = inputFilterConfig
+	}
+}
+
+// SetChangeEvents overrides the change event config of the named thermometers.
+func (c *PluginConfig) SetChangeEvents(changeEventConfig ChangeEventConfig, thermometerNames ...string) {
+	if c.ChangeEvents == nil {
+		c.ChangeEvents = make(map[string]ChangeEventConfig)
+	}
+
+	for _, name := range thermometerNames {
+		c.ChangeEvents[name] = changeEventConfig
+	}
+}
+
+// SetSmoothing sets the smoothing of the named thermometers.
+func (c *PluginConfig) SetSmoothing(smoothingConfig SmoothingConfig, thermometerNames ...string) {
+	if c.Smoothing == nil {
+		c.Smoothing = make(map[string]SmoothingConfig)
+	}
+
+	for _, name := range thermometerNames {
+		c.Smoothing[name] = smoothingConfig
+	}
+}
+
+// configureThermometer applies the input filters, smoothing and event gates configured for the thermometer's name.
+// The plugin calls it for every thermometer it creates, and ReplayRecording for every replayed one, so replays
+// process readings the same way.
+func (c *PluginConfig) configureThermometer(wt *thermometer.WirelessThermometer) {
+	c.setInputFilters(wt)
+	c.setSmoothing(wt)
+	c.setEventGates(wt)
+}
+
+// AddTags adds tags to the named entity.
+func (c *PluginConfig) AddTags(entityName string, tags ...string) {
+	if c.Tags == nil {
+		c.Tags = make(map[string][]string)
+	}
+
+	c.Tags[entityName] = append(c.Tags[entityName], tags...)
+}
+
+func (c *PluginConfig) AddSchedule(scheduleConfig ScheduleConfig) {
+	c.Schedules = append(c.Schedules, scheduleConfig)
 }
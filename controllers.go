@@ -0,0 +1,110 @@
+package environment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/common"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// startControllerTimer periodically evaluates the controllers, so minimum cycle times expire even when the inputs
+// don't change.
+func (p *plugin) startControllerTimer() {
+	if len(p.state.controllers) == 0 || p.config.ControllerEvaluationInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.config.ControllerEvaluationInterval)
+	done := make(chan struct{})
+	p.state.stopControllerTimer = func() {
+		ticker.Stop()
+		close(done)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				err := p.state.container.EnqueueOnPluginGoRoutine(p.evaluateControllers)
+
+				if err != nil {
+					fmt.Printf("%T Unable to enqueue controller evaluation: %v\n", p, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *plugin) stopControllerTimer() {
+	if p.state.stopControllerTimer != nil {
+		p.state.stopControllerTimer()
+		p.state.stopControllerTimer = nil
+	}
+}
+
+func (p *plugin) evaluateControllers() {
+	now := p.now()
+
+	for _, c := range p.state.controllers {
+		c.Evaluate(now, p.lookupSensorData, p.broadcastEvent)
+
+		if controllerEntity, ok := c.(entities.Thermometer); ok {
+			p.publishMqttState(controllerEntity)
+		}
+	}
+}
+
+func (p *plugin) lookupSensorData(name string) (spienvironment.SensorData, bool) {
+	for _, stateTracker := range p.state.entities {
+		wt, ok := stateTracker.(*thermometer.WirelessThermometer)
+
+		if ok && wt.Name == name && !wt.SensorData.IsEmpty() {
+			return wt.SensorData, true
+		}
+	}
+
+	return spienvironment.SensorData{}, false
+}
+
+// invokeSwitch turns a switch entity on or off.  The switch is invoked on the goroutine of the plugin that owns it,
+// so failures at that point can only be logged.
+func (p *plugin) invokeSwitch(pmaasEntityId string, on bool) error {
+	return p.state.container.InvokeOnEntity(pmaasEntityId, func(entity any) {
+		switchEntity, ok := entity.(entities.Switch)
+
+		if !ok {
+			fmt.Printf("%T Entity %s is not a Switch, it's a %T\n", p, pmaasEntityId, entity)
+			return
+		}
+
+		err := switchEntity.SetOn(on)
+
+		if err != nil {
+			fmt.Printf("%T Unable to turn switch %s on=%v: %v\n", p, pmaasEntityId, on, err)
+		}
+	})
+}
+
+// findController returns the controller with the passed ID, if it's of the expected type.  Must be called on the
+// plugin goroutine.
+func findController[T common.IController](p *plugin, id string) (T, error) {
+	var result T
+	c, ok := p.state.controllers[id]
+
+	if !ok {
+		return result, fmt.Errorf("controller %s not found", id)
+	}
+
+	result, ok = c.(T)
+
+	if !ok {
+		return result, fmt.Errorf("controller %s is a %T, not a %T", id, c, result)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,19 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type BarometerData struct {
+	Pressure       float32   `track:"always"`
+	LastUpdateTime time.Time `track:"always"`
+}
+
+var BarometerDataType = reflect.TypeOf((*BarometerData)(nil)).Elem()
+
+func BarometerDataToInsertArgs(anyData *any) ([]any, error) {
+	bd := (*anyData).(BarometerData)
+
+	return []any{bd.Pressure, bd.LastUpdateTime}, nil
+}
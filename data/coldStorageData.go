@@ -0,0 +1,28 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type ColdStorageData struct {
+	HasTemperature             bool
+	Temperature                float32   `track:"always,nullable"`
+	InRange                    bool      `track:"always"`
+	DoorOpen                   bool      `track:"always"`
+	TimeOutOfRangeTodaySeconds int64     `track:"always"`
+	LastUpdateTime             time.Time `track:"always"`
+}
+
+var ColdStorageDataType = reflect.TypeOf((*ColdStorageData)(nil)).Elem()
+
+func ColdStorageDataToInsertArgs(anyData *any) ([]any, error) {
+	cd := (*anyData).(ColdStorageData)
+	var temperature any = nil
+
+	if cd.HasTemperature {
+		temperature = cd.Temperature
+	}
+
+	return []any{temperature, cd.InRange, cd.DoorOpen, cd.TimeOutOfRangeTodaySeconds, cd.LastUpdateTime}, nil
+}
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type HumidistatData struct {
+	HasInput            bool
+	Humidity            float32 `track:"always,nullable"`
+	HasDewPoint         bool
+	DewPoint            float32   `track:"always,nullable"`
+	Setpoint            float32   `track:"onchange"`
+	State               int32     `track:"always"`
+	RunTimeTodaySeconds int64     `track:"always"`
+	LastUpdateTime      time.Time `track:"always"`
+}
+
+var HumidistatDataType = reflect.TypeOf((*HumidistatData)(nil)).Elem()
+
+func HumidistatDataToInsertArgs(anyData *any) ([]any, error) {
+	hd := (*anyData).(HumidistatData)
+	var humidity any = nil
+	var dewPoint any = nil
+
+	if hd.HasInput {
+		humidity = hd.Humidity
+	}
+
+	if hd.HasInput && hd.HasDewPoint {
+		dewPoint = hd.DewPoint
+	}
+
+	return []any{humidity, dewPoint, hd.Setpoint, hd.State, hd.RunTimeTodaySeconds, hd.LastUpdateTime}, nil
+}
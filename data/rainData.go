@@ -0,0 +1,46 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type RainData struct {
+	HasRate        bool
+	Rate           float32 `track:"always,nullable"`
+	HasHourly      bool
+	Hourly         float32 `track:"always,nullable"`
+	HasToday       bool
+	Today          float32 `track:"always,nullable"`
+	HasEvent       bool
+	Event          float32   `track:"always,nullable"`
+	LastUpdateTime time.Time `track:"always"`
+}
+
+var RainDataType = reflect.TypeOf((*RainData)(nil)).Elem()
+
+func RainDataToInsertArgs(anyData *any) ([]any, error) {
+	rd := (*anyData).(RainData)
+	var rate any = nil
+	var hourly any = nil
+	var today any = nil
+	var event any = nil
+
+	if rd.HasRate {
+		rate = rd.Rate
+	}
+
+	if rd.HasHourly {
+		hourly = rd.Hourly
+	}
+
+	if rd.HasToday {
+		today = rd.Today
+	}
+
+	if rd.HasEvent {
+		event = rd.Event
+	}
+
+	return []any{rate, hourly, today, event, rd.LastUpdateTime}, nil
+}
@@ -0,0 +1,19 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type SolarRadiationData struct {
+	Radiation      float32   `track:"always"`
+	LastUpdateTime time.Time `track:"always"`
+}
+
+var SolarRadiationDataType = reflect.TypeOf((*SolarRadiationData)(nil)).Elem()
+
+func SolarRadiationDataToInsertArgs(anyData *any) ([]any, error) {
+	sd := (*anyData).(SolarRadiationData)
+
+	return []any{sd.Radiation, sd.LastUpdateTime}, nil
+}
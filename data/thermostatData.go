@@ -0,0 +1,29 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type ThermostatData struct {
+	HasTemperature bool
+	Temperature    float32   `track:"always,nullable"`
+	Mode           int32     `track:"onchange"`
+	Action         int32     `track:"always"`
+	HeatSetpoint   float32   `track:"onchange"`
+	CoolSetpoint   float32   `track:"onchange"`
+	LastUpdateTime time.Time `track:"always"`
+}
+
+var ThermostatDataType = reflect.TypeOf((*ThermostatData)(nil)).Elem()
+
+func ThermostatDataToInsertArgs(anyData *any) ([]any, error) {
+	td := (*anyData).(ThermostatData)
+	var temperature any = nil
+
+	if td.HasTemperature {
+		temperature = td.Temperature
+	}
+
+	return []any{temperature, td.Mode, td.Action, td.HeatSetpoint, td.CoolSetpoint, td.LastUpdateTime}, nil
+}
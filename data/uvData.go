@@ -0,0 +1,19 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type UVData struct {
+	UVIndex        float32   `track:"always"`
+	LastUpdateTime time.Time `track:"always"`
+}
+
+var UVDataType = reflect.TypeOf((*UVData)(nil)).Elem()
+
+func UVDataToInsertArgs(anyData *any) ([]any, error) {
+	ud := (*anyData).(UVData)
+
+	return []any{ud.UVIndex, ud.LastUpdateTime}, nil
+}
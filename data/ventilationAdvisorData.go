@@ -0,0 +1,42 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type VentilationAdvisorData struct {
+	HasInput                bool
+	IndoorTemperature       float32   `track:"always,nullable"`
+	OutdoorTemperature      float32   `track:"always,nullable"`
+	IndoorAbsoluteHumidity  float32   `track:"always,nullable"`
+	OutdoorAbsoluteHumidity float32   `track:"always,nullable"`
+	Recommendation          int32     `track:"always"`
+	LastUpdateTime          time.Time `track:"always"`
+}
+
+var VentilationAdvisorDataType = reflect.TypeOf((*VentilationAdvisorData)(nil)).Elem()
+
+func VentilationAdvisorDataToInsertArgs(anyData *any) ([]any, error) {
+	vd := (*anyData).(VentilationAdvisorData)
+	var indoorTemperature any = nil
+	var outdoorTemperature any = nil
+	var indoorAbsoluteHumidity any = nil
+	var outdoorAbsoluteHumidity any = nil
+
+	if vd.HasInput {
+		indoorTemperature = vd.IndoorTemperature
+		outdoorTemperature = vd.OutdoorTemperature
+		indoorAbsoluteHumidity = vd.IndoorAbsoluteHumidity
+		outdoorAbsoluteHumidity = vd.OutdoorAbsoluteHumidity
+	}
+
+	return []any{
+		indoorTemperature,
+		outdoorTemperature,
+		indoorAbsoluteHumidity,
+		outdoorAbsoluteHumidity,
+		vd.Recommendation,
+		vd.LastUpdateTime,
+	}, nil
+}
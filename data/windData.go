@@ -0,0 +1,34 @@
+package data
+
+import (
+	"reflect"
+	"time"
+)
+
+type WindData struct {
+	Speed          float32 `track:"always"`
+	HasGust        bool
+	Gust           float32 `track:"always,nullable"`
+	HasDirection   bool
+	Direction      int32     `track:"always,nullable"`
+	MaxGustToday   float32   `track:"always"`
+	LastUpdateTime time.Time `track:"always"`
+}
+
+var WindDataType = reflect.TypeOf((*WindData)(nil)).Elem()
+
+func WindDataToInsertArgs(anyData *any) ([]any, error) {
+	wd := (*anyData).(WindData)
+	var gust any = nil
+	var direction any = nil
+
+	if wd.HasGust {
+		gust = wd.Gust
+	}
+
+	if wd.HasDirection {
+		direction = wd.Direction
+	}
+
+	return []any{wd.Speed, gust, direction, wd.MaxGustToday, wd.LastUpdateTime}, nil
+}
@@ -9,6 +9,7 @@ type WirelessThermometerData struct {
 	Temperature    float32 `track:"always"`
 	HasHumidity    bool
 	Humidity       float32   `track:"always,nullable"`
+	DewPoint       *float32  `json:",omitempty"`
 	BatteryLevel   int32     `track:"onchange,nullable"`
 	RSSI           int32     `track:"always,nullable"`
 	LastUpdateTime time.Time `track:"always"`
@@ -0,0 +1,285 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/chart"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-plugin-environment/internal/history"
+	"github.com/avanha/pmaas-plugin-environment/internal/psychrometrics"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	"github.com/avanha/pmaas-spi"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+const entityDetailPath = "/plugins/environment/entity/"
+
+var WirelessThermometerDetailTemplate = spi.TemplateInfo{
+	Name: "environment_wireless_thermometer_detail",
+	FuncMap: template.FuncMap{
+		"CelsiusToFahrenheit": CelsiusToFahrenheit,
+		"RelativeTime":        RelativeTime,
+	},
+	Paths:  []string{"templates/wireless_thermometer_detail.htmlt"},
+	Styles: []string{"css/wireless_thermometer_detail.css"},
+}
+
+// detailRange is a time range the detail page can show.  Step is the interval readings are averaged over, to keep
+// the number of chart points reasonable.
+type detailRange struct {
+	Name     string
+	Duration time.Duration
+	Step     time.Duration
+}
+
+var detailRanges = []detailRange{
+	{Name: "24h", Duration: 24 * time.Hour, Step: 5 * time.Minute},
+	{Name: "7d", Duration: 7 * 24 * time.Hour, Step: 30 * time.Minute},
+	{Name: "30d", Duration: 30 * 24 * time.Hour, Step: 2 * time.Hour},
+}
+
+// WirelessThermometerDetail is the view model of the thermometer detail page.
+type WirelessThermometerDetail struct {
+	thermometer.WirelessThermometer
+	Zone             string
+	Tracking         tracking.Config
+	HistoryConfig    HistoryConfig
+	Range            string
+	Ranges           []string
+	TemperatureChart template.HTML
+	HumidityChart    template.HTML
+	BatteryChart     template.HTML
+	RSSIChart        template.HTML
+	Extremes         []DetailExtremes
+}
+
+// DetailExtremes holds the extremes of one of the detail ranges.
+type DetailExtremes struct {
+	history.Summary
+	Range string
+}
+
+// handleHttpEntityRequest renders the detail page of a thermometer, /plugins/environment/entity/{id}?range=24h|7d|30d.
+func (p *plugin) handleHttpEntityRequest(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, entityDetailPath), "/")
+	preferences, err := p.displayPreferences(w, r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	selectedRange := detailRanges[0]
+
+	if rangeName := r.URL.Query().Get("range"); rangeName != "" {
+		found := false
+
+		for _, candidate := range detailRanges {
+			if candidate.Name == rangeName {
+				selectedRange = candidate
+				found = true
+			}
+		}
+
+		if !found {
+			http.Error(w, fmt.Sprintf("unknown range %q", rangeName), http.StatusBadRequest)
+			return
+		}
+	}
+
+	type result struct {
+		thermometer thermometer.WirelessThermometer
+		readings    []history.Reading
+		err         error
+	}
+
+	now := p.now()
+	longestRange := detailRanges[len(detailRanges)-1].Duration
+	entityResult, err := spi.ExecValueFunctionOnPluginGoRoutine(
+		p.state.container,
+		func() result {
+			thermometers := p.findWirelessThermometers([]string{id})
+
+			for entityId, wt := range thermometers {
+				return result{
+					thermometer: wt.GetState().(thermometer.WirelessThermometer),
+					readings:    p.getHistory(entityId, now.Add(-longestRange), time.Time{}, 0),
+				}
+			}
+
+			return result{err: fmt.Errorf("thermometer %s not found", id)}
+		},
+		func() result { return result{err: errors.New("unable to retrieve thermometer")} },
+		"handleHttpEntityRequest")
+
+	if err == nil {
+		err = entityResult.err
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	detail := p.buildWirelessThermometerDetail(
+		entityResult.thermometer, entityResult.readings, selectedRange, now, preferences)
+	p.state.container.RenderList(w, r, spi.RenderListOptions{Title: detail.GetSortKey()}, []any{&detail})
+}
+
+func (p *plugin) buildWirelessThermometerDetail(
+	wt thermometer.WirelessThermometer,
+	readings []history.Reading,
+	selectedRange detailRange,
+	now time.Time,
+	preferences display.Preferences) WirelessThermometerDetail {
+	wt.Display = preferences
+	unit := preferences.TemperatureUnit
+	detail := WirelessThermometerDetail{
+		WirelessThermometer: wt,
+		Zone:                p.config.Zones[wt.Name],
+		Tracking:            wt.TrackingConfig(),
+		HistoryConfig:       p.config.History,
+		Range:               selectedRange.Name,
+		Ranges:              make([]string, len(detailRanges)),
+		Extremes:            make([]DetailExtremes, len(detailRanges)),
+	}
+
+	for i, candidate := range detailRanges {
+		detail.Ranges[i] = candidate.Name
+		detail.Extremes[i] = DetailExtremes{
+			Summary: history.Summarize(readingsSince(readings, now.Add(-candidate.Duration))),
+			Range:   candidate.Name,
+		}
+	}
+
+	from := now.Add(-selectedRange.Duration)
+	chartReadings := history.Downsample(readingsSince(readings, from), selectedRange.Step)
+	newChart := func(series ...chart.Series) chart.Chart {
+		return chart.Chart{
+			Width:     800,
+			Height:    240,
+			From:      from,
+			To:        now,
+			Series:    series,
+			MaxGap:    max(3*selectedRange.Step, 30*time.Minute),
+			TimeLabel: preferences.Time,
+			DateLabel: preferences.Date,
+		}
+	}
+
+	temperature := chart.Series{Name: "Temperature", Class: "temperature", Points: make([]chart.Point, 0)}
+	dewPoint := chart.Series{Name: "Dew point", Class: "dew-point", Points: make([]chart.Point, 0)}
+	humidity := chart.Series{Name: "Humidity", Class: "humidity", Points: make([]chart.Point, 0)}
+	battery := chart.Series{Name: "Battery", Class: "battery", Points: make([]chart.Point, 0)}
+	rssi := chart.Series{Name: "RSSI", Class: "rssi", Points: make([]chart.Point, 0)}
+
+	for _, reading := range chartReadings {
+		temperature.Points = append(temperature.Points,
+			chart.Point{Time: reading.Time, Value: float64(unit.FromCelsius(reading.Temperature))})
+
+		if reading.HasHumidity {
+			humidity.Points = append(humidity.Points,
+				chart.Point{Time: reading.Time, Value: float64(reading.Humidity)})
+
+			if value, ok := psychrometrics.DewPoint(reading.Temperature, reading.Humidity); ok {
+				dewPoint.Points = append(dewPoint.Points,
+					chart.Point{Time: reading.Time, Value: float64(unit.FromCelsius(value))})
+			}
+		}
+
+		if reading.BatteryLevel != 0 {
+			battery.Points = append(battery.Points, chart.Point{Time: reading.Time, Value: float64(reading.BatteryLevel)})
+		}
+
+		if reading.RSSI != 0 {
+			rssi.Points = append(rssi.Points, chart.Point{Time: reading.Time, Value: float64(reading.RSSI)})
+		}
+	}
+
+	temperatureChart := newChart(temperature, dewPoint)
+	selectedExtremes := history.Summarize(readingsSince(readings, from))
+
+	if selectedExtremes.HasData {
+		temperatureChart.Markers = []chart.Marker{
+			{
+				Point: chart.Point{
+					Time:  selectedExtremes.MaxTemperatureTime,
+					Value: float64(unit.FromCelsius(selectedExtremes.MaxTemperature)),
+				},
+				Class: "high",
+				Label: "High " + preferences.Temperature(selectedExtremes.MaxTemperature),
+			},
+			{
+				Point: chart.Point{
+					Time:  selectedExtremes.MinTemperatureTime,
+					Value: float64(unit.FromCelsius(selectedExtremes.MinTemperature)),
+				},
+				Class: "low",
+				Label: "Low " + preferences.Temperature(selectedExtremes.MinTemperature),
+			},
+		}
+	}
+
+	detail.TemperatureChart = template.HTML(temperatureChart.Render())
+
+	if wt.SensorData.HasHumidity {
+		detail.HumidityChart = template.HTML(newChart(humidity).Render())
+	}
+
+	if !wt.BatteryData.IsEmpty() {
+		batteryChart := newChart(battery)
+		batteryChart.ValueFormat = "%.0f"
+		detail.BatteryChart = template.HTML(batteryChart.Render())
+	}
+
+	if !wt.RSSIData.IsEmpty() {
+		rssiChart := newChart(rssi)
+		rssiChart.ValueFormat = "%.0f"
+		detail.RSSIChart = template.HTML(rssiChart.Render())
+	}
+
+	return detail
+}
+
+// readingsSince returns the readings at or after from.  Readings are in time order.
+func readingsSince(readings []history.Reading, from time.Time) []history.Reading {
+	for i, reading := range readings {
+		if !reading.Time.Before(from) {
+			return readings[i:]
+		}
+	}
+
+	return readings[len(readings):]
+}
+
+func (p *plugin) wirelessThermometerDetailRendererFactory() (spi.EntityRenderer, error) {
+	t, err := p.state.container.GetTemplate(p.withClock(&WirelessThermometerDetailTemplate))
+
+	if err != nil {
+		return spi.EntityRenderer{}, fmt.Errorf("unable to load wireless_thermometer_detail template: %v", err)
+	}
+
+	renderer := func(w io.Writer, entity any) error {
+		detail, ok := entity.(*WirelessThermometerDetail)
+
+		if !ok {
+			return errors.New("item is not an instance of *WirelessThermometerDetail")
+		}
+
+		err := t.Instance.Execute(w, detail)
+
+		if err != nil {
+			return fmt.Errorf("unable to execute wireless_thermometer_detail template: %w", err)
+		}
+
+		return nil
+	}
+
+	return spi.EntityRenderer{StreamingRenderFunc: renderer, Styles: t.Styles, Scripts: t.Scripts}, nil
+}
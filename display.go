@@ -0,0 +1,132 @@
+package environment
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/coldstorage"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-plugin-environment/internal/humidistat"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermostat"
+	"github.com/avanha/pmaas-plugin-environment/internal/units"
+	"github.com/avanha/pmaas-plugin-environment/internal/ventilation"
+)
+
+const displayCookiePrefix = "environment_"
+const maxDisplayDecimals = 4
+
+// displayPreferences returns the display preferences for a request.  The configured defaults are overridden by
+// cookies, which are overridden by query parameters.  Passing save=1 with query parameters stores them in cookies.
+func (p *plugin) displayPreferences(w http.ResponseWriter, r *http.Request) (display.Preferences, error) {
+	config := p.config.Display
+	preferences := display.Preferences{
+		TemperatureUnit: units.Celsius,
+		Decimals:        config.Decimals,
+		Use24HourClock:  config.Use24HourClock,
+		Locale:          "en-US",
+	}
+
+	if unit, err := units.ParseTemperatureUnit(config.TemperatureUnit); err == nil {
+		preferences.TemperatureUnit = unit
+	}
+
+	if locale, err := display.ParseLocale(config.Locale); err == nil {
+		preferences.Locale = locale
+	}
+
+	query := r.URL.Query()
+	save := query.Get("save") == "1"
+
+	for _, name := range []string{"unit", "decimals", "clock", "locale"} {
+		value, fromQuery := "", false
+
+		if query.Has(name) {
+			value, fromQuery = query.Get(name), true
+		} else if cookie, err := r.Cookie(displayCookiePrefix + name); err == nil {
+			value = cookie.Value
+		} else {
+			continue
+		}
+
+		err := applyDisplayPreference(&preferences, name, value)
+
+		if err != nil {
+			if fromQuery {
+				return preferences, err
+			}
+
+			// Ignore stale or tampered cookies
+			continue
+		}
+
+		if fromQuery && save && w != nil {
+			http.SetCookie(w, &http.Cookie{
+				Name:     displayCookiePrefix + name,
+				Value:    value,
+				Path:     "/plugins/environment/",
+				Expires:  p.now().AddDate(1, 0, 0),
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+
+	return preferences, nil
+}
+
+func applyDisplayPreference(preferences *display.Preferences, name string, value string) error {
+	switch name {
+	case "unit":
+		unit, err := units.ParseTemperatureUnit(value)
+
+		if err != nil {
+			return err
+		}
+
+		preferences.TemperatureUnit = unit
+	case "decimals":
+		decimals, err := strconv.Atoi(value)
+
+		if err != nil || decimals < 0 || decimals > maxDisplayDecimals {
+			return fmt.Errorf("decimals must be between 0 and %d, not %q", maxDisplayDecimals, value)
+		}
+
+		preferences.Decimals = decimals
+	case "clock":
+		if value != "12" && value != "24" {
+			return fmt.Errorf("clock must be 12 or 24, not %q", value)
+		}
+
+		preferences.Use24HourClock = value == "24"
+	case "locale":
+		locale, err := display.ParseLocale(value)
+
+		if err != nil {
+			return err
+		}
+
+		preferences.Locale = locale
+	}
+
+	return nil
+}
+
+// withDisplayPreferences sets the display preferences of a controller state copy that is about to be rendered.
+func withDisplayPreferences(state any, preferences display.Preferences) any {
+	switch typedState := state.(type) {
+	case thermostat.Thermostat:
+		typedState.Display = preferences
+		return typedState
+	case humidistat.Humidistat:
+		typedState.Display = preferences
+		return typedState
+	case coldstorage.ColdStorageMonitor:
+		typedState.Display = preferences
+		return typedState
+	case ventilation.VentilationAdvisor:
+		typedState.Display = preferences
+		return typedState
+	}
+
+	return state
+}
@@ -0,0 +1,11 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type Barometer interface {
+	WeatherSensor
+}
+
+var BarometerType = reflect.TypeOf((*Barometer)(nil)).Elem()
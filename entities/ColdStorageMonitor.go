@@ -0,0 +1,11 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type ColdStorageMonitor interface {
+	Thermometer
+}
+
+var ColdStorageMonitorType = reflect.TypeOf((*ColdStorageMonitor)(nil)).Elem()
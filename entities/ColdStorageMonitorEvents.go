@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/avanha/pmaas-spi/events"
+)
+
+// ColdStorageRangeChangeEvent is published when the temperature leaves or returns to the safe range.
+type ColdStorageRangeChangeEvent struct {
+	events.EntityEvent
+	InRange     bool
+	Temperature float32
+}
+
+// ColdStorageDoorChangeEvent is published when a door opening is detected from a sudden temperature rise, and when
+// the temperature starts recovering.
+type ColdStorageDoorChangeEvent struct {
+	events.EntityEvent
+	DoorOpen bool
+	OpenedAt time.Time
+}
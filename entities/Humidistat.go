@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type HumidistatMode int
+
+const (
+	HumidistatModeOff HumidistatMode = iota
+	HumidistatModeDehumidify
+	HumidistatModeHumidify
+)
+
+func (m HumidistatMode) String() string {
+	switch m {
+	case HumidistatModeOff:
+		return "off"
+	case HumidistatModeDehumidify:
+		return "dehumidify"
+	case HumidistatModeHumidify:
+		return "humidify"
+	}
+
+	return "unknown"
+}
+
+// ParseHumidistatMode converts the string form of a mode, as returned by HumidistatMode.String, back to a mode.
+func ParseHumidistatMode(value string) (HumidistatMode, bool) {
+	for _, mode := range []HumidistatMode{HumidistatModeOff, HumidistatModeDehumidify, HumidistatModeHumidify} {
+		if mode.String() == value {
+			return mode, true
+		}
+	}
+
+	return HumidistatModeOff, false
+}
+
+// HumidityVariable selects the measurement a Humidistat controls.
+type HumidityVariable int
+
+const (
+	HumidityVariableRelativeHumidity HumidityVariable = iota
+	HumidityVariableDewPoint
+)
+
+func (v HumidityVariable) String() string {
+	switch v {
+	case HumidityVariableRelativeHumidity:
+		return "relative humidity"
+	case HumidityVariableDewPoint:
+		return "dew point"
+	}
+
+	return "unknown"
+}
+
+type HumidistatState int
+
+const (
+	HumidistatStateIdle HumidistatState = iota
+	HumidistatStateRunning
+	HumidistatStateCooldown
+)
+
+func (s HumidistatState) String() string {
+	switch s {
+	case HumidistatStateIdle:
+		return "idle"
+	case HumidistatStateRunning:
+		return "running"
+	case HumidistatStateCooldown:
+		return "cooldown"
+	}
+
+	return "unknown"
+}
+
+type Humidistat interface {
+	Thermometer
+}
+
+var HumidistatType = reflect.TypeOf((*Humidistat)(nil)).Elem()
@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"github.com/avanha/pmaas-spi/events"
+)
+
+type HumidistatModeChangeEvent struct {
+	events.EntityEvent
+	NewMode HumidistatMode
+	OldMode HumidistatMode
+}
+
+type HumidistatSetpointChangeEvent struct {
+	events.EntityEvent
+	NewValue float32
+	OldValue float32
+}
+
+type HumidistatStateChangeEvent struct {
+	events.EntityEvent
+	NewState HumidistatState
+	OldState HumidistatState
+}
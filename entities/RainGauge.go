@@ -0,0 +1,11 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type RainGauge interface {
+	WeatherSensor
+}
+
+var RainGaugeType = reflect.TypeOf((*RainGauge)(nil)).Elem()
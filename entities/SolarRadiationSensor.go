@@ -0,0 +1,11 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type SolarRadiationSensor interface {
+	WeatherSensor
+}
+
+var SolarRadiationSensorType = reflect.TypeOf((*SolarRadiationSensor)(nil)).Elem()
@@ -0,0 +1,11 @@
+package entities
+
+import "reflect"
+
+// Switch is implemented by entities that can be turned on and off.  Controllers in this plugin drive their outputs
+// by invoking SetOn on a Switch entity through the container.
+type Switch interface {
+	SetOn(on bool) error
+}
+
+var SwitchType = reflect.TypeOf((*Switch)(nil)).Elem()
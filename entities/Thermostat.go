@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type ThermostatMode int
+
+const (
+	ThermostatModeOff ThermostatMode = iota
+	ThermostatModeHeat
+	ThermostatModeCool
+	ThermostatModeAuto
+)
+
+func (m ThermostatMode) String() string {
+	switch m {
+	case ThermostatModeOff:
+		return "off"
+	case ThermostatModeHeat:
+		return "heat"
+	case ThermostatModeCool:
+		return "cool"
+	case ThermostatModeAuto:
+		return "auto"
+	}
+
+	return "unknown"
+}
+
+// ParseThermostatMode converts the string form of a mode, as returned by ThermostatMode.String, back to a mode.
+func ParseThermostatMode(value string) (ThermostatMode, bool) {
+	for _, mode := range []ThermostatMode{
+		ThermostatModeOff, ThermostatModeHeat, ThermostatModeCool, ThermostatModeAuto} {
+		if mode.String() == value {
+			return mode, true
+		}
+	}
+
+	return ThermostatModeOff, false
+}
+
+type ThermostatAction int
+
+const (
+	ThermostatActionIdle ThermostatAction = iota
+	ThermostatActionHeating
+	ThermostatActionCooling
+)
+
+func (a ThermostatAction) String() string {
+	switch a {
+	case ThermostatActionIdle:
+		return "idle"
+	case ThermostatActionHeating:
+		return "heating"
+	case ThermostatActionCooling:
+		return "cooling"
+	}
+
+	return "unknown"
+}
+
+type Thermostat interface {
+	Thermometer
+}
+
+var ThermostatType = reflect.TypeOf((*Thermostat)(nil)).Elem()
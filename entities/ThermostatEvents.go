@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"github.com/avanha/pmaas-spi/events"
+)
+
+type ThermostatModeChangeEvent struct {
+	events.EntityEvent
+	NewMode ThermostatMode
+	OldMode ThermostatMode
+}
+
+type ThermostatSetpointChangeEvent struct {
+	events.EntityEvent
+	HeatSetpoint    float32
+	CoolSetpoint    float32
+	OldHeatSetpoint float32
+	OldCoolSetpoint float32
+}
+
+type ThermostatActionChangeEvent struct {
+	events.EntityEvent
+	NewAction ThermostatAction
+	OldAction ThermostatAction
+}
@@ -0,0 +1,11 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type UVSensor interface {
+	WeatherSensor
+}
+
+var UVSensorType = reflect.TypeOf((*UVSensor)(nil)).Elem()
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type VentilationRecommendation int
+
+const (
+	VentilationRecommendationNeutral VentilationRecommendation = iota
+	VentilationRecommendationOpen
+	VentilationRecommendationClose
+)
+
+func (r VentilationRecommendation) String() string {
+	switch r {
+	case VentilationRecommendationNeutral:
+		return "neutral"
+	case VentilationRecommendationOpen:
+		return "open"
+	case VentilationRecommendationClose:
+		return "close"
+	}
+
+	return "unknown"
+}
+
+type VentilationAdvisor interface {
+	Thermometer
+}
+
+var VentilationAdvisorType = reflect.TypeOf((*VentilationAdvisor)(nil)).Elem()
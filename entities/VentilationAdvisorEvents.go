@@ -0,0 +1,11 @@
+package entities
+
+import (
+	"github.com/avanha/pmaas-spi/events"
+)
+
+type VentilationRecommendationChangeEvent struct {
+	events.EntityEvent
+	NewValue VentilationRecommendation
+	OldValue VentilationRecommendation
+}
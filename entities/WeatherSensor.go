@@ -0,0 +1,12 @@
+package entities
+
+import (
+	"github.com/avanha/pmaas-plugin-environment/internal/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+// WeatherSensor is implemented by the entities of a weather station, other than its outdoor thermometer.
+type WeatherSensor interface {
+	tracking.Trackable
+	common.ISortable
+}
@@ -0,0 +1,11 @@
+package entities
+
+import (
+	"reflect"
+)
+
+type WindSensor interface {
+	WeatherSensor
+}
+
+var WindSensorType = reflect.TypeOf((*WindSensor)(nil)).Elem()
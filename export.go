@@ -0,0 +1,61 @@
+package environment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/history"
+)
+
+// handleHttpExportRequest exports the history of one or more thermometers as CSV or JSON.  Query parameters:
+//   - entity: the name or ID of a thermometer, repeated or comma-separated.  Defaults to all thermometers.
+//   - from, to: RFC 3339 times, or local times in the "2006-01-02T15:04" or "2006-01-02" layouts.  Defaults to the
+//     last 24 hours.
+//   - format: csv (the default) or json.
+//   - unit, decimals and locale: override the display preferences.  Locales with a decimal comma get
+//     semicolon-separated CSV.
+//   - tz: an IANA time zone name for timestamps and local times.  Defaults to the server's time zone.
+//   - step: an optional duration like "15m" to average readings over.
+func (p *plugin) handleHttpExportRequest(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+
+	if format == "" {
+		format = "csv"
+	} else if format != "csv" && format != "json" {
+		http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	request, err := p.parseHistoryRequest(w, r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, err := p.getHistorySeriesOnPluginGoRoutine(request)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	location := request.options.Location
+	fileName := fmt.Sprintf("environment_%s_%s",
+		request.from.In(location).Format("20060102T1504"), request.to.In(location).Format("20060102T1504"))
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.json\"", fileName))
+		err = history.WriteJson(w, series, request.options)
+	} else {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", fileName))
+		err = history.WriteCsv(w, series, request.options)
+	}
+
+	if err != nil {
+		fmt.Printf("%T handleHttpExportRequest: Error writing export: %v\n", p, err)
+	}
+}
@@ -0,0 +1,158 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/hass"
+	"github.com/avanha/pmaas-plugin-environment/internal/mqtt"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+)
+
+const hassNodePrefix = "pmaas_environment"
+
+// hassAnnouncement is what was last announced to Home Assistant for a thermometer.
+type hassAnnouncement struct {
+	device  hass.DeviceInfo
+	sensors []hass.Sensor
+}
+
+func (p *plugin) homeAssistantDiscoveryEnabled() bool {
+	return p.state.mqttClient != nil && p.config.Mqtt.HomeAssistantDiscovery
+}
+
+// onHomeAssistantStatus is called on the MQTT client goroutine when Home Assistant publishes its status.  Home
+// Assistant forgets non-retained configs on restart, so announce everything again when it comes back online.
+func (p *plugin) onHomeAssistantStatus(message mqtt.Message) {
+	if string(message.Payload) != mqttOnlinePayload {
+		return
+	}
+
+	err := p.state.container.EnqueueOnPluginGoRoutine(p.announceAllToHomeAssistant)
+
+	if err != nil {
+		fmt.Printf("%T Unable to enqueue Home Assistant announcement: %v\n", p, err)
+	}
+}
+
+func (p *plugin) announceAllToHomeAssistant() {
+	if !p.homeAssistantDiscoveryEnabled() {
+		return
+	}
+
+	clear(p.state.hassAnnouncements)
+
+	for _, entity := range p.state.entities {
+		if wt, ok := entity.(*thermometer.WirelessThermometer); ok && !wt.SensorData.IsEmpty() {
+			p.announceToHomeAssistant(wt)
+			p.publishHomeAssistantState(wt)
+		}
+	}
+}
+
+// announceToHomeAssistant publishes discovery configs for the sensors the thermometer currently reports.  Configs
+// are only published when the device, including its name, or the set of sensors changes, and sensors that are no
+// longer reported are removed.
+func (p *plugin) announceToHomeAssistant(wt *thermometer.WirelessThermometer) {
+	if !p.homeAssistantDiscoveryEnabled() {
+		return
+	}
+
+	sensors := make([]hass.Sensor, 0, len(hass.AllWirelessThermometerSensors))
+	sensors = append(sensors, hass.TemperatureSensor)
+
+	if wt.SensorData.HasHumidity {
+		sensors = append(sensors, hass.HumiditySensor, hass.DewPointSensor)
+	}
+
+	if !wt.BatteryData.IsEmpty() {
+		sensors = append(sensors, hass.BatterySensor)
+	}
+
+	if !wt.RSSIData.IsEmpty() {
+		sensors = append(sensors, hass.RSSISensor)
+	}
+
+	device := p.homeAssistantDevice(wt)
+	announced, ok := p.state.hassAnnouncements[wt.TargetEntityId]
+
+	if ok && announced.device == device && slices.Equal(announced.sensors, sensors) {
+		return
+	}
+
+	removed := make([]hass.Sensor, 0)
+
+	for _, sensor := range announced.sensors {
+		if !slices.Contains(sensors, sensor) {
+			removed = append(removed, sensor)
+		}
+	}
+
+	announcements, err := hass.SensorAnnouncements(p.config.Mqtt.DiscoveryPrefix, device, sensors)
+
+	if err != nil {
+		fmt.Printf("%T Unable to build Home Assistant discovery config for %s: %v\n", p, wt.GetSortKey(), err)
+		return
+	}
+
+	for _, announcement := range hass.SensorRemovals(p.config.Mqtt.DiscoveryPrefix, device.NodeId, removed) {
+		p.publishMqtt(announcement.Topic, announcement.Payload, true)
+	}
+
+	for _, announcement := range announcements {
+		p.publishMqtt(announcement.Topic, announcement.Payload, true)
+	}
+
+	p.state.hassAnnouncements[wt.TargetEntityId] = hassAnnouncement{device: device, sensors: sensors}
+}
+
+// homeAssistantDevice describes the thermometer to Home Assistant.  The node ID and state topic are derived from the
+// ID of the source entity, so they stay the same when the thermometer is renamed or doesn't have a name.
+func (p *plugin) homeAssistantDevice(wt *thermometer.WirelessThermometer) hass.DeviceInfo {
+	nodeId := hass.NodeId(hassNodePrefix, wt.TargetEntityId)
+
+	return hass.DeviceInfo{
+		NodeId:            nodeId,
+		Name:              wt.GetSortKey(),
+		Model:             "Wireless Thermometer",
+		StateTopic:        hass.StateTopic(p.config.Mqtt.TopicPrefix, nodeId),
+		AvailabilityTopic: p.config.Mqtt.TopicPrefix + "/status",
+	}
+}
+
+// publishHomeAssistantState publishes the state of the thermometer to the state topic of its Home Assistant device.
+func (p *plugin) publishHomeAssistantState(wt *thermometer.WirelessThermometer) {
+	if !p.homeAssistantDiscoveryEnabled() {
+		return
+	}
+
+	payload, err := json.Marshal(wt.Data().Data)
+
+	if err != nil {
+		fmt.Printf("%T Unable to encode state of %s for Home Assistant: %v\n", p, wt.GetSortKey(), err)
+		return
+	}
+
+	p.publishMqtt(p.homeAssistantDevice(wt).StateTopic, payload, p.config.Mqtt.RetainState)
+}
+
+// removeFromHomeAssistant removes all previously announced sensors of the thermometer.
+func (p *plugin) removeFromHomeAssistant(wt *thermometer.WirelessThermometer) {
+	announced, ok := p.state.hassAnnouncements[wt.TargetEntityId]
+
+	if !ok {
+		return
+	}
+
+	delete(p.state.hassAnnouncements, wt.TargetEntityId)
+
+	if !p.homeAssistantDiscoveryEnabled() {
+		return
+	}
+
+	for _, announcement := range hass.SensorRemovals(
+		p.config.Mqtt.DiscoveryPrefix, announced.device.NodeId, announced.sensors) {
+		p.publishMqtt(announcement.Topic, announcement.Payload, true)
+	}
+}
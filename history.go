@@ -0,0 +1,242 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/history"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	"github.com/avanha/pmaas-spi"
+)
+
+var historyTimeLayouts = []string{"2006-01-02T15:04", "2006-01-02"}
+
+// historyRequest holds the query parameters shared by the history and export routes.
+type historyRequest struct {
+	entityNames []string
+	from        time.Time
+	to          time.Time
+	step        time.Duration
+	options     history.ExportOptions
+}
+
+// recordHistory adds the current reading of the thermometer to its history.
+func (p *plugin) recordHistory(entityId string, wt *thermometer.WirelessThermometer) {
+	if wt.SensorData.IsEmpty() {
+		return
+	}
+
+	h, ok := p.state.histories[entityId]
+
+	if !ok {
+		h = history.NewHistory(history.Config{
+			MaxReadings:        p.config.History.MaxReadings,
+			MaxRawAge:          p.config.History.MaxRawAge,
+			DownsampleInterval: p.config.History.DownsampleInterval,
+			MaxAge:             p.config.History.MaxAge,
+		})
+		p.state.histories[entityId] = h
+	}
+
+	h.Add(history.Reading{
+		Time:         p.now(),
+		Temperature:  wt.SensorData.Temperature,
+		HasHumidity:  wt.SensorData.HasHumidity,
+		Humidity:     wt.SensorData.Humidity,
+		BatteryLevel: wt.BatteryData.Level,
+		RSSI:         wt.RSSIData.RSSI,
+	})
+}
+
+// getHistory returns a copy of the readings of an entity between from and to, averaged over step if it's positive.
+// Must be called on the plugin goroutine.
+func (p *plugin) getHistory(entityId string, from time.Time, to time.Time, step time.Duration) []history.Reading {
+	h, ok := p.state.histories[entityId]
+
+	if !ok {
+		return make([]history.Reading, 0)
+	}
+
+	return history.Downsample(h.Range(from, to), step)
+}
+
+// handleHttpHistoryRequest returns the history of one or more thermometers as JSON.  It accepts the same query
+// parameters as the export route, except format.
+func (p *plugin) handleHttpHistoryRequest(w http.ResponseWriter, r *http.Request) {
+	request, err := p.parseHistoryRequest(w, r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, err := p.getHistorySeriesOnPluginGoRoutine(request)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = history.WriteJson(w, series, request.options)
+
+	if err != nil {
+		fmt.Printf("%T handleHttpHistoryRequest: Error writing history: %v\n", p, err)
+	}
+}
+
+func (p *plugin) parseHistoryRequest(w http.ResponseWriter, r *http.Request) (historyRequest, error) {
+	query := r.URL.Query()
+	preferences, err := p.displayPreferences(w, r)
+
+	if err != nil {
+		return historyRequest{}, err
+	}
+
+	request := historyRequest{
+		entityNames: make([]string, 0),
+		options: history.ExportOptions{
+			TemperatureUnit: preferences.TemperatureUnit,
+			Decimals:        preferences.Decimals,
+			DecimalComma:    preferences.UsesDecimalComma(),
+			Location:        time.Local,
+		},
+	}
+
+	if query.Has("tz") {
+		request.options.Location, err = time.LoadLocation(query.Get("tz"))
+
+		if err != nil {
+			return request, fmt.Errorf("unknown time zone %q", query.Get("tz"))
+		}
+	}
+
+	if query.Has("step") {
+		request.step, err = time.ParseDuration(query.Get("step"))
+
+		if err != nil || request.step < 0 {
+			return request, fmt.Errorf("invalid step %q", query.Get("step"))
+		}
+	}
+
+	request.to = p.now()
+	request.from = request.to.Add(-24 * time.Hour)
+
+	if query.Has("to") {
+		request.to, err = parseHistoryTime(query.Get("to"), request.options.Location)
+
+		if err != nil {
+			return request, err
+		}
+	}
+
+	if query.Has("from") {
+		request.from, err = parseHistoryTime(query.Get("from"), request.options.Location)
+
+		if err != nil {
+			return request, err
+		}
+	}
+
+	for _, value := range query["entity"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				request.entityNames = append(request.entityNames, name)
+			}
+		}
+	}
+
+	return request, nil
+}
+
+func (p *plugin) getHistorySeriesOnPluginGoRoutine(request historyRequest) ([]history.Series, error) {
+	type result struct {
+		series []history.Series
+		err    error
+	}
+
+	historyResult, err := spi.ExecValueFunctionOnPluginGoRoutine(
+		p.state.container,
+		func() result {
+			series, err := p.getHistorySeries(request)
+
+			return result{series: series, err: err}
+		},
+		func() result { return result{err: errors.New("unable to retrieve history")} },
+		"getHistorySeries")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return historyResult.series, historyResult.err
+}
+
+// getHistorySeries copies the history of the requested thermometers, or all of them if no names are passed, in name
+// order.
+func (p *plugin) getHistorySeries(request historyRequest) ([]history.Series, error) {
+	thermometers := p.findWirelessThermometers(request.entityNames)
+
+	if len(thermometers) == 0 {
+		return nil, fmt.Errorf("no thermometers match %v", request.entityNames)
+	}
+
+	series := make([]history.Series, 0, len(thermometers))
+
+	for entityId, wt := range thermometers {
+		series = append(series, history.Series{
+			Entity:   wt.GetSortKey(),
+			Readings: p.getHistory(entityId, request.from, request.to, request.step),
+		})
+	}
+
+	sort.Slice(series, func(i int, j int) bool { return series[i].Entity < series[j].Entity })
+
+	return series, nil
+}
+
+// findWirelessThermometers returns the thermometers matching the passed names or IDs, keyed by entity ID.  All
+// thermometers are returned if no names are passed.
+func (p *plugin) findWirelessThermometers(names []string) map[string]*thermometer.WirelessThermometer {
+	result := make(map[string]*thermometer.WirelessThermometer)
+
+	for entityId, entity := range p.state.entities {
+		wt, ok := entity.(*thermometer.WirelessThermometer)
+
+		if !ok {
+			continue
+		}
+
+		if len(names) == 0 {
+			result[entityId] = wt
+			continue
+		}
+
+		for _, name := range names {
+			if name == wt.Name || name == wt.Id || name == wt.TargetEntityId {
+				result[entityId] = wt
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+func parseHistoryTime(value string, location *time.Location) (time.Time, error) {
+	if result, err := time.Parse(time.RFC3339, value); err == nil {
+		return result, nil
+	}
+
+	for _, layout := range historyTimeLayouts {
+		if result, err := time.ParseInLocation(layout, value, location); err == nil {
+			return result, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
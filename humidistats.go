@@ -0,0 +1,139 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/humidistat"
+	"github.com/avanha/pmaas-plugin-environment/internal/schedule"
+	"github.com/avanha/pmaas-spi"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+var HumidistatTemplate = spi.TemplateInfo{
+	Name: "environment_humidistat",
+	FuncMap: template.FuncMap{
+		"FormatDuration": FormatDuration,
+		"RelativeTime":   RelativeTime,
+	},
+	Paths:  []string{"templates/humidistat.htmlt"},
+	Styles: []string{"css/humidistat.css"},
+}
+
+func (p *plugin) createHumidistats(schedules map[string]*schedule.Schedule) {
+	for _, humidistatConfig := range p.config.Humidistats {
+		instance := humidistat.CreateHumidistat(
+			p.state.nextEntityId(),
+			humidistat.Settings{
+				Name:            humidistatConfig.Name,
+				Input:           humidistatConfig.InputThermometer,
+				Mode:            humidistatConfig.Mode,
+				ControlVariable: humidistatConfig.ControlVariable,
+				Setpoint:        humidistatConfig.Setpoint,
+				Hysteresis:      humidistatConfig.Hysteresis,
+				MinOnTime:       humidistatConfig.MinOnTime,
+				MinOffTime:      humidistatConfig.MinOffTime,
+				MaxRunTime:      humidistatConfig.MaxRunTime,
+				CooldownTime:    humidistatConfig.CooldownTime,
+				SwitchEntityId:  humidistatConfig.SwitchEntityId,
+				Schedule:        lookupSchedule(schedules, humidistatConfig.Schedule, humidistatConfig.Name),
+			},
+			p.invokeSwitch,
+			tracking.Config{
+				TrackingMode:        tracking.ModePoll,
+				PollIntervalSeconds: 300,
+				Name:                buildTrackingName("Humidistat", humidistatConfig.Name),
+				Schema: tracking.Schema{
+					DataStructType:     data.HumidistatDataType,
+					InsertArgFactoryFn: data.HumidistatDataToInsertArgs,
+				},
+			})
+
+		var stubFactoryFn spi.EntityStubFactoryFunc = func() (any, error) {
+			return instance.GetStub(p.state.container), nil
+		}
+		p.state.controllers[instance.Id] = instance
+		pmaasEntityId, err := p.state.container.RegisterEntity(
+			instance.Id,
+			entities.HumidistatType,
+			instance.Name,
+			stubFactoryFn)
+
+		if err == nil {
+			instance.PmaasEntityId = pmaasEntityId
+		} else {
+			fmt.Printf("Humidistat %s could not be registered: %v\n", instance.Id, err)
+		}
+	}
+}
+
+func (p *plugin) humidistatRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&HumidistatTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*humidistat.Humidistat)
+			return ok
+		},
+		"*Humidistat")
+}
+
+// handleHttpHumidistatRequest processes the setpoint control form on the humidistat card.
+func (p *plugin) handleHttpHumidistatRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.PostForm.Get("id")
+	mode, modeOk := entities.ParseHumidistatMode(r.PostForm.Get("mode"))
+	setpoint, setpointErr := strconv.ParseFloat(r.PostForm.Get("setpoint"), 32)
+
+	if !modeOk || setpointErr != nil {
+		http.Error(w, "Invalid mode or setpoint", http.StatusBadRequest)
+		return
+	}
+
+	err, _ = spi.ExecValueFunctionOnPluginGoRoutine(
+		p.state.container,
+		func() error {
+			instance, err := findController[*humidistat.Humidistat](p, id)
+
+			if err != nil {
+				return err
+			}
+
+			now := p.now()
+			err = instance.SetSetpoint(float32(setpoint), now, p.broadcastEvent)
+
+			if err != nil {
+				return err
+			}
+
+			instance.SetMode(mode, p.broadcastEvent)
+			instance.Evaluate(now, p.lookupSensorData, p.broadcastEvent)
+
+			return nil
+		},
+		func() error { return errors.New("unable to update humidistat") },
+		"handleHttpHumidistatRequest")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/plugins/environment/", http.StatusSeeOther)
+}
@@ -0,0 +1,93 @@
+package environment
+
+import (
+	"fmt"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/internal/influx"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+)
+
+func (p *plugin) startInflux() {
+	influxConfig := p.config.Influx
+
+	if influxConfig.URL == "" && influxConfig.FilePath == "" {
+		return
+	}
+
+	writer := influx.NewWriter(influx.Options{
+		URL:           influxConfig.URL,
+		Token:         influxConfig.Token,
+		FilePath:      influxConfig.FilePath,
+		BufferPath:    influxConfig.BufferPath,
+		MaxBufferSize: influxConfig.MaxBufferSize,
+		BatchSize:     influxConfig.BatchSize,
+		FlushInterval: influxConfig.FlushInterval,
+		MaxRetries:    influxConfig.MaxRetries,
+		RetryDelay:    influxConfig.RetryDelay,
+	})
+	writer.Start()
+	p.state.influxWriter = writer
+}
+
+func (p *plugin) stopInflux() {
+	if p.state.influxWriter == nil {
+		return
+	}
+
+	// The writer flushes on its own goroutine, so don't wait for it.
+	p.state.influxWriter.Stop()
+	p.state.influxWriter = nil
+}
+
+// exportToInflux writes the current tracking sample of the thermometer.
+func (p *plugin) exportToInflux(wt *thermometer.WirelessThermometer) {
+	if p.state.influxWriter == nil {
+		return
+	}
+
+	err := p.state.influxWriter.WritePoint(p.influxPoint(wt))
+
+	if err != nil {
+		fmt.Printf("%T Unable to export %s to InfluxDB: %v\n", p, wt.GetSortKey(), err)
+	}
+}
+
+func (p *plugin) influxPoint(wt *thermometer.WirelessThermometer) influx.Point {
+	sample := wt.Data()
+	sampleData := sample.Data.(data.WirelessThermometerData)
+	measurement := p.config.Influx.Measurement
+
+	if measurement == "" {
+		measurement = "environment"
+	}
+
+	fields := map[string]any{"temperature": sampleData.Temperature}
+
+	if sampleData.HasHumidity {
+		fields["humidity"] = sampleData.Humidity
+
+		if sampleData.DewPoint != nil {
+			fields["dew_point"] = *sampleData.DewPoint
+		}
+	}
+
+	if sampleData.BatteryLevel != 0 {
+		fields["battery_level"] = sampleData.BatteryLevel
+	}
+
+	if sampleData.RSSI != 0 {
+		fields["rssi"] = sampleData.RSSI
+	}
+
+	return influx.Point{
+		Measurement: measurement,
+		Tags: map[string]string{
+			"name": wt.GetSortKey(),
+			"id":   wt.TargetEntityId,
+			"zone": p.config.Zones[wt.Name],
+		},
+		Fields: fields,
+		Time:   sample.LastUpdateTime,
+	}
+}
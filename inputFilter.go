@@ -0,0 +1,31 @@
+package environment
+
+import (
+	"github.com/avanha/pmaas-plugin-environment/internal/filter"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+)
+
+// setInputFilters configures the input filters of a thermometer, using its override if there is one.
+func (c *PluginConfig) setInputFilters(wt *thermometer.WirelessThermometer) {
+	config, ok := c.InputFilters[wt.Name]
+
+	if !ok {
+		config = c.InputFilter
+	}
+
+	wt.SetInputFilters(
+		filter.Config{
+			Min:              config.MinTemperature,
+			Max:              config.MaxTemperature,
+			MaxRatePerMinute: config.MaxTemperatureRate,
+			ConfirmDelta:     config.TemperatureConfirmDelta,
+			MedianOf:         config.MedianOf,
+		},
+		filter.Config{
+			Min:              config.MinHumidity,
+			Max:              config.MaxHumidity,
+			MaxRatePerMinute: config.MaxHumidityRate,
+			ConfirmDelta:     config.HumidityConfirmDelta,
+			MedianOf:         config.MedianOf,
+		})
+}
@@ -0,0 +1,327 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+)
+
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a line on the chart.  Class is added to the SVG path, so the line can be styled with CSS.
+type Series struct {
+	Name   string
+	Class  string
+	Points []Point
+}
+
+// Marker is a labeled dot on the chart, for example to highlight an extreme.
+type Marker struct {
+	Point
+	Class string
+	Label string
+}
+
+// Chart is a line chart over time, rendered as inline SVG on the server so no scripts are needed.
+type Chart struct {
+	Width    int
+	Height   int
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Series   []Series
+	Markers  []Marker
+
+	// ValueFormat formats the labels of the value axis, for example "%.1f".
+	ValueFormat string
+
+	// TimeLabel and DateLabel format the labels of hourly and 5-daily ticks of the time axis.  They default to
+	// "15:04" and "Jan 2".
+	TimeLabel func(t time.Time) string
+	DateLabel func(t time.Time) string
+
+	// MaxGap breaks lines between points that are further apart, so missing data isn't interpolated.
+	MaxGap time.Duration
+}
+
+const (
+	paddingLeft   = 44
+	paddingRight  = 8
+	paddingTop    = 8
+	paddingBottom = 20
+	valueTicks    = 4
+
+	sparklinePadding = 3
+)
+
+// plot maps times and values to SVG coordinates.
+type plot struct {
+	left, top, width, height float64
+	from, to                 time.Time
+	minValue, maxValue       float64
+}
+
+func (p plot) x(t time.Time) float64 {
+	span := p.to.Sub(p.from)
+
+	if span <= 0 {
+		return p.left
+	}
+
+	return p.left + p.width*float64(t.Sub(p.from))/float64(span)
+}
+
+func (p plot) y(value float64) float64 {
+	return p.top + p.height*(p.maxValue-value)/(p.maxValue-p.minValue)
+}
+
+// Render returns the chart as an SVG element.
+func (c Chart) Render() string {
+	location := c.Location
+
+	if location == nil {
+		location = time.Local
+	}
+
+	valueFormat := c.ValueFormat
+
+	if valueFormat == "" {
+		valueFormat = "%.1f"
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg class="chart" viewBox="0 0 %d %d" preserveAspectRatio="none" role="img">`,
+		c.Width, c.Height)
+
+	minValue, maxValue, ok := c.valueRange()
+
+	if !ok {
+		fmt.Fprintf(&builder, `<text class="no-data" x="%d" y="%d" text-anchor="middle">No data</text></svg>`,
+			c.Width/2, c.Height/2)
+
+		return builder.String()
+	}
+
+	p := plot{
+		left:     paddingLeft,
+		top:      paddingTop,
+		width:    float64(c.Width - paddingLeft - paddingRight),
+		height:   float64(c.Height - paddingTop - paddingBottom),
+		from:     c.From,
+		to:       c.To,
+		minValue: minValue,
+		maxValue: maxValue,
+	}
+
+	for i := 0; i <= valueTicks; i = i + 1 {
+		value := minValue + (maxValue-minValue)*float64(i)/valueTicks
+		y := p.y(value)
+		fmt.Fprintf(&builder, `<line class="grid" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`,
+			p.left, y, p.left+p.width, y)
+		fmt.Fprintf(&builder, `<text class="axis-label" x="%.1f" y="%.1f" text-anchor="end">%s</text>`,
+			p.left-4, y+4, fmt.Sprintf(valueFormat, value))
+	}
+
+	for _, tick := range timeTicks(c.From, c.To, location, c.TimeLabel, c.DateLabel) {
+		x := p.x(tick.time)
+		fmt.Fprintf(&builder, `<line class="grid" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`,
+			x, p.top, x, p.top+p.height)
+		fmt.Fprintf(&builder, `<text class="axis-label" x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			x, c.Height-4, html.EscapeString(tick.label))
+	}
+
+	c.renderSeriesAndMarkers(&builder, p, 3)
+	builder.WriteString("</svg>")
+
+	return builder.String()
+}
+
+// RenderSparkline returns the chart as a small SVG element without axes, labels or grid lines.
+func (c Chart) RenderSparkline() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg class="sparkline" viewBox="0 0 %d %d" preserveAspectRatio="none" role="img">`,
+		c.Width, c.Height)
+
+	minValue, maxValue, ok := c.valueRange()
+
+	if ok {
+		p := plot{
+			left:     sparklinePadding,
+			top:      sparklinePadding,
+			width:    float64(c.Width - 2*sparklinePadding),
+			height:   float64(c.Height - 2*sparklinePadding),
+			from:     c.From,
+			to:       c.To,
+			minValue: minValue,
+			maxValue: maxValue,
+		}
+		c.renderSeriesAndMarkers(&builder, p, 2)
+	}
+
+	builder.WriteString("</svg>")
+
+	return builder.String()
+}
+
+func (c Chart) renderSeriesAndMarkers(builder *strings.Builder, p plot, markerRadius int) {
+	for _, series := range c.Series {
+		path := c.path(p, series.Points)
+
+		if path == "" {
+			continue
+		}
+
+		fmt.Fprintf(builder, `<path class="series %s" d="%s"><title>%s</title></path>`,
+			html.EscapeString(series.Class), path, html.EscapeString(series.Name))
+	}
+
+	for _, marker := range c.Markers {
+		if !c.visible(marker.Point) {
+			continue
+		}
+
+		fmt.Fprintf(builder, `<circle class="marker %s" cx="%.1f" cy="%.1f" r="%d"><title>%s</title></circle>`,
+			html.EscapeString(marker.Class), p.x(marker.Time), p.y(marker.Value), markerRadius,
+			html.EscapeString(marker.Label))
+	}
+}
+
+// valueRange returns the range of the value axis, padded so lines don't touch the edges.  Markers are included, so
+// they're always visible.
+func (c Chart) valueRange() (float64, float64, bool) {
+	minValue := math.Inf(1)
+	maxValue := math.Inf(-1)
+	include := func(point Point) {
+		if !c.visible(point) {
+			return
+		}
+
+		minValue = math.Min(minValue, point.Value)
+		maxValue = math.Max(maxValue, point.Value)
+	}
+
+	for _, series := range c.Series {
+		for _, point := range series.Points {
+			include(point)
+		}
+	}
+
+	for _, marker := range c.Markers {
+		include(marker.Point)
+	}
+
+	if math.IsInf(minValue, 1) {
+		return 0, 0, false
+	}
+
+	padding := (maxValue - minValue) * 0.05
+
+	if padding == 0 {
+		padding = 1
+	}
+
+	return minValue - padding, maxValue + padding, true
+}
+
+// visible reports whether the point is within the chart's time span and has a finite value that can be plotted.
+func (c Chart) visible(point Point) bool {
+	if point.Time.Before(c.From) || point.Time.After(c.To) {
+		return false
+	}
+
+	return !math.IsNaN(point.Value) && !math.IsInf(point.Value, 0)
+}
+
+func (c Chart) path(p plot, points []Point) string {
+	var builder strings.Builder
+	var previous time.Time
+
+	for _, point := range points {
+		if !c.visible(point) {
+			continue
+		}
+
+		command := "L"
+
+		if builder.Len() == 0 || (c.MaxGap > 0 && point.Time.Sub(previous) > c.MaxGap) {
+			command = "M"
+		}
+
+		if builder.Len() > 0 {
+			builder.WriteByte(' ')
+		}
+
+		fmt.Fprintf(&builder, "%s%.1f %.1f", command, p.x(point.Time), p.y(point.Value))
+		previous = point.Time
+	}
+
+	return builder.String()
+}
+
+type tick struct {
+	time  time.Time
+	label string
+}
+
+// timeTicks returns the ticks of the time axis, aligned to local hours or days depending on the span.  Nil label
+// functions use the default layouts.
+func timeTicks(
+	from time.Time,
+	to time.Time,
+	location *time.Location,
+	timeLabel func(t time.Time) string,
+	dateLabel func(t time.Time) string) []tick {
+	span := to.Sub(from)
+	result := make([]tick, 0)
+
+	if span <= 0 {
+		return result
+	}
+
+	if timeLabel == nil {
+		timeLabel = func(t time.Time) string { return t.Format("15:04") }
+	}
+
+	if dateLabel == nil {
+		dateLabel = func(t time.Time) string { return t.Format("Jan 2") }
+	}
+
+	if span <= 36*time.Hour {
+		step := 3
+
+		if span <= 8*time.Hour {
+			step = 1
+		}
+
+		local := from.In(location)
+		t := time.Date(local.Year(), local.Month(), local.Day(), local.Hour()-local.Hour()%step+step, 0, 0, 0, location)
+
+		for ; t.Before(to); t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+step, 0, 0, 0, location) {
+			result = append(result, tick{time: t, label: timeLabel(t)})
+		}
+
+		return result
+	}
+
+	step := 1
+	label := func(t time.Time) string { return t.Format("Mon 2") }
+
+	if span > 10*24*time.Hour {
+		step = 5
+		label = dateLabel
+	}
+
+	local := from.In(location)
+	t := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, location)
+
+	for ; t.Before(to); t = time.Date(t.Year(), t.Month(), t.Day()+step, 0, 0, 0, 0, location) {
+		result = append(result, tick{time: t, label: label(t)})
+	}
+
+	return result
+}
@@ -0,0 +1,157 @@
+package chart
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestChart_Render(t *testing.T) {
+	// Arrange
+	chart := Chart{
+		Width:    400,
+		Height:   200,
+		From:     start,
+		To:       start.Add(24 * time.Hour),
+		Location: time.UTC,
+		MaxGap:   2 * time.Hour,
+		Series: []Series{{
+			Name:  "Temperature",
+			Class: "temperature",
+			Points: []Point{
+				{Time: start.Add(time.Hour), Value: 10},
+				{Time: start.Add(2 * time.Hour), Value: 20},
+				{Time: start.Add(6 * time.Hour), Value: 15},
+			},
+		}},
+		Markers: []Marker{{Point: Point{Time: start.Add(2 * time.Hour), Value: 20}, Class: "high", Label: "<High>"}},
+	}
+
+	// Act
+	svg := chart.Render()
+
+	// Assert
+	if !strings.HasPrefix(svg, `<svg class="chart" viewBox="0 0 400 200"`) || !strings.HasSuffix(svg, "</svg>") {
+		t.Fatalf("unexpected SVG %s", svg)
+	}
+
+	// The gap between 2:00 and 6:00 starts a new segment.
+	if !strings.Contains(svg, `class="series temperature"`) || strings.Count(svg, "M") != 2 {
+		t.Fatalf("expected two path segments, got %s", svg)
+	}
+
+	if !strings.Contains(svg, `<circle class="marker high"`) || !strings.Contains(svg, "&lt;High&gt;") {
+		t.Fatalf("expected an escaped marker, got %s", svg)
+	}
+
+	if strings.Count(svg, ">03:00<") != 1 || strings.Count(svg, ">21:00<") != 1 {
+		t.Fatalf("expected 3 hour ticks, got %s", svg)
+	}
+}
+
+func TestChart_RenderWithoutData(t *testing.T) {
+	// Act
+	svg := Chart{Width: 100, Height: 50, From: start, To: start.Add(time.Hour)}.Render()
+
+	// Assert
+	if !strings.Contains(svg, "No data") {
+		t.Fatalf("expected a no data message, got %s", svg)
+	}
+}
+
+func TestChart_RenderSkipsNonFiniteValues(t *testing.T) {
+	// Arrange
+	chart := Chart{
+		Width:  400,
+		Height: 200,
+		From:   start,
+		To:     start.Add(24 * time.Hour),
+		Series: []Series{{
+			Name:  "Dew point",
+			Class: "dew-point",
+			Points: []Point{
+				{Time: start.Add(time.Hour), Value: 10},
+				{Time: start.Add(2 * time.Hour), Value: math.Inf(-1)},
+				{Time: start.Add(3 * time.Hour), Value: math.NaN()},
+				{Time: start.Add(4 * time.Hour), Value: 20},
+			},
+		}},
+		Markers: []Marker{{Point: Point{Time: start.Add(2 * time.Hour), Value: math.Inf(-1)}, Class: "low"}},
+	}
+
+	// Act
+	svg := chart.Render()
+
+	// Assert
+	if strings.Contains(svg, "NaN") || strings.Contains(svg, "Inf") || strings.Contains(svg, "marker low") {
+		t.Fatalf("expected non-finite values to be skipped, got %s", svg)
+	}
+
+	if !strings.Contains(svg, `class="series dew-point"`) {
+		t.Fatalf("expected the finite points to be plotted, got %s", svg)
+	}
+}
+
+func TestTimeTicks_Days(t *testing.T) {
+	// Act
+	ticks := timeTicks(start.Add(12*time.Hour), start.Add(7*24*time.Hour+12*time.Hour), time.UTC, nil, nil)
+
+	// Assert
+	if len(ticks) != 7 || ticks[0].label != "Sat 2" || !ticks[0].time.Equal(start.Add(24*time.Hour)) {
+		t.Fatalf("unexpected ticks %v", ticks)
+	}
+}
+
+func TestTimeTicks_Labels(t *testing.T) {
+	// Arrange
+	timeLabel := func(t time.Time) string { return t.Format("3 PM") }
+	dateLabel := func(t time.Time) string { return t.Format("2 Jan") }
+
+	// Act
+	hours := timeTicks(start, start.Add(4*time.Hour), time.UTC, timeLabel, dateLabel)
+	days := timeTicks(start, start.AddDate(0, 0, 20), time.UTC, timeLabel, dateLabel)
+
+	// Assert
+	if len(hours) == 0 || hours[0].label != "1 AM" {
+		t.Fatalf("unexpected hour ticks %v", hours)
+	}
+
+	if len(days) == 0 || days[0].label != "2 Mar" {
+		t.Fatalf("unexpected day ticks %v", days)
+	}
+}
+
+func TestChart_RenderSparkline(t *testing.T) {
+	// Arrange
+	chart := Chart{
+		Width:  120,
+		Height: 24,
+		From:   start,
+		To:     start.Add(24 * time.Hour),
+		Series: []Series{{
+			Class:  "temperature",
+			Points: []Point{{Time: start.Add(time.Hour), Value: 10}, {Time: start.Add(2 * time.Hour), Value: 12}},
+		}},
+		Markers: []Marker{
+			{Point: Point{Time: start.Add(3 * time.Hour), Value: 20}, Class: "high"},
+			{Point: Point{Time: start.Add(-time.Hour), Value: 0}, Class: "low"},
+		},
+	}
+
+	// Act
+	svg := chart.RenderSparkline()
+
+	// Assert
+	if !strings.HasPrefix(svg, `<svg class="sparkline"`) || strings.Contains(svg, "axis-label") {
+		t.Fatalf("unexpected SVG %s", svg)
+	}
+
+	// The high marker is within range and extends the value axis, the low marker is before the start.
+	if !strings.Contains(svg, `<circle class="marker high" cx="17.2" cy="3.8" r="2">`) ||
+		strings.Contains(svg, "marker low") {
+		t.Fatalf("unexpected markers %s", svg)
+	}
+}
@@ -0,0 +1,307 @@
+package coldstorage
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/controller"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	spievents "github.com/avanha/pmaas-spi/events"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type Settings struct {
+	Name           string
+	Input          string
+	MinTemperature float32
+	MaxTemperature float32
+
+	// A rise of DoorOpenRise degrees within DoorOpenWindow is reported as a door opening.
+	DoorOpenRise   float32
+	DoorOpenWindow time.Duration
+
+	// LogDays is the number of daily log entries to keep.
+	LogDays int
+}
+
+// DailyLogEntry summarizes one local calendar day, in the style of a HACCP temperature log.
+type DailyLogEntry struct {
+	Date               time.Time
+	HasData            bool
+	MinTemperature     float32
+	MinTemperatureTime time.Time
+	MaxTemperature     float32
+	MaxTemperatureTime time.Time
+	TimeOutOfRange     time.Duration
+	Excursions         int
+	DoorOpenings       int
+}
+
+type sample struct {
+	time        time.Time
+	temperature float32
+}
+
+func CreateColdStorageMonitor(
+	instanceId int,
+	settings Settings,
+	trackingConfig tracking.Config) *ColdStorageMonitor {
+	return &ColdStorageMonitor{
+		Id:             fmt.Sprintf("ColdStorageMonitor_%d", instanceId),
+		Name:           settings.Name,
+		Input:          settings.Input,
+		MinTemperature: settings.MinTemperature,
+		MaxTemperature: settings.MaxTemperature,
+		DoorOpenRise:   settings.DoorOpenRise,
+		DoorOpenWindow: settings.DoorOpenWindow,
+		LogDays:        settings.LogDays,
+		InRange:        true,
+		Log:            make([]DailyLogEntry, 0, settings.LogDays),
+		trackingConfig: trackingConfig,
+	}
+}
+
+// ColdStorageMonitor applies a freezer or refrigerator monitoring profile to a thermometer.  It accumulates the time
+// spent outside the safe range, detects door openings from sudden temperature rises, and keeps a daily log.
+type ColdStorageMonitor struct {
+	Id             string
+	PmaasEntityId  string
+	Name           string
+	Input          string
+	MinTemperature float32
+	MaxTemperature float32
+	DoorOpenRise   float32
+	DoorOpenWindow time.Duration
+	LogDays        int
+	HasTemperature bool
+	Temperature    float32
+	SampleTime     time.Time
+	InRange        bool
+	ExcursionStart time.Time
+	DoorOpen       bool
+	DoorOpenedAt   time.Time
+	Log            []DailyLogEntry
+	LastUpdateTime time.Time
+	recentSamples  []sample
+
+	// Display is only populated on state copies that are rendered.
+	Display display.Preferences
+
+	trackingConfig tracking.Config
+	stub           *coldStorageMonitorStub
+}
+
+func (c *ColdStorageMonitor) GetStub(container spi.IPMAASContainer) entities.ColdStorageMonitor {
+	if c.stub == nil {
+		c.stub = newColdStorageMonitorStub(
+			c.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.ColdStorageMonitor]{
+				Container: container,
+				Entity:    c,
+			})
+	}
+
+	return c.stub
+}
+
+func (c *ColdStorageMonitor) TrackingConfig() tracking.Config {
+	return c.trackingConfig
+}
+
+func (c *ColdStorageMonitor) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: c.LastUpdateTime,
+		Data: data.ColdStorageData{
+			HasTemperature:             c.HasTemperature,
+			Temperature:                c.Temperature,
+			InRange:                    c.InRange,
+			DoorOpen:                   c.DoorOpen,
+			TimeOutOfRangeTodaySeconds: int64(c.Today().TimeOutOfRange.Seconds()),
+			LastUpdateTime:             c.LastUpdateTime,
+		},
+	}
+}
+
+func (c *ColdStorageMonitor) GetSortKey() string {
+	return c.Name
+}
+
+// GetState returns a copy of the monitor, including its log, so it can be read on other goroutines.
+func (c *ColdStorageMonitor) GetState() any {
+	state := *c
+	state.Log = slices.Clone(c.Log)
+	state.recentSamples = nil
+
+	return state
+}
+
+// Today returns the log entry of the most recent day.
+func (c *ColdStorageMonitor) Today() DailyLogEntry {
+	if len(c.Log) == 0 {
+		return DailyLogEntry{}
+	}
+
+	return c.Log[len(c.Log)-1]
+}
+
+func (c *ColdStorageMonitor) entityEvent() spievents.EntityEvent {
+	return spievents.EntityEvent{
+		Id:         c.PmaasEntityId,
+		EntityType: entities.ColdStorageMonitorType,
+		Name:       c.Name,
+	}
+}
+
+func (c *ColdStorageMonitor) Evaluate(
+	now time.Time,
+	lookup controller.SensorLookupFunc,
+	publishEventFunc func(pmaasEntityId string, event any)) {
+	c.accumulateTimeOutOfRange(now)
+	c.LastUpdateTime = now
+	sensorData, ok := lookup(c.Input)
+
+	if !ok || c.HasTemperature && sensorData.LastUpdateTime.Equal(c.SampleTime) {
+		// No new reading
+		return
+	}
+
+	temperature := sensorData.Temperature
+	c.HasTemperature = true
+	c.Temperature = temperature
+	c.SampleTime = sensorData.LastUpdateTime
+
+	entry := c.logEntry(now)
+
+	if !entry.HasData || temperature < entry.MinTemperature {
+		entry.MinTemperature = temperature
+		entry.MinTemperatureTime = now
+	}
+
+	if !entry.HasData || temperature > entry.MaxTemperature {
+		entry.MaxTemperature = temperature
+		entry.MaxTemperatureTime = now
+	}
+
+	entry.HasData = true
+
+	inRange := temperature >= c.MinTemperature && temperature <= c.MaxTemperature
+
+	if inRange != c.InRange {
+		c.InRange = inRange
+
+		if !inRange {
+			c.ExcursionStart = now
+			entry.Excursions = entry.Excursions + 1
+		}
+
+		publishEventFunc(c.PmaasEntityId, entities.ColdStorageRangeChangeEvent{
+			EntityEvent: c.entityEvent(),
+			InRange:     inRange,
+			Temperature: temperature,
+		})
+	}
+
+	c.detectDoor(now, temperature, entry, publishEventFunc)
+}
+
+// detectDoor reports a door opening when the temperature rises by DoorOpenRise within DoorOpenWindow, and the door
+// closing as soon as the temperature starts falling again.
+func (c *ColdStorageMonitor) detectDoor(
+	now time.Time,
+	temperature float32,
+	entry *DailyLogEntry,
+	publishEventFunc func(pmaasEntityId string, event any)) {
+	previousTemperature := temperature
+
+	if len(c.recentSamples) > 0 {
+		previousTemperature = c.recentSamples[len(c.recentSamples)-1].temperature
+	}
+
+	windowStart := now.Add(-c.DoorOpenWindow)
+	c.recentSamples = slices.DeleteFunc(c.recentSamples, func(s sample) bool { return s.time.Before(windowStart) })
+	c.recentSamples = append(c.recentSamples, sample{time: now, temperature: temperature})
+
+	if c.DoorOpenRise <= 0 {
+		return
+	}
+
+	doorOpen := c.DoorOpen
+
+	if c.DoorOpen {
+		doorOpen = temperature >= previousTemperature
+	} else {
+		lowest := temperature
+
+		for _, s := range c.recentSamples {
+			lowest = min(lowest, s.temperature)
+		}
+
+		doorOpen = temperature-lowest >= c.DoorOpenRise
+	}
+
+	if doorOpen == c.DoorOpen {
+		return
+	}
+
+	c.DoorOpen = doorOpen
+
+	if doorOpen {
+		c.DoorOpenedAt = now
+		entry.DoorOpenings = entry.DoorOpenings + 1
+	}
+
+	publishEventFunc(c.PmaasEntityId, entities.ColdStorageDoorChangeEvent{
+		EntityEvent: c.entityEvent(),
+		DoorOpen:    doorOpen,
+		OpenedAt:    c.DoorOpenedAt,
+	})
+}
+
+// accumulateTimeOutOfRange adds the time since the previous evaluation to the log, if the last reading was out of
+// range.  Time spanning midnight is split between the days.
+func (c *ColdStorageMonitor) accumulateTimeOutOfRange(now time.Time) {
+	if c.LastUpdateTime.IsZero() {
+		return
+	}
+
+	from := c.LastUpdateTime
+
+	for from.Before(now) {
+		entry := c.logEntry(from)
+		to := startOfDay(from).AddDate(0, 0, 1)
+
+		if now.Before(to) {
+			to = now
+		}
+
+		if !c.InRange {
+			entry.TimeOutOfRange = entry.TimeOutOfRange + to.Sub(from)
+		}
+
+		from = to
+	}
+}
+
+// logEntry returns the log entry for the day of the passed time, starting a new day if necessary.
+func (c *ColdStorageMonitor) logEntry(t time.Time) *DailyLogEntry {
+	date := startOfDay(t)
+
+	if len(c.Log) == 0 || c.Log[len(c.Log)-1].Date.Before(date) {
+		if c.LogDays > 0 && len(c.Log) >= c.LogDays {
+			c.Log = slices.Delete(c.Log, 0, len(c.Log)-c.LogDays+1)
+		}
+
+		c.Log = append(c.Log, DailyLogEntry{Date: date})
+	}
+
+	return &c.Log[len(c.Log)-1]
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,177 @@
+package coldstorage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type testSensor struct {
+	sensorData spienvironment.SensorData
+}
+
+func (s *testSensor) read(time time.Time, temperature float32) {
+	s.sensorData = spienvironment.SensorData{Temperature: temperature, LastUpdateTime: time}
+}
+
+func (s *testSensor) lookup(name string) (spienvironment.SensorData, bool) {
+	return s.sensorData, !s.sensorData.IsEmpty()
+}
+
+func createTestMonitor() *ColdStorageMonitor {
+	return CreateColdStorageMonitor(
+		1,
+		Settings{
+			Name:           "Freezer",
+			Input:          "freezer",
+			MinTemperature: -25,
+			MaxTemperature: -15,
+			DoorOpenRise:   3,
+			DoorOpenWindow: 5 * time.Minute,
+			LogDays:        2,
+		},
+		tracking.Config{})
+}
+
+func TestColdStorageMonitor_Evaluate_AccumulatesTimeOutOfRange(t *testing.T) {
+	// Arrange
+	monitor := createTestMonitor()
+	sensor := &testSensor{}
+	var events []any
+	publish := func(pmaasEntityId string, event any) { events = append(events, event) }
+	start := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	// Act
+	sensor.read(start, -18)
+	monitor.Evaluate(start, sensor.lookup, publish)
+	sensor.read(start.Add(30*time.Minute), -14)
+	monitor.Evaluate(start.Add(30*time.Minute), sensor.lookup, publish)
+	monitor.Evaluate(start.Add(time.Hour), sensor.lookup, publish)
+	sensor.read(start.Add(90*time.Minute), -16)
+	monitor.Evaluate(start.Add(90*time.Minute), sensor.lookup, publish)
+
+	// Assert
+	if len(monitor.Log) != 2 {
+		t.Fatalf("expected 2 log entries, got %v", monitor.Log)
+	}
+
+	firstDay := monitor.Log[0]
+	secondDay := monitor.Log[1]
+
+	if firstDay.TimeOutOfRange != 30*time.Minute || secondDay.TimeOutOfRange != 30*time.Minute {
+		t.Fatalf("expected 30m out of range on each day, got %v and %v",
+			firstDay.TimeOutOfRange, secondDay.TimeOutOfRange)
+	}
+
+	if firstDay.Excursions != 1 || firstDay.MinTemperature != -18 || firstDay.MaxTemperature != -14 {
+		t.Fatalf("unexpected first day entry %+v", firstDay)
+	}
+
+	rangeEvents := 0
+
+	for _, event := range events {
+		if _, ok := event.(entities.ColdStorageRangeChangeEvent); ok {
+			rangeEvents = rangeEvents + 1
+		}
+	}
+
+	if rangeEvents != 2 || !monitor.InRange {
+		t.Fatalf("expected 2 range change events, got %v", events)
+	}
+}
+
+func TestColdStorageMonitor_Evaluate_DetectsDoorOpening(t *testing.T) {
+	// Arrange
+	monitor := createTestMonitor()
+	sensor := &testSensor{}
+	var events []any
+	publish := func(pmaasEntityId string, event any) { events = append(events, event) }
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	readings := []float32{-20, -19.5, -16.5, -16, -17, -18}
+
+	// Act
+	doorStates := make([]bool, len(readings))
+
+	for i, reading := range readings {
+		now := start.Add(time.Duration(i) * time.Minute)
+		sensor.read(now, reading)
+		monitor.Evaluate(now, sensor.lookup, publish)
+		doorStates[i] = monitor.DoorOpen
+	}
+
+	// Assert
+	expected := []bool{false, false, true, true, false, false}
+
+	for i := range expected {
+		if doorStates[i] != expected[i] {
+			t.Fatalf("expected door states %v, got %v", expected, doorStates)
+		}
+	}
+
+	if monitor.Today().DoorOpenings != 1 {
+		t.Fatalf("expected 1 door opening, got %d", monitor.Today().DoorOpenings)
+	}
+}
+
+func TestColdStorageMonitor_Log_KeepsConfiguredDays(t *testing.T) {
+	// Arrange
+	monitor := createTestMonitor()
+	sensor := &testSensor{}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	for i := 0; i < 4; i = i + 1 {
+		now := start.AddDate(0, 0, i)
+		sensor.read(now, -20)
+		monitor.Evaluate(now, sensor.lookup, func(string, any) {})
+	}
+
+	// Assert
+	if len(monitor.Log) != 2 || !monitor.Log[0].Date.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the last 2 days, got %v", monitor.Log)
+	}
+}
+
+func TestWriteLogCsv(t *testing.T) {
+	// Arrange
+	log := []DailyLogEntry{
+		{
+			Date:               time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			HasData:            true,
+			MinTemperature:     -21.04,
+			MinTemperatureTime: time.Date(2024, 1, 1, 4, 5, 0, 0, time.UTC),
+			MaxTemperature:     -14,
+			MaxTemperatureTime: time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC),
+			TimeOutOfRange:     45 * time.Minute,
+			Excursions:         1,
+			DoorOpenings:       3,
+		},
+		{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	var buffer bytes.Buffer
+
+	// Act
+	err := WriteLogCsv(&buffer, log)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"date,min_temperature_c,min_temperature_time,max_temperature_c,max_temperature_time," +
+			"minutes_out_of_range,excursions,door_openings",
+		"2024-01-01,-21.0,04:05,-14.0,18:30,45,1,3",
+		"2024-01-02,,,,,0,0,0",
+		"",
+	}, "\n")
+
+	if buffer.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,69 @@
+package coldstorage
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type coldStorageMonitorStub struct {
+	id                     string
+	closeFn                func() error
+	entityWrapperReference atomic.Pointer[common.ThreadSafeEntityWrapper[entities.ColdStorageMonitor]]
+}
+
+func (s *coldStorageMonitorStub) TrackingConfig() tracking.Config {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.ColdStorageMonitor) tracking.Config { return target.TrackingConfig() })
+}
+
+func (s *coldStorageMonitorStub) Data() tracking.DataSample {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.ColdStorageMonitor) tracking.DataSample { return target.Data() })
+}
+
+func (s *coldStorageMonitorStub) GetSortKey() string {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.ColdStorageMonitor) string { return target.GetSortKey() })
+}
+
+func newColdStorageMonitorStub(
+	id string,
+	entityWrapper *common.ThreadSafeEntityWrapper[entities.ColdStorageMonitor]) *coldStorageMonitorStub {
+	instance := &coldStorageMonitorStub{
+		id: id,
+	}
+
+	instance.entityWrapperReference.Store(entityWrapper)
+
+	instance.closeFn = func() error {
+		if instance.entityWrapperReference.CompareAndSwap(entityWrapper, nil) {
+			instance.closeFn = nil
+			return nil
+		}
+
+		return fmt.Errorf("failed to clear entity wrapper, current value does not match expected value")
+	}
+
+	return instance
+}
+
+func (s *coldStorageMonitorStub) close() {
+	closeFn := s.closeFn
+
+	if closeFn == nil {
+		return
+	}
+
+	err := closeFn()
+
+	if err != nil {
+		fmt.Printf("Failed to close coldStorageMonitor stub %s: %v", s.id, err)
+	}
+}
@@ -0,0 +1,52 @@
+package coldstorage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+var logCsvHeader = []string{
+	"date", "min_temperature_c", "min_temperature_time", "max_temperature_c", "max_temperature_time",
+	"minutes_out_of_range", "excursions", "door_openings",
+}
+
+// WriteLogCsv writes the daily log, oldest day first, as CSV.
+func WriteLogCsv(w io.Writer, log []DailyLogEntry) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(logCsvHeader)
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range log {
+		minTemperature, minTemperatureTime, maxTemperature, maxTemperatureTime := "", "", "", ""
+
+		if entry.HasData {
+			minTemperature = fmt.Sprintf("%.1f", entry.MinTemperature)
+			minTemperatureTime = entry.MinTemperatureTime.Format("15:04")
+			maxTemperature = fmt.Sprintf("%.1f", entry.MaxTemperature)
+			maxTemperatureTime = entry.MaxTemperatureTime.Format("15:04")
+		}
+
+		err = writer.Write([]string{
+			entry.Date.Format("2006-01-02"),
+			minTemperature,
+			minTemperatureTime,
+			maxTemperature,
+			maxTemperatureTime,
+			fmt.Sprintf("%.0f", entry.TimeOutOfRange.Minutes()),
+			fmt.Sprintf("%d", entry.Excursions),
+			fmt.Sprintf("%d", entry.DoorOpenings),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,14 @@
+package common
+
+import (
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/controller"
+)
+
+// IController is implemented by entities that act on the readings of other entities.  Evaluate is called on the
+// plugin goroutine whenever a thermometer changes, as well as periodically, so time-based constraints can progress.
+type IController interface {
+	Evaluate(now time.Time, lookup controller.SensorLookupFunc, publishEvent func(pmaasEntityId string, event any))
+	GetState() any
+}
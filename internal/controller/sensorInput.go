@@ -0,0 +1,57 @@
+package controller
+
+import (
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// SensorLookupFunc returns the current sensor data of the thermometer with the passed name.  The second return value
+// is false if there is no such thermometer, or it hasn't reported any data yet.
+type SensorLookupFunc func(name string) (spienvironment.SensorData, bool)
+
+type Aggregation int
+
+const (
+	AggregationAverage Aggregation = iota
+	AggregationMinimum
+	AggregationMaximum
+)
+
+// AggregateTemperature combines the temperatures of the named thermometers into a single virtual reading.
+// Returns false if none of the inputs have data.
+func AggregateTemperature(names []string, aggregation Aggregation, lookup SensorLookupFunc) (float32, bool) {
+	var result float32 = 0
+	count := 0
+
+	for _, name := range names {
+		sensorData, ok := lookup(name)
+
+		if !ok {
+			continue
+		}
+
+		value := sensorData.Temperature
+
+		switch {
+		case count == 0:
+			result = value
+		case aggregation == AggregationMinimum:
+			result = min(result, value)
+		case aggregation == AggregationMaximum:
+			result = max(result, value)
+		default:
+			result = result + value
+		}
+
+		count = count + 1
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	if aggregation == AggregationAverage {
+		result = result / float32(count)
+	}
+
+	return result, true
+}
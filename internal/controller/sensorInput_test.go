@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"testing"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+func TestAggregateTemperature(t *testing.T) {
+	// Arrange
+	readings := map[string]float32{"a": 20, "b": 23}
+	lookup := func(name string) (spienvironment.SensorData, bool) {
+		value, ok := readings[name]
+		return spienvironment.SensorData{Temperature: value}, ok
+	}
+	inputs := []string{"a", "b", "missing"}
+
+	// Act
+	average, averageOk := AggregateTemperature(inputs, AggregationAverage, lookup)
+	minimum, _ := AggregateTemperature(inputs, AggregationMinimum, lookup)
+	maximum, _ := AggregateTemperature(inputs, AggregationMaximum, lookup)
+	_, noneOk := AggregateTemperature([]string{"missing"}, AggregationAverage, lookup)
+
+	// Assert
+	if !averageOk || average != 21.5 {
+		t.Fatalf("expected average 21.5, got %v (%v)", average, averageOk)
+	}
+
+	if minimum != 20 || maximum != 23 {
+		t.Fatalf("expected min 20 and max 23, got %v and %v", minimum, maximum)
+	}
+
+	if noneOk {
+		t.Fatalf("expected no result without inputs")
+	}
+}
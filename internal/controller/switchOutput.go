@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"time"
+)
+
+// SwitchFunc turns the switch entity identified by pmaasEntityId on or off.
+type SwitchFunc func(pmaasEntityId string, on bool) error
+
+func CreateSwitchOutput(
+	switchEntityId string,
+	minOnTime time.Duration,
+	minOffTime time.Duration,
+	switchFn SwitchFunc) SwitchOutput {
+	return SwitchOutput{
+		SwitchEntityId: switchEntityId,
+		MinOnTime:      minOnTime,
+		MinOffTime:     minOffTime,
+		switchFn:       switchFn,
+	}
+}
+
+// SwitchOutput tracks the state of a controller output and enforces minimum on and off times between changes.  An
+// output without a SwitchEntityId still tracks its state, but doesn't drive anything.
+type SwitchOutput struct {
+	SwitchEntityId string
+	MinOnTime      time.Duration
+	MinOffTime     time.Duration
+	On             bool
+	LastChangeTime time.Time
+	LockedOutUntil time.Time
+	switchFn       SwitchFunc
+}
+
+// CanChange reports whether the minimum on or off time for the current state, and any lock out, has elapsed.
+func (o *SwitchOutput) CanChange(now time.Time) bool {
+	if !o.On && now.Before(o.LockedOutUntil) {
+		return false
+	}
+
+	if o.LastChangeTime.IsZero() {
+		return true
+	}
+
+	minTime := o.MinOffTime
+
+	if o.On {
+		minTime = o.MinOnTime
+	}
+
+	return now.Sub(o.LastChangeTime) >= minTime
+}
+
+// Set requests a new output state.  Returns true if the state changed.  Requests that would violate the minimum on or
+// off time are ignored; controllers are expected to repeat the request on a later evaluation.
+func (o *SwitchOutput) Set(on bool, now time.Time) (bool, error) {
+	if o.On == on || !o.CanChange(now) {
+		return false, nil
+	}
+
+	if o.SwitchEntityId != "" && o.switchFn != nil {
+		err := o.switchFn(o.SwitchEntityId, on)
+
+		if err != nil {
+			return false, err
+		}
+	}
+
+	o.On = on
+	o.LastChangeTime = now
+
+	return true, nil
+}
+
+// LockOut prevents the output from turning on before the passed time.
+func (o *SwitchOutput) LockOut(until time.Time) {
+	o.LockedOutUntil = until
+}
+
+// OnDuration returns how long the output has been on, or zero if it's off.
+func (o *SwitchOutput) OnDuration(now time.Time) time.Duration {
+	if !o.On {
+		return 0
+	}
+
+	return now.Sub(o.LastChangeTime)
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSwitchOutput_Set_RespectsMinimumTimes(t *testing.T) {
+	// Arrange
+	var calls []bool
+	output := CreateSwitchOutput("switch", 3*time.Minute, 5*time.Minute,
+		func(pmaasEntityId string, on bool) error {
+			calls = append(calls, on)
+			return nil
+		})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	changedOn, _ := output.Set(true, start)
+	changedOffEarly, _ := output.Set(false, start.Add(time.Minute))
+	changedOff, _ := output.Set(false, start.Add(3*time.Minute))
+	changedOnEarly, _ := output.Set(true, start.Add(4*time.Minute))
+
+	// Assert
+	if !changedOn || changedOffEarly || !changedOff || changedOnEarly {
+		t.Fatalf("unexpected changes: %v %v %v %v", changedOn, changedOffEarly, changedOff, changedOnEarly)
+	}
+
+	if len(calls) != 2 || calls[0] != true || calls[1] != false {
+		t.Fatalf("expected switch calls [true false], got %v", calls)
+	}
+}
+
+func TestSwitchOutput_LockOut(t *testing.T) {
+	// Arrange
+	output := CreateSwitchOutput("", 0, time.Minute, nil)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	output.LockOut(start.Add(10 * time.Minute))
+
+	// Act
+	changedDuringLockOut, _ := output.Set(true, start.Add(5*time.Minute))
+	changedAfterLockOut, _ := output.Set(true, start.Add(10*time.Minute))
+
+	// Assert
+	if changedDuringLockOut || !changedAfterLockOut {
+		t.Fatalf("expected the lock out to delay turning on, got %v and %v", changedDuringLockOut, changedAfterLockOut)
+	}
+}
@@ -0,0 +1,79 @@
+package deadband
+
+import "time"
+
+// Config configures when changes of a single kind of reading are published.  The zero value publishes every change.
+type Config struct {
+	// Changes smaller than Absolute, or than Relative times the last published value, aren't published.  When both
+	// are set, the larger applies.
+	Absolute float32
+	Relative float32
+
+	// MinInterval is the minimum time between published changes.
+	MinInterval time.Duration
+
+	// MaxInterval publishes the current value as a heartbeat when nothing was published for this long, even if it
+	// didn't change.  Zero disables heartbeats.
+	MaxInterval time.Duration
+}
+
+// Gate decides which readings are published.  Readings are compared against the last published value, so slow drift
+// is published once it adds up.  It isn't safe for concurrent use.
+type Gate struct {
+	config        Config
+	hasPublished  bool
+	published     float32
+	publishedTime time.Time
+}
+
+func NewGate(config Config) *Gate {
+	return &Gate{config: config}
+}
+
+// Check returns whether a reading should be published and the previously published value.  Readings that are
+// published become the new reference.
+func (g *Gate) Check(value float32, now time.Time) (bool, float32) {
+	previous := g.published
+
+	if !g.shouldPublish(value, now) {
+		return false, previous
+	}
+
+	g.hasPublished = true
+	g.published = value
+	g.publishedTime = now
+
+	return true, previous
+}
+
+func (g *Gate) shouldPublish(value float32, now time.Time) bool {
+	if !g.hasPublished {
+		return true
+	}
+
+	elapsed := now.Sub(g.publishedTime)
+
+	if g.config.MaxInterval > 0 && elapsed >= g.config.MaxInterval {
+		return true
+	}
+
+	if g.config.MinInterval > 0 && elapsed < g.config.MinInterval {
+		return false
+	}
+
+	change := abs(value - g.published)
+
+	if change == 0 {
+		return false
+	}
+
+	return change >= max(g.config.Absolute, g.config.Relative*abs(g.published))
+}
+
+func abs(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,83 @@
+package deadband
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestGate_ZeroConfigPublishesEveryChange(t *testing.T) {
+	// Arrange
+	gate := NewGate(Config{})
+	gate.Check(20, start)
+
+	// Act
+	unchanged, _ := gate.Check(20, start.Add(time.Minute))
+	changed, previous := gate.Check(20.1, start.Add(2*time.Minute))
+
+	// Assert
+	if unchanged || !changed || previous != 20 {
+		t.Fatalf("expected only the change to be published, got %v %v %v", unchanged, changed, previous)
+	}
+}
+
+func TestGate_AbsoluteDeadbandAccumulatesDrift(t *testing.T) {
+	// Arrange
+	gate := NewGate(Config{Absolute: 0.5})
+	gate.Check(20, start)
+
+	// Act
+	first, _ := gate.Check(20.3, start.Add(time.Minute))
+	second, previous := gate.Check(20.6, start.Add(2*time.Minute))
+
+	// Assert
+	if first || !second || previous != 20 {
+		t.Fatalf("expected the accumulated drift to be published, got %v %v %v", first, second, previous)
+	}
+}
+
+func TestGate_RelativeDeadband(t *testing.T) {
+	// Arrange
+	gate := NewGate(Config{Relative: 0.05})
+	gate.Check(50, start)
+
+	// Act
+	small, _ := gate.Check(52, start.Add(time.Minute))
+	large, _ := gate.Check(53, start.Add(2*time.Minute))
+
+	// Assert
+	if small || !large {
+		t.Fatalf("expected only the change of at least 5%% to be published, got %v %v", small, large)
+	}
+}
+
+func TestGate_MinInterval(t *testing.T) {
+	// Arrange
+	gate := NewGate(Config{MinInterval: 5 * time.Minute})
+	gate.Check(20, start)
+
+	// Act
+	early, _ := gate.Check(25, start.Add(time.Minute))
+	late, previous := gate.Check(25, start.Add(5*time.Minute))
+
+	// Assert
+	if early || !late || previous != 20 {
+		t.Fatalf("expected the change to be published after the interval, got %v %v %v", early, late, previous)
+	}
+}
+
+func TestGate_Heartbeat(t *testing.T) {
+	// Arrange
+	gate := NewGate(Config{Absolute: 1, MaxInterval: time.Hour})
+	gate.Check(20, start)
+
+	// Act
+	beforeHeartbeat, _ := gate.Check(20, start.Add(59*time.Minute))
+	heartbeat, previous := gate.Check(20, start.Add(time.Hour))
+
+	// Assert
+	if beforeHeartbeat || !heartbeat || previous != 20 {
+		t.Fatalf("expected a heartbeat after an hour, got %v %v %v", beforeHeartbeat, heartbeat, previous)
+	}
+}
@@ -0,0 +1,133 @@
+package display
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/units"
+)
+
+// Preferences control how values are formatted for display.
+type Preferences struct {
+	TemperatureUnit units.TemperatureUnit
+	Decimals        int
+	Use24HourClock  bool
+	Locale          string
+}
+
+// Languages that use a decimal comma.
+var decimalCommaLanguages = map[string]bool{
+	"bg": true, "cs": true, "da": true, "de": true, "el": true, "es": true, "et": true, "fi": true, "fr": true,
+	"hr": true, "hu": true, "id": true, "it": true, "lt": true, "lv": true, "nb": true, "nl": true, "nn": true,
+	"no": true, "pl": true, "pt": true, "ro": true, "ru": true, "sk": true, "sl": true, "sr": true, "sv": true,
+	"tr": true, "uk": true, "vi": true,
+}
+
+// ParseLocale validates and normalizes a locale like "en-US" or "de_DE".
+func ParseLocale(value string) (string, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), "_", "-")
+	parts := strings.Split(value, "-")
+
+	if len(parts) > 2 || len(parts[0]) < 2 || len(parts[0]) > 3 || !isLetters(parts[0]) {
+		return "", fmt.Errorf("invalid locale %q", value)
+	}
+
+	result := strings.ToLower(parts[0])
+
+	if len(parts) == 2 {
+		if len(parts[1]) != 2 || !isLetters(parts[1]) {
+			return "", fmt.Errorf("invalid locale %q", value)
+		}
+
+		result = result + "-" + strings.ToUpper(parts[1])
+	}
+
+	return result, nil
+}
+
+func isLetters(value string) bool {
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p Preferences) language() string {
+	language, _, _ := strings.Cut(p.Locale, "-")
+
+	return language
+}
+
+// UsesDecimalComma returns whether the locale uses a comma as the decimal separator.
+func (p Preferences) UsesDecimalComma() bool {
+	return decimalCommaLanguages[p.language()]
+}
+
+// dayFirst returns whether dates are written day first, which is everywhere but the United States.
+func (p Preferences) dayFirst() bool {
+	return p.Locale != "" && p.Locale != "en" && p.Locale != "en-US"
+}
+
+// Number formats a value with the passed number of decimals and the locale's decimal separator.
+func (p Preferences) Number(value float64, decimals int) string {
+	result := strconv.FormatFloat(value, 'f', max(decimals, 0), 64)
+
+	if p.UsesDecimalComma() {
+		result = strings.Replace(result, ".", ",", 1)
+	}
+
+	return result
+}
+
+// TemperatureValue converts a temperature in degrees Celsius to the preferred unit and formats it without a unit.
+func (p Preferences) TemperatureValue(celsius float32) string {
+	return p.Number(float64(p.TemperatureUnit.FromCelsius(celsius)), p.Decimals)
+}
+
+// Temperature converts a temperature in degrees Celsius to the preferred unit and formats it, for example "21.5 °C".
+func (p Preferences) Temperature(celsius float32) string {
+	return p.TemperatureValue(celsius) + " " + p.TemperatureUnit.Symbol()
+}
+
+// TemperatureSymbol returns the symbol of the preferred unit, for example "°F".
+func (p Preferences) TemperatureSymbol() string {
+	return p.TemperatureUnit.Symbol()
+}
+
+// Humidity formats a relative humidity with one decimal less than temperatures, for example "45.5%".
+func (p Preferences) Humidity(humidity float32) string {
+	return p.Number(float64(humidity), p.Decimals-1) + "%"
+}
+
+// Time formats the time of day, for example "3:04 PM" or "15:04".
+func (p Preferences) Time(t time.Time) string {
+	if p.Use24HourClock {
+		return t.Format("15:04")
+	}
+
+	return t.Format("3:04 PM")
+}
+
+// Date formats a date without the year, for example "Jan 2" or "2 Jan".
+func (p Preferences) Date(t time.Time) string {
+	if p.dayFirst() {
+		return t.Format("2 Jan")
+	}
+
+	return t.Format("Jan 2")
+}
+
+// DateTime formats a date and time of day, for example "Jan 2 3:04 PM" or "2 Jan 15:04".
+func (p Preferences) DateTime(t time.Time) string {
+	return p.Date(t) + " " + p.Time(t)
+}
+
+// WeekdayTime formats the day of the week and time of day, for example "Mon 3:04 PM" or "Mon 15:04".
+func (p Preferences) WeekdayTime(t time.Time) string {
+	return t.Format("Mon") + " " + p.Time(t)
+}
@@ -0,0 +1,62 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/units"
+)
+
+var afternoon = time.Date(2024, 3, 1, 15, 4, 0, 0, time.UTC)
+
+func TestPreferences_Temperature(t *testing.T) {
+	// Arrange
+	us := Preferences{TemperatureUnit: units.Fahrenheit, Decimals: 1, Locale: "en-US"}
+	germany := Preferences{TemperatureUnit: units.Celsius, Decimals: 2, Use24HourClock: true, Locale: "de-DE"}
+
+	// Act & Assert
+	if result := us.Temperature(20); result != "68.0 °F" {
+		t.Fatalf("unexpected US temperature %s", result)
+	}
+
+	if result := germany.Temperature(20.456); result != "20,46 °C" {
+		t.Fatalf("unexpected German temperature %s", result)
+	}
+
+	if result := germany.Humidity(45.26); result != "45,3%" {
+		t.Fatalf("unexpected German humidity %s", result)
+	}
+
+	if result := us.Humidity(45.26); result != "45%" {
+		t.Fatalf("unexpected US humidity %s", result)
+	}
+}
+
+func TestPreferences_Time(t *testing.T) {
+	// Arrange
+	us := Preferences{Locale: "en-US"}
+	uk := Preferences{Locale: "en-GB", Use24HourClock: true}
+
+	// Act & Assert
+	if result := us.DateTime(afternoon); result != "Mar 1 3:04 PM" {
+		t.Fatalf("unexpected US date time %s", result)
+	}
+
+	if result := uk.DateTime(afternoon); result != "1 Mar 15:04" {
+		t.Fatalf("unexpected UK date time %s", result)
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	for input, expected := range map[string]string{"en-us": "en-US", "de_DE": "de-DE", "fr": "fr"} {
+		if result, err := ParseLocale(input); err != nil || result != expected {
+			t.Fatalf("ParseLocale(%q) = %s, %v, expected %s", input, result, err, expected)
+		}
+	}
+
+	for _, input := range []string{"", "x", "en-USA", "e1-US", "<script>"} {
+		if _, err := ParseLocale(input); err == nil {
+			t.Fatalf("expected an error for %q", input)
+		}
+	}
+}
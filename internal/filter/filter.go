@@ -0,0 +1,192 @@
+package filter
+
+import (
+	"slices"
+	"time"
+)
+
+// Config configures the filter of a single kind of reading, like temperature or humidity.  The zero value accepts
+// every reading.
+type Config struct {
+	// Readings below Min or above Max are rejected.  The bounds are only applied when Min < Max.
+	Min float32
+	Max float32
+
+	// MaxRatePerMinute rejects readings that changed faster than this since the last accepted reading.  The allowed
+	// change grows with the time since the last accepted reading, up to rateWindow.  After a longer gap, a reading
+	// that changed more than that is held and accepted when the next reading is within the allowed change of it, so
+	// a sustained real change is eventually accepted but a single corrupt reading isn't.  Zero disables the check.
+	MaxRatePerMinute float32
+
+	// ConfirmDelta holds readings that differ by more than ConfirmDelta from the last accepted one.  A held reading
+	// is accepted when the next reading is within ConfirmDelta of it.  Zero disables confirmation.
+	ConfirmDelta float32
+
+	// MedianOf replaces each accepted reading with the median of the last MedianOf accepted readings.  Values below 2
+	// disable the median.
+	MedianOf int
+}
+
+// rateWindow caps the time used to compute the change allowed by MaxRatePerMinute.
+const rateWindow = 15 * time.Minute
+
+type Result int
+
+const (
+	Accepted Result = iota
+	OutOfBounds
+	RateExceeded
+	Unconfirmed
+)
+
+func (r Result) String() string {
+	switch r {
+	case Accepted:
+		return "accepted"
+	case OutOfBounds:
+		return "out of bounds"
+	case RateExceeded:
+		return "rate exceeded"
+	case Unconfirmed:
+		return "unconfirmed"
+	default:
+		return "unknown"
+	}
+}
+
+// Stats counts the results of a filter.
+type Stats struct {
+	Accepted     int
+	OutOfBounds  int
+	RateExceeded int
+	Unconfirmed  int
+}
+
+func (s Stats) Rejected() int {
+	return s.OutOfBounds + s.RateExceeded + s.Unconfirmed
+}
+
+// Filter validates a stream of readings.  It isn't safe for concurrent use.
+type Filter struct {
+	config     Config
+	hasLast    bool
+	last       float32
+	lastTime   time.Time
+	hasPending bool
+	pending    float32
+	window     []float32
+	stats      Stats
+}
+
+func NewFilter(config Config) *Filter {
+	return &Filter{
+		config: config,
+		window: make([]float32, 0, max(config.MedianOf, 0)),
+	}
+}
+
+func (f *Filter) Stats() Stats {
+	return f.stats
+}
+
+// Apply filters a reading taken at the passed time.  Returns the value to use and Accepted, or the reason the reading
+// was rejected, in which case the value must be ignored.
+func (f *Filter) Apply(value float32, now time.Time) (float32, Result) {
+	result := f.check(value, now)
+
+	switch result {
+	case Accepted:
+		f.stats.Accepted = f.stats.Accepted + 1
+	case OutOfBounds:
+		f.stats.OutOfBounds = f.stats.OutOfBounds + 1
+	case RateExceeded:
+		f.stats.RateExceeded = f.stats.RateExceeded + 1
+	case Unconfirmed:
+		f.stats.Unconfirmed = f.stats.Unconfirmed + 1
+	}
+
+	if result != Accepted {
+		return value, result
+	}
+
+	f.hasLast = true
+	f.last = value
+	f.lastTime = now
+	f.hasPending = false
+
+	return f.median(value), Accepted
+}
+
+func (f *Filter) check(value float32, now time.Time) Result {
+	if f.config.Min < f.config.Max && (value < f.config.Min || value > f.config.Max) {
+		return OutOfBounds
+	}
+
+	if !f.hasLast {
+		return Accepted
+	}
+
+	change := abs(value - f.last)
+
+	if f.config.MaxRatePerMinute > 0 {
+		elapsed := now.Sub(f.lastTime)
+		allowed := f.config.MaxRatePerMinute * max(float32(min(elapsed, rateWindow).Minutes()), 1.0/60)
+
+		if change > allowed {
+			if elapsed <= rateWindow {
+				return RateExceeded
+			}
+
+			if f.hasPending && abs(value-f.pending) <= allowed {
+				return Accepted
+			}
+
+			f.hasPending = true
+			f.pending = value
+
+			return RateExceeded
+		}
+	}
+
+	if f.config.ConfirmDelta > 0 && change > f.config.ConfirmDelta {
+		if f.hasPending && abs(value-f.pending) <= f.config.ConfirmDelta {
+			return Accepted
+		}
+
+		f.hasPending = true
+		f.pending = value
+
+		return Unconfirmed
+	}
+
+	return Accepted
+}
+
+func (f *Filter) median(value float32) float32 {
+	if f.config.MedianOf < 2 {
+		return value
+	}
+
+	if len(f.window) == f.config.MedianOf {
+		f.window = append(f.window[:0], f.window[1:]...)
+	}
+
+	f.window = append(f.window, value)
+	sorted := slices.Clone(f.window)
+	slices.Sort(sorted)
+	middle := len(sorted) / 2
+
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
+
+func abs(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,138 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFilter_ZeroConfigAcceptsEverything(t *testing.T) {
+	// Arrange
+	filter := NewFilter(Config{})
+
+	// Act
+	filter.Apply(20, start)
+	value, result := filter.Apply(-40, start.Add(time.Second))
+
+	// Assert
+	if result != Accepted || value != -40 {
+		t.Fatalf("expected -40 to be accepted, got %v %v", value, result)
+	}
+}
+
+func TestFilter_RejectsOutOfBounds(t *testing.T) {
+	// Arrange
+	filter := NewFilter(Config{Min: -35, Max: 60})
+
+	// Act
+	_, result := filter.Apply(-40, start)
+
+	// Assert
+	if result != OutOfBounds {
+		t.Fatalf("expected %v, got %v", OutOfBounds, result)
+	}
+
+	if stats := filter.Stats(); stats.OutOfBounds != 1 || stats.Rejected() != 1 || stats.Accepted != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestFilter_RejectsRateExceeded(t *testing.T) {
+	// Arrange
+	filter := NewFilter(Config{MaxRatePerMinute: 1})
+	filter.Apply(20, start)
+
+	// Act
+	_, spikeResult := filter.Apply(30, start.Add(time.Minute))
+	_, slowResult := filter.Apply(30, start.Add(20*time.Minute))
+
+	// Assert
+	if spikeResult != RateExceeded {
+		t.Fatalf("expected the spike to be rejected, got %v", spikeResult)
+	}
+
+	if slowResult != Accepted {
+		t.Fatalf("expected the sustained change to be accepted, got %v", slowResult)
+	}
+}
+
+func TestFilter_RejectsSpikeAfterLongGap(t *testing.T) {
+	// Arrange
+	filter := NewFilter(Config{MaxRatePerMinute: 1})
+	filter.Apply(20, start)
+
+	// Act
+	_, spikeResult := filter.Apply(-40, start.Add(6*time.Hour))
+	_, nextResult := filter.Apply(21, start.Add(6*time.Hour+time.Minute))
+
+	// Assert
+	if spikeResult != RateExceeded {
+		t.Fatalf("expected the spike to be rejected, got %v", spikeResult)
+	}
+
+	if nextResult != Accepted {
+		t.Fatalf("expected the next reading to be accepted, got %v", nextResult)
+	}
+}
+
+func TestFilter_ConfirmsLargeChangeAfterLongGap(t *testing.T) {
+	// Arrange
+	filter := NewFilter(Config{MaxRatePerMinute: 1})
+	filter.Apply(20, start)
+
+	// Act
+	_, firstResult := filter.Apply(0, start.Add(6*time.Hour))
+	value, secondResult := filter.Apply(1, start.Add(6*time.Hour+time.Minute))
+
+	// Assert
+	if firstResult != RateExceeded {
+		t.Fatalf("expected the first reading to be held, got %v", firstResult)
+	}
+
+	if secondResult != Accepted || value != 1 {
+		t.Fatalf("expected the confirming reading to be accepted, got %v %v", value, secondResult)
+	}
+}
+
+func TestFilter_ConfirmsOnSecondReading(t *testing.T) {
+	// Arrange
+	filter := NewFilter(Config{ConfirmDelta: 2})
+	filter.Apply(20, start)
+
+	// Act
+	_, spikeResult := filter.Apply(-40, start.Add(time.Minute))
+	_, recoveredResult := filter.Apply(20.5, start.Add(2*time.Minute))
+	_, firstJumpResult := filter.Apply(25, start.Add(3*time.Minute))
+	value, secondJumpResult := filter.Apply(25.5, start.Add(4*time.Minute))
+
+	// Assert
+	if spikeResult != Unconfirmed || recoveredResult != Accepted {
+		t.Fatalf("expected the spike to be held and dropped, got %v %v", spikeResult, recoveredResult)
+	}
+
+	if firstJumpResult != Unconfirmed || secondJumpResult != Accepted || value != 25.5 {
+		t.Fatalf("expected the confirmed jump to be accepted, got %v %v %v", firstJumpResult, secondJumpResult, value)
+	}
+
+	if stats := filter.Stats(); stats.Unconfirmed != 2 || stats.Accepted != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestFilter_MedianOf(t *testing.T) {
+	// Arrange
+	filter := NewFilter(Config{MedianOf: 3})
+	values := []float32{20, 21, 35, 22, 23}
+	expected := []float32{20, 20.5, 21, 22, 23}
+
+	for i := 0; i < len(values); i = i + 1 {
+		// Act
+		value, _ := filter.Apply(values[i], start.Add(time.Duration(i)*time.Minute))
+
+		// Assert
+		if value != expected[i] {
+			t.Fatalf("reading %d: expected %v, got %v", i, expected[i], value)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package hass
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Announcement is a Home Assistant MQTT discovery message.  An empty payload removes the entity from Home Assistant.
+type Announcement struct {
+	Topic   string
+	Payload []byte
+}
+
+type Device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+}
+
+type SensorConfig struct {
+	Name              string `json:"name"`
+	UniqueId          string `json:"unique_id"`
+	ObjectId          string `json:"object_id,omitempty"`
+	StateTopic        string `json:"state_topic"`
+	ValueTemplate     string `json:"value_template"`
+	DeviceClass       string `json:"device_class,omitempty"`
+	StateClass        string `json:"state_class,omitempty"`
+	UnitOfMeasurement string `json:"unit_of_measurement,omitempty"`
+	EntityCategory    string `json:"entity_category,omitempty"`
+	AvailabilityTopic string `json:"availability_topic,omitempty"`
+	Device            Device `json:"device"`
+}
+
+// Sensor describes one value of a device's JSON state.
+type Sensor struct {
+	Key               string
+	Name              string
+	Field             string
+	DeviceClass       string
+	UnitOfMeasurement string
+	Diagnostic        bool
+
+	// Optional fields are left out of the state when they're undefined, so they render as unknown.
+	Optional bool
+}
+
+var TemperatureSensor = Sensor{
+	Key: "temperature", Name: "Temperature", Field: "Temperature", DeviceClass: "temperature", UnitOfMeasurement: "°C"}
+var HumiditySensor = Sensor{
+	Key: "humidity", Name: "Humidity", Field: "Humidity", DeviceClass: "humidity", UnitOfMeasurement: "%"}
+var DewPointSensor = Sensor{
+	Key: "dew_point", Name: "Dew point", Field: "DewPoint", DeviceClass: "temperature", UnitOfMeasurement: "°C",
+	Optional: true}
+var BatterySensor = Sensor{
+	Key: "battery", Name: "Battery", Field: "BatteryLevel", DeviceClass: "battery", UnitOfMeasurement: "%",
+	Diagnostic: true}
+var RSSISensor = Sensor{
+	Key: "rssi", Name: "RSSI", Field: "RSSI", DeviceClass: "signal_strength", UnitOfMeasurement: "dBm",
+	Diagnostic: true}
+
+// AllWirelessThermometerSensors lists every sensor that may be announced for a wireless thermometer.
+var AllWirelessThermometerSensors = []Sensor{TemperatureSensor, HumiditySensor, DewPointSensor, BatterySensor, RSSISensor}
+
+// DeviceInfo identifies a device and the topics its sensors use.
+type DeviceInfo struct {
+	// NodeId must be unique and stable across restarts.  It's used in discovery topics and unique IDs.
+	NodeId            string
+	Name              string
+	Model             string
+	StateTopic        string
+	AvailabilityTopic string
+}
+
+// SensorAnnouncements builds the discovery messages that group the passed sensors into a single device.
+func SensorAnnouncements(discoveryPrefix string, device DeviceInfo, sensors []Sensor) ([]Announcement, error) {
+	result := make([]Announcement, 0, len(sensors))
+
+	for _, sensor := range sensors {
+		config := SensorConfig{
+			Name:              sensor.Name,
+			UniqueId:          fmt.Sprintf("%s_%s", device.NodeId, sensor.Key),
+			StateTopic:        device.StateTopic,
+			ValueTemplate:     valueTemplate(sensor),
+			DeviceClass:       sensor.DeviceClass,
+			StateClass:        "measurement",
+			UnitOfMeasurement: sensor.UnitOfMeasurement,
+			AvailabilityTopic: device.AvailabilityTopic,
+			Device: Device{
+				Identifiers:  []string{device.NodeId},
+				Name:         device.Name,
+				Manufacturer: "PMAAS",
+				Model:        device.Model,
+			},
+		}
+
+		if sensor.Diagnostic {
+			config.EntityCategory = "diagnostic"
+		}
+
+		payload, err := json.Marshal(config)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, Announcement{Topic: sensorTopic(discoveryPrefix, device.NodeId, sensor), Payload: payload})
+	}
+
+	return result, nil
+}
+
+// SensorRemovals builds the discovery messages that remove the passed sensors from Home Assistant.
+func SensorRemovals(discoveryPrefix string, nodeId string, sensors []Sensor) []Announcement {
+	result := make([]Announcement, len(sensors))
+
+	for i, sensor := range sensors {
+		result[i] = Announcement{Topic: sensorTopic(discoveryPrefix, nodeId, sensor), Payload: []byte{}}
+	}
+
+	return result
+}
+
+// StateTopic returns the topic the state of a device is published to for Home Assistant.  It's derived from the node
+// ID rather than the device's name, so it doesn't change when the device is renamed.
+func StateTopic(topicPrefix string, nodeId string) string {
+	return fmt.Sprintf("%s/device/%s/state", topicPrefix, nodeId)
+}
+
+func sensorTopic(discoveryPrefix string, nodeId string, sensor Sensor) string {
+	return fmt.Sprintf("%s/sensor/%s/%s/config", discoveryPrefix, nodeId, sensor.Key)
+}
+
+// NodeId converts an arbitrary identifier into a discovery node ID, which may only contain letters, digits,
+// underscores and hyphens.
+func NodeId(prefix string, id string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+
+		return '_'
+	}, id)
+
+	return prefix + "_" + mapped
+}
+
+func valueTemplate(sensor Sensor) string {
+	if sensor.Optional {
+		return fmt.Sprintf("{{ value_json.%s | default(none) }}", sensor.Field)
+	}
+
+	return fmt.Sprintf("{{ value_json.%s }}", sensor.Field)
+}
@@ -0,0 +1,94 @@
+package hass
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSensorAnnouncements(t *testing.T) {
+	// Arrange
+	device := DeviceInfo{
+		NodeId:            "pmaas_environment_sensor_1",
+		Name:              "Garage",
+		Model:             "Wireless Thermometer",
+		StateTopic:        "pmaas/environment/device/pmaas_environment_sensor_1/state",
+		AvailabilityTopic: "pmaas/environment/status",
+	}
+
+	// Act
+	announcements, err := SensorAnnouncements("homeassistant", device, []Sensor{TemperatureSensor, BatterySensor})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(announcements) != 2 {
+		t.Fatalf("expected 2 announcements, got %v", announcements)
+	}
+
+	if announcements[0].Topic != "homeassistant/sensor/pmaas_environment_sensor_1/temperature/config" {
+		t.Fatalf("unexpected topic %s", announcements[0].Topic)
+	}
+
+	var config SensorConfig
+	_ = json.Unmarshal(announcements[0].Payload, &config)
+
+	if config.UniqueId != "pmaas_environment_sensor_1_temperature" ||
+		config.DeviceClass != "temperature" ||
+		config.UnitOfMeasurement != "°C" ||
+		config.ValueTemplate != "{{ value_json.Temperature }}" ||
+		config.Device.Identifiers[0] != device.NodeId ||
+		config.Device.Name != "Garage" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	_ = json.Unmarshal(announcements[1].Payload, &config)
+
+	if config.EntityCategory != "diagnostic" || config.DeviceClass != "battery" {
+		t.Fatalf("unexpected battery config %+v", config)
+	}
+}
+
+func TestSensorAnnouncements_OptionalField(t *testing.T) {
+	// Act
+	announcements, _ := SensorAnnouncements("homeassistant", DeviceInfo{NodeId: "node"}, []Sensor{DewPointSensor})
+
+	// Assert
+	var config SensorConfig
+	_ = json.Unmarshal(announcements[0].Payload, &config)
+
+	if config.ValueTemplate != "{{ value_json.DewPoint | default(none) }}" {
+		t.Fatalf("unexpected value template %s", config.ValueTemplate)
+	}
+}
+
+func TestSensorRemovals(t *testing.T) {
+	// Act
+	removals := SensorRemovals("homeassistant", "node", []Sensor{RSSISensor})
+
+	// Assert
+	if len(removals) != 1 || removals[0].Topic != "homeassistant/sensor/node/rssi/config" || len(removals[0].Payload) != 0 {
+		t.Fatalf("unexpected removals %v", removals)
+	}
+}
+
+func TestNodeId(t *testing.T) {
+	// Act
+	result := NodeId("pmaas", "rtl433/Acurite-Tower:1234")
+
+	// Assert
+	if result != "pmaas_rtl433_Acurite-Tower_1234" {
+		t.Fatalf("unexpected node ID %s", result)
+	}
+}
+
+func TestStateTopic(t *testing.T) {
+	// Act
+	result := StateTopic("pmaas/environment", "pmaas_environment_sensor_1")
+
+	// Assert
+	if result != "pmaas/environment/device/pmaas_environment_sensor_1/state" {
+		t.Fatalf("unexpected state topic %s", result)
+	}
+}
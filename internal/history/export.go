@@ -0,0 +1,200 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/psychrometrics"
+	"github.com/avanha/pmaas-plugin-environment/internal/units"
+)
+
+// Series is the history of a single entity.
+type Series struct {
+	Entity   string
+	Readings []Reading
+}
+
+type ExportOptions struct {
+	TemperatureUnit units.TemperatureUnit
+	Location        *time.Location
+
+	// Decimals and DecimalComma format the temperatures in CSV exports.  A decimal comma makes the CSV separated by
+	// semicolons, as spreadsheets expect in those locales.
+	Decimals     int
+	DecimalComma bool
+}
+
+// ExportedReading is the JSON representation of a reading.  Values the sensor doesn't report are null.
+type ExportedReading struct {
+	Entity       string
+	Time         string
+	Temperature  float32
+	Humidity     *float32
+	DewPoint     *float32
+	BatteryLevel *int
+	RSSI         *int
+	Samples      int
+}
+
+type exportHeader struct {
+	TemperatureUnit string
+	TimeZone        string
+}
+
+func (o ExportOptions) exportedReading(entity string, reading Reading) ExportedReading {
+	location := o.Location
+
+	if location == nil {
+		location = time.Local
+	}
+
+	result := ExportedReading{
+		Entity:      entity,
+		Time:        reading.Time.In(location).Format(time.RFC3339),
+		Temperature: o.TemperatureUnit.FromCelsius(reading.Temperature),
+		Samples:     max(reading.Samples, 1),
+	}
+
+	if reading.HasHumidity {
+		humidity := reading.Humidity
+		result.Humidity = &humidity
+
+		if dewPoint, ok := psychrometrics.DewPoint(reading.Temperature, reading.Humidity); ok {
+			dewPoint = o.TemperatureUnit.FromCelsius(dewPoint)
+			result.DewPoint = &dewPoint
+		}
+	}
+
+	if reading.BatteryLevel != 0 {
+		batteryLevel := reading.BatteryLevel
+		result.BatteryLevel = &batteryLevel
+	}
+
+	if reading.RSSI != 0 {
+		rssi := reading.RSSI
+		result.RSSI = &rssi
+	}
+
+	return result
+}
+
+// WriteCsv writes the readings of each series as CSV, with a row per reading.
+func WriteCsv(w io.Writer, series []Series, options ExportOptions) error {
+	writer := csv.NewWriter(w)
+
+	if options.DecimalComma {
+		writer.Comma = ';'
+	}
+
+	unitSuffix := string(options.TemperatureUnit)
+	err := writer.Write([]string{
+		"entity", "time", "temperature_" + unitSuffix, "humidity", "dew_point_" + unitSuffix, "battery_level", "rssi",
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, s := range series {
+		for _, reading := range s.Readings {
+			exported := options.exportedReading(s.Entity, reading)
+			err = writer.Write([]string{
+				exported.Entity,
+				exported.Time,
+				options.formatNumber(&exported.Temperature, options.Decimals),
+				options.formatNumber(exported.Humidity, 1),
+				options.formatNumber(exported.DewPoint, options.Decimals),
+				formatOptionalInt(exported.BatteryLevel),
+				formatOptionalInt(exported.RSSI),
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// Flush after each series, so large exports are streamed to the client.
+		writer.Flush()
+
+		if err = writer.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteJson writes the readings of each series as a JSON object with the unit and time zone, and a Readings array.
+// Readings are encoded one at a time, so the export is streamed.
+func WriteJson(w io.Writer, series []Series, options ExportOptions) error {
+	location := options.Location
+
+	if location == nil {
+		location = time.Local
+	}
+
+	header, err := json.Marshal(exportHeader{
+		TemperatureUnit: options.TemperatureUnit.Symbol(),
+		TimeZone:        location.String(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Open the header object and add the Readings array to it.
+	_, err = fmt.Fprintf(w, "%s,\"Readings\":[", header[:len(header)-1])
+
+	if err != nil {
+		return err
+	}
+
+	separator := ""
+
+	for _, s := range series {
+		for _, reading := range s.Readings {
+			encoded, err := json.Marshal(options.exportedReading(s.Entity, reading))
+
+			if err != nil {
+				return err
+			}
+
+			if _, err = fmt.Fprintf(w, "%s\n%s", separator, encoded); err != nil {
+				return err
+			}
+
+			separator = ","
+		}
+	}
+
+	_, err = io.WriteString(w, "]}\n")
+
+	return err
+}
+
+func (o ExportOptions) formatNumber(value *float32, decimals int) string {
+	if value == nil {
+		return ""
+	}
+
+	result := strconv.FormatFloat(float64(*value), 'f', max(decimals, 0), 32)
+
+	if o.DecimalComma {
+		result = strings.Replace(result, ".", ",", 1)
+	}
+
+	return result
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*value)
+}
@@ -0,0 +1,143 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/units"
+)
+
+var exportSeries = []Series{
+	{
+		Entity: "Attic",
+		Readings: []Reading{
+			{Time: start, Temperature: 20, HasHumidity: true, Humidity: 50, BatteryLevel: 90, RSSI: -70},
+			{Time: start.Add(time.Minute), Temperature: 21},
+		},
+	},
+}
+
+func TestWriteCsv(t *testing.T) {
+	// Arrange
+	location, _ := time.LoadLocation("America/New_York")
+	var buffer bytes.Buffer
+
+	// Act
+	err := WriteCsv(&buffer, exportSeries, ExportOptions{TemperatureUnit: units.Fahrenheit, Location: location, Decimals: 2})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	expected := []string{
+		"entity,time,temperature_F,humidity,dew_point_F,battery_level,rssi",
+		"Attic,2024-03-01T07:00:00-05:00,68.00,50.0,48.67,90,-70",
+		"Attic,2024-03-01T07:01:00-05:00,69.80,,,,",
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected output %q", lines)
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("line %d: expected %s, got %s", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestWriteJson(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+
+	// Act
+	err := WriteJson(&buffer, exportSeries, ExportOptions{TemperatureUnit: units.Kelvin, Location: time.UTC})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result struct {
+		TemperatureUnit string
+		TimeZone        string
+		Readings        []ExportedReading
+	}
+
+	if err = json.Unmarshal(buffer.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buffer.String(), err)
+	}
+
+	if result.TemperatureUnit != "K" || result.TimeZone != "UTC" || len(result.Readings) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if result.Readings[0].Temperature != 293.15 || *result.Readings[0].Humidity != 50 ||
+		result.Readings[1].Humidity != nil || result.Readings[1].Time != "2024-03-01T12:01:00Z" {
+		t.Fatalf("unexpected readings %+v", result.Readings)
+	}
+}
+
+func TestWriteJson_UndefinedDewPoint(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+	series := []Series{
+		{
+			Entity: "Attic",
+			Readings: []Reading{
+				{Time: start, Temperature: 20, HasHumidity: true, Humidity: 0},
+				{Time: start.Add(time.Minute), Temperature: 21, HasHumidity: true, Humidity: 50},
+			},
+		},
+	}
+
+	// Act
+	err := WriteJson(&buffer, series, ExportOptions{TemperatureUnit: units.Celsius, Location: time.UTC})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result struct {
+		Readings []ExportedReading
+	}
+
+	if err = json.Unmarshal(buffer.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buffer.String(), err)
+	}
+
+	if len(result.Readings) != 2 || *result.Readings[0].Humidity != 0 || result.Readings[0].DewPoint != nil ||
+		result.Readings[1].DewPoint == nil {
+		t.Fatalf("unexpected readings %+v", result.Readings)
+	}
+}
+
+func TestWriteCsv_DecimalComma(t *testing.T) {
+	// Arrange
+	var buffer bytes.Buffer
+
+	// Act
+	err := WriteCsv(&buffer, exportSeries[:1], ExportOptions{
+		TemperatureUnit: units.Celsius,
+		Location:        time.UTC,
+		Decimals:        1,
+		DecimalComma:    true,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+
+	if lines[1] != "Attic;2024-03-01T12:00:00Z;20,0;50,0;9,3;90;-70" {
+		t.Fatalf("unexpected line %s", lines[1])
+	}
+}
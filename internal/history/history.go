@@ -0,0 +1,274 @@
+package history
+
+import (
+	"time"
+)
+
+// Reading is a single reading of a thermometer, or the average of Samples readings once downsampled.  Downsampled
+// readings are timestamped with the start of their interval.
+type Reading struct {
+	Time         time.Time
+	Temperature  float32
+	HasHumidity  bool
+	Humidity     float32
+	BatteryLevel int
+	RSSI         int
+	Samples      int
+}
+
+type Config struct {
+	// MaxReadings and MaxRawAge bound the raw readings.  Older readings are averaged over DownsampleInterval and
+	// kept until they're MaxAge old.
+	MaxReadings        int
+	MaxRawAge          time.Duration
+	DownsampleInterval time.Duration
+	MaxAge             time.Duration
+}
+
+// History keeps the recent readings of a single entity in a ring buffer, and a downsampled ring buffer of older
+// ones.  It's not safe for concurrent use and is only accessed from the plugin goroutine.
+type History struct {
+	config      Config
+	raw         ring[Reading]
+	downsampled ring[Reading]
+	accumulator accumulator
+}
+
+// accumulator averages the readings of the interval that's being downsampled.
+type accumulator struct {
+	start           time.Time
+	count           int
+	humidityCount   int
+	temperatureSum  float64
+	humiditySum     float64
+	batteryLevelSum int
+	batteryCount    int
+	rssiSum         int
+	rssiCount       int
+}
+
+func NewHistory(config Config) *History {
+	if config.MaxReadings <= 0 {
+		config.MaxReadings = 10000
+	}
+
+	if config.DownsampleInterval <= 0 {
+		config.DownsampleInterval = 15 * time.Minute
+	}
+
+	downsampledCapacity := 0
+
+	// MaxReadings may evict raw readings long before they're MaxRawAge old, so the downsampled readings can span up to
+	// MaxAge.  One interval is added for the partial intervals at either end.
+	if config.MaxAge > config.MaxRawAge {
+		downsampledCapacity = int(config.MaxAge/config.DownsampleInterval) + 2
+	}
+
+	return &History{
+		config:      config,
+		raw:         newRing[Reading](config.MaxReadings),
+		downsampled: newRing[Reading](downsampledCapacity),
+	}
+}
+
+// Add appends a reading.  Readings must be added in time order.  Raw readings that no longer fit are downsampled.
+func (h *History) Add(reading Reading) {
+	reading.Samples = 1
+
+	if evicted, ok := h.raw.push(reading); ok {
+		h.downsample(evicted, reading.Time)
+	}
+
+	for h.config.MaxRawAge > 0 && h.raw.len() > 0 && reading.Time.Sub(h.raw.at(0).Time) > h.config.MaxRawAge {
+		evicted, _ := h.raw.popFront()
+		h.downsample(evicted, reading.Time)
+	}
+}
+
+func (h *History) downsample(reading Reading, now time.Time) {
+	if h.config.MaxAge <= h.config.MaxRawAge {
+		return
+	}
+
+	start := reading.Time.Truncate(h.config.DownsampleInterval)
+
+	if h.accumulator.count > 0 && !start.Equal(h.accumulator.start) {
+		h.downsampled.push(h.accumulator.reading())
+		h.accumulator = accumulator{}
+	}
+
+	h.accumulator.add(start, reading)
+
+	for h.downsampled.len() > 0 && now.Sub(h.downsampled.at(0).Time) > h.config.MaxAge {
+		h.downsampled.popFront()
+	}
+}
+
+// Range returns a copy of the readings at or after from, and before to, oldest first.  A zero time leaves that end
+// unbounded.
+func (h *History) Range(from time.Time, to time.Time) []Reading {
+	result := make([]Reading, 0)
+	appendInRange := func(reading Reading) {
+		if (from.IsZero() || !reading.Time.Before(from)) && (to.IsZero() || reading.Time.Before(to)) {
+			result = append(result, reading)
+		}
+	}
+
+	for i := 0; i < h.downsampled.len(); i = i + 1 {
+		appendInRange(h.downsampled.at(i))
+	}
+
+	if h.accumulator.count > 0 {
+		appendInRange(h.accumulator.reading())
+	}
+
+	for i := 0; i < h.raw.len(); i = i + 1 {
+		appendInRange(h.raw.at(i))
+	}
+
+	return result
+}
+
+// Latest returns the most recent reading.
+func (h *History) Latest() (Reading, bool) {
+	if h.raw.len() == 0 {
+		return Reading{}, false
+	}
+
+	return h.raw.at(h.raw.len() - 1), true
+}
+
+func (a *accumulator) add(start time.Time, reading Reading) {
+	a.start = start
+	a.count = a.count + reading.Samples
+	a.temperatureSum = a.temperatureSum + float64(reading.Temperature)*float64(reading.Samples)
+
+	if reading.HasHumidity {
+		a.humidityCount = a.humidityCount + reading.Samples
+		a.humiditySum = a.humiditySum + float64(reading.Humidity)*float64(reading.Samples)
+	}
+
+	if reading.BatteryLevel != 0 {
+		a.batteryCount = a.batteryCount + reading.Samples
+		a.batteryLevelSum = a.batteryLevelSum + reading.BatteryLevel*reading.Samples
+	}
+
+	if reading.RSSI != 0 {
+		a.rssiCount = a.rssiCount + reading.Samples
+		a.rssiSum = a.rssiSum + reading.RSSI*reading.Samples
+	}
+}
+
+func (a *accumulator) reading() Reading {
+	result := Reading{
+		Time:        a.start,
+		Temperature: float32(a.temperatureSum / float64(a.count)),
+		Samples:     a.count,
+	}
+
+	if a.humidityCount > 0 {
+		result.HasHumidity = true
+		result.Humidity = float32(a.humiditySum / float64(a.humidityCount))
+	}
+
+	if a.batteryCount > 0 {
+		result.BatteryLevel = a.batteryLevelSum / a.batteryCount
+	}
+
+	if a.rssiCount > 0 {
+		result.RSSI = a.rssiSum / a.rssiCount
+	}
+
+	return result
+}
+
+// Downsample averages the readings over intervals of step, for example to limit the number of points in a chart.
+// Readings must be in time order.
+func Downsample(readings []Reading, step time.Duration) []Reading {
+	if step <= 0 {
+		return readings
+	}
+
+	result := make([]Reading, 0)
+	current := accumulator{}
+
+	for _, reading := range readings {
+		start := reading.Time.Truncate(step)
+
+		if current.count > 0 && !start.Equal(current.start) {
+			result = append(result, current.reading())
+			current = accumulator{}
+		}
+
+		if reading.Samples == 0 {
+			reading.Samples = 1
+		}
+
+		current.add(start, reading)
+	}
+
+	if current.count > 0 {
+		result = append(result, current.reading())
+	}
+
+	return result
+}
+
+// Summary holds the extremes and averages of a set of readings.
+type Summary struct {
+	HasData            bool
+	MinTemperature     float32
+	MinTemperatureTime time.Time
+	MaxTemperature     float32
+	MaxTemperatureTime time.Time
+	AverageTemperature float32
+	HasHumidity        bool
+	MinHumidity        float32
+	MaxHumidity        float32
+	AverageHumidity    float32
+}
+
+// Summarize returns the extremes and averages of the readings.  Downsampled readings are weighted by their samples.
+func Summarize(readings []Reading) Summary {
+	result := Summary{}
+	current := accumulator{}
+
+	for _, reading := range readings {
+		if reading.Samples == 0 {
+			reading.Samples = 1
+		}
+
+		if !result.HasData || reading.Temperature < result.MinTemperature {
+			result.MinTemperature = reading.Temperature
+			result.MinTemperatureTime = reading.Time
+		}
+
+		if !result.HasData || reading.Temperature > result.MaxTemperature {
+			result.MaxTemperature = reading.Temperature
+			result.MaxTemperatureTime = reading.Time
+		}
+
+		if reading.HasHumidity {
+			if !result.HasHumidity || reading.Humidity < result.MinHumidity {
+				result.MinHumidity = reading.Humidity
+			}
+
+			if !result.HasHumidity || reading.Humidity > result.MaxHumidity {
+				result.MaxHumidity = reading.Humidity
+			}
+
+			result.HasHumidity = true
+		}
+
+		result.HasData = true
+		current.add(time.Time{}, reading)
+	}
+
+	if result.HasData {
+		average := current.reading()
+		result.AverageTemperature = average.Temperature
+		result.AverageHumidity = average.Humidity
+	}
+
+	return result
+}
@@ -0,0 +1,157 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func addReadings(history *History, count int, interval time.Duration) {
+	for i := 0; i < count; i = i + 1 {
+		history.Add(Reading{
+			Time:        start.Add(time.Duration(i) * interval),
+			Temperature: float32(i),
+			HasHumidity: true,
+			Humidity:    50,
+		})
+	}
+}
+
+func TestHistory_DropsOldestReadings(t *testing.T) {
+	// Arrange
+	history := NewHistory(Config{MaxReadings: 3})
+
+	// Act
+	addReadings(history, 5, time.Minute)
+
+	// Assert
+	readings := history.Range(time.Time{}, time.Time{})
+
+	if len(readings) != 3 || readings[0].Temperature != 2 || readings[2].Temperature != 4 {
+		t.Fatalf("unexpected readings %v", readings)
+	}
+}
+
+func TestHistory_Range(t *testing.T) {
+	// Arrange
+	history := NewHistory(Config{})
+	addReadings(history, 5, time.Minute)
+
+	// Act
+	readings := history.Range(start.Add(time.Minute), start.Add(3*time.Minute))
+
+	// Assert
+	if len(readings) != 2 || readings[0].Temperature != 1 || readings[1].Temperature != 2 {
+		t.Fatalf("unexpected readings %v", readings)
+	}
+}
+
+func TestHistory_DownsamplesOldReadings(t *testing.T) {
+	// Arrange
+	history := NewHistory(Config{
+		MaxReadings:        100,
+		MaxRawAge:          10 * time.Minute,
+		DownsampleInterval: 5 * time.Minute,
+		MaxAge:             time.Hour,
+	})
+
+	// Act
+	addReadings(history, 21, time.Minute)
+
+	// Assert
+	readings := history.Range(time.Time{}, time.Time{})
+
+	// 12:00-12:04 and 12:05-12:09 are downsampled, 12:10 is too old and accumulating, 12:11-12:20 are raw.
+	if len(readings) != 13 {
+		t.Fatalf("unexpected readings %v", readings)
+	}
+
+	if readings[0].Time != start || readings[0].Temperature != 2 || readings[0].Samples != 5 ||
+		readings[0].Humidity != 50 {
+		t.Fatalf("unexpected first downsampled reading %+v", readings[0])
+	}
+
+	if readings[1].Temperature != 7 || readings[2].Samples != 1 || readings[3].Temperature != 11 {
+		t.Fatalf("unexpected readings %v", readings)
+	}
+}
+
+func TestHistory_DropsReadingsOlderThanMaxAge(t *testing.T) {
+	// Arrange
+	history := NewHistory(Config{
+		MaxReadings:        10,
+		MaxRawAge:          time.Hour,
+		DownsampleInterval: time.Hour,
+		MaxAge:             5 * time.Hour,
+	})
+
+	// Act
+	addReadings(history, 48, time.Hour)
+
+	// Assert
+	readings := history.Range(time.Time{}, time.Time{})
+	oldest := readings[0].Time
+	latest, _ := history.Latest()
+
+	if latest.Time.Sub(oldest) > 5*time.Hour {
+		t.Fatalf("expected readings within 5 hours, oldest is %v, latest is %v", oldest, latest.Time)
+	}
+}
+
+func TestHistory_KeepsDownsampledReadingsWhenRawReadingsAreEvictedByCount(t *testing.T) {
+	// Arrange
+	history := NewHistory(Config{
+		MaxReadings:        10,
+		MaxRawAge:          24 * time.Hour,
+		DownsampleInterval: time.Hour,
+		MaxAge:             48 * time.Hour,
+	})
+
+	// Act
+	// 40 hours of readings, of which only the last 100 minutes fit in the raw readings.
+	addReadings(history, 240, 10*time.Minute)
+
+	// Assert
+	readings := history.Range(time.Time{}, time.Time{})
+
+	if len(readings) == 0 || !readings[0].Time.Equal(start) {
+		t.Fatalf("expected the downsampled readings to reach back to %v, got %v", start, readings)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	// Arrange
+	history := NewHistory(Config{})
+	addReadings(history, 6, time.Minute)
+
+	// Act
+	readings := Downsample(history.Range(time.Time{}, time.Time{}), 3*time.Minute)
+
+	// Assert
+	if len(readings) != 2 || readings[0].Temperature != 1 || readings[1].Temperature != 4 || readings[1].Samples != 3 {
+		t.Fatalf("unexpected readings %v", readings)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	// Arrange
+	readings := []Reading{
+		{Time: start, Temperature: 10, HasHumidity: true, Humidity: 40, Samples: 3},
+		{Time: start.Add(time.Hour), Temperature: 20, HasHumidity: true, Humidity: 60},
+		{Time: start.Add(2 * time.Hour), Temperature: 5},
+	}
+
+	// Act
+	summary := Summarize(readings)
+
+	// Assert
+	if !summary.HasData || summary.MinTemperature != 5 || !summary.MinTemperatureTime.Equal(start.Add(2*time.Hour)) ||
+		summary.MaxTemperature != 20 || summary.AverageTemperature != 11 {
+		t.Fatalf("unexpected temperature summary %+v", summary)
+	}
+
+	if !summary.HasHumidity || summary.MinHumidity != 40 || summary.MaxHumidity != 60 || summary.AverageHumidity != 45 {
+		t.Fatalf("unexpected humidity summary %+v", summary)
+	}
+}
@@ -0,0 +1,55 @@
+package history
+
+// ring is a fixed-capacity FIFO buffer.
+type ring[T any] struct {
+	items []T
+	start int
+	count int
+}
+
+func newRing[T any](capacity int) ring[T] {
+	return ring[T]{items: make([]T, max(capacity, 1))}
+}
+
+func (r *ring[T]) len() int {
+	return r.count
+}
+
+func (r *ring[T]) full() bool {
+	return r.count == len(r.items)
+}
+
+// at returns the i-th oldest item.
+func (r *ring[T]) at(i int) T {
+	return r.items[(r.start+i)%len(r.items)]
+}
+
+// push appends an item, evicting and returning the oldest one if the buffer is full.
+func (r *ring[T]) push(item T) (T, bool) {
+	var evicted T
+	ok := false
+
+	if r.full() {
+		evicted, ok = r.popFront()
+	}
+
+	r.items[(r.start+r.count)%len(r.items)] = item
+	r.count = r.count + 1
+
+	return evicted, ok
+}
+
+func (r *ring[T]) popFront() (T, bool) {
+	var item T
+
+	if r.count == 0 {
+		return item, false
+	}
+
+	item = r.items[r.start]
+	r.items[r.start] = *new(T)
+	r.start = (r.start + 1) % len(r.items)
+	r.count = r.count - 1
+
+	return item, true
+}
@@ -0,0 +1,335 @@
+package humidistat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/controller"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-plugin-environment/internal/psychrometrics"
+	"github.com/avanha/pmaas-plugin-environment/internal/schedule"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	spievents "github.com/avanha/pmaas-spi/events"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type Settings struct {
+	Name            string
+	Input           string
+	Mode            entities.HumidistatMode
+	ControlVariable entities.HumidityVariable
+	Setpoint        float32
+	Hysteresis      float32
+	MinOnTime       time.Duration
+	MinOffTime      time.Duration
+	MaxRunTime      time.Duration
+	CooldownTime    time.Duration
+	SwitchEntityId  string
+	Schedule        *schedule.Schedule
+}
+
+func CreateHumidistat(
+	instanceId int,
+	settings Settings,
+	switchFn controller.SwitchFunc,
+	trackingConfig tracking.Config) *Humidistat {
+	minOnTime := settings.MinOnTime
+
+	// The max run time takes precedence, otherwise the output couldn't be turned off when it's reached
+	if settings.MaxRunTime > 0 {
+		minOnTime = min(minOnTime, settings.MaxRunTime)
+	}
+
+	return &Humidistat{
+		Id:              fmt.Sprintf("Humidistat_%d", instanceId),
+		Name:            settings.Name,
+		Input:           settings.Input,
+		Mode:            settings.Mode,
+		ControlVariable: settings.ControlVariable,
+		Setpoint:        settings.Setpoint,
+		Hysteresis:      settings.Hysteresis,
+		MaxRunTime:      settings.MaxRunTime,
+		CooldownTime:    settings.CooldownTime,
+		Output: controller.CreateSwitchOutput(
+			settings.SwitchEntityId, minOnTime, settings.MinOffTime, switchFn),
+		Scheduler:      schedule.CreateScheduler(settings.Schedule),
+		trackingConfig: trackingConfig,
+	}
+}
+
+// Humidistat runs a humidifier or dehumidifier to keep the relative humidity or dew point of a thermometer near a
+// setpoint.
+type Humidistat struct {
+	Id              string
+	PmaasEntityId   string
+	Name            string
+	Input           string
+	Mode            entities.HumidistatMode
+	ControlVariable entities.HumidityVariable
+	State           entities.HumidistatState
+	Setpoint        float32
+	Hysteresis      float32
+	MaxRunTime      time.Duration
+	CooldownTime    time.Duration
+	HasInput        bool
+	Temperature     float32
+	Humidity        float32
+	HasDewPoint     bool
+	DewPoint        float32
+	Output          controller.SwitchOutput
+	Scheduler       schedule.Scheduler
+	RunTimeToday    time.Duration
+	TotalRunTime    time.Duration
+	LastUpdateTime  time.Time
+
+	// Display is only populated on state copies that are rendered.
+	Display display.Preferences
+
+	trackingConfig tracking.Config
+	stub           *humidistatStub
+}
+
+// Force implementation of schedule.IScheduled
+var _ schedule.IScheduled = (*Humidistat)(nil)
+
+func (h *Humidistat) GetStub(container spi.IPMAASContainer) entities.Humidistat {
+	if h.stub == nil {
+		h.stub = newHumidistatStub(
+			h.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.Humidistat]{
+				Container: container,
+				Entity:    h,
+			})
+	}
+
+	return h.stub
+}
+
+func (h *Humidistat) TrackingConfig() tracking.Config {
+	return h.trackingConfig
+}
+
+func (h *Humidistat) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: h.LastUpdateTime,
+		Data: data.HumidistatData{
+			HasInput:            h.HasInput,
+			Humidity:            h.Humidity,
+			HasDewPoint:         h.HasDewPoint,
+			DewPoint:            h.DewPoint,
+			Setpoint:            h.Setpoint,
+			State:               int32(h.State),
+			RunTimeTodaySeconds: int64(h.RunTimeToday.Seconds()),
+			LastUpdateTime:      h.LastUpdateTime,
+		},
+	}
+}
+
+func (h *Humidistat) GetSortKey() string {
+	return h.Name
+}
+
+func (h *Humidistat) GetState() any {
+	return *h
+}
+
+func (h *Humidistat) GetScheduler() *schedule.Scheduler {
+	return &h.Scheduler
+}
+
+func (h *Humidistat) ValidateSetpoints(setpoints schedule.Setpoints) error {
+	if h.ControlVariable == entities.HumidityVariableRelativeHumidity &&
+		(setpoints.Humidity <= 0 || setpoints.Humidity >= 100) {
+		return fmt.Errorf("relative humidity setpoint %.1f must be between 0 and 100", setpoints.Humidity)
+	}
+
+	return nil
+}
+
+func (h *Humidistat) entityEvent() spievents.EntityEvent {
+	return spievents.EntityEvent{
+		Id:         h.PmaasEntityId,
+		EntityType: entities.HumidistatType,
+		Name:       h.Name,
+	}
+}
+
+// SetMode changes the operating mode.  The output is updated on the next evaluation.
+func (h *Humidistat) SetMode(mode entities.HumidistatMode, publishEventFunc func(pmaasEntityId string, event any)) {
+	if h.Mode == mode {
+		return
+	}
+
+	oldMode := h.Mode
+	h.Mode = mode
+	publishEventFunc(h.PmaasEntityId, entities.HumidistatModeChangeEvent{
+		EntityEvent: h.entityEvent(),
+		NewMode:     mode,
+		OldMode:     oldMode,
+	})
+}
+
+// SetSetpoint changes the setpoint.  On a scheduled humidistat, the change is a hold that lasts until the next period
+// starts.
+func (h *Humidistat) SetSetpoint(
+	setpoint float32,
+	now time.Time,
+	publishEventFunc func(pmaasEntityId string, event any)) error {
+	setpoints := schedule.Setpoints{Humidity: setpoint}
+	err := h.ValidateSetpoints(setpoints)
+
+	if err != nil {
+		return err
+	}
+
+	if h.Scheduler.Enabled() {
+		if h.Setpoint == setpoint {
+			return nil
+		}
+
+		h.Scheduler.HoldUntil(setpoints, time.Time{}, now)
+		h.Scheduler.Evaluate(now)
+	}
+
+	h.applySetpoint(setpoint, publishEventFunc)
+
+	return nil
+}
+
+func (h *Humidistat) applySetpoint(setpoint float32, publishEventFunc func(pmaasEntityId string, event any)) {
+	if h.Setpoint == setpoint {
+		return
+	}
+
+	oldValue := h.Setpoint
+	h.Setpoint = setpoint
+	publishEventFunc(h.PmaasEntityId, entities.HumidistatSetpointChangeEvent{
+		EntityEvent: h.entityEvent(),
+		NewValue:    setpoint,
+		OldValue:    oldValue,
+	})
+}
+
+func (h *Humidistat) Evaluate(
+	now time.Time,
+	lookup controller.SensorLookupFunc,
+	publishEventFunc func(pmaasEntityId string, event any)) {
+	h.accumulateRunTime(now)
+
+	sensorData, ok := lookup(h.Input)
+	h.HasInput = ok && sensorData.HasHumidity
+
+	if h.HasInput {
+		h.Temperature = sensorData.Temperature
+		h.Humidity = sensorData.Humidity
+		h.DewPoint, h.HasDewPoint = psychrometrics.DewPoint(sensorData.Temperature, sensorData.Humidity)
+
+		// Without a dew point there's nothing to control on
+		h.HasInput = h.HasDewPoint || h.ControlVariable != entities.HumidityVariableDewPoint
+	}
+
+	if setpoints, changed := h.Scheduler.Evaluate(now); changed {
+		err := h.ValidateSetpoints(setpoints)
+
+		if err == nil {
+			h.applySetpoint(setpoints.Humidity, publishEventFunc)
+		} else {
+			fmt.Printf("Humidistat %s: ignoring %s setpoint: %v\n", h.Id, h.Scheduler.Source, err)
+		}
+	}
+
+	if h.Output.On && h.MaxRunTime > 0 && h.Output.OnDuration(now) >= h.MaxRunTime {
+		h.setOutput(false, now)
+
+		if !h.Output.On {
+			h.Output.LockOut(now.Add(h.CooldownTime))
+		}
+	} else {
+		h.setOutput(h.HasInput && h.demand(), now)
+	}
+
+	currentState := h.State
+
+	switch {
+	case h.Output.On:
+		h.State = entities.HumidistatStateRunning
+	case now.Before(h.Output.LockedOutUntil):
+		h.State = entities.HumidistatStateCooldown
+	default:
+		h.State = entities.HumidistatStateIdle
+	}
+
+	if currentState != h.State {
+		publishEventFunc(h.PmaasEntityId, entities.HumidistatStateChangeEvent{
+			EntityEvent: h.entityEvent(),
+			NewState:    h.State,
+			OldState:    currentState,
+		})
+	}
+}
+
+// ControlValue returns the measurement selected by ControlVariable.
+func (h *Humidistat) ControlValue() float32 {
+	if h.ControlVariable == entities.HumidityVariableDewPoint {
+		return h.DewPoint
+	}
+
+	return h.Humidity
+}
+
+// demand applies the hysteresis band, centred on the setpoint.  The output state determines which edge of the band
+// applies.
+func (h *Humidistat) demand() bool {
+	value := h.ControlValue()
+	halfBand := h.Hysteresis / 2
+
+	switch h.Mode {
+	case entities.HumidistatModeDehumidify:
+		if h.Output.On {
+			return value > h.Setpoint-halfBand
+		}
+
+		return value >= h.Setpoint+halfBand
+	case entities.HumidistatModeHumidify:
+		if h.Output.On {
+			return value < h.Setpoint+halfBand
+		}
+
+		return value <= h.Setpoint-halfBand
+	}
+
+	return false
+}
+
+func (h *Humidistat) setOutput(on bool, now time.Time) {
+	_, err := h.Output.Set(on, now)
+
+	if err != nil {
+		fmt.Printf("Humidistat %s: unable to switch %s: %v\n", h.Id, h.Output.SwitchEntityId, err)
+	}
+}
+
+// accumulateRunTime adds the time the output was on since the previous evaluation to the run time totals.  The daily
+// total resets at local midnight.
+func (h *Humidistat) accumulateRunTime(now time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if h.LastUpdateTime.Before(midnight) {
+		h.RunTimeToday = 0
+	}
+
+	if h.Output.On && !h.LastUpdateTime.IsZero() {
+		h.TotalRunTime = h.TotalRunTime + now.Sub(h.LastUpdateTime)
+
+		if h.LastUpdateTime.Before(midnight) {
+			h.RunTimeToday = now.Sub(midnight)
+		} else {
+			h.RunTimeToday = h.RunTimeToday + now.Sub(h.LastUpdateTime)
+		}
+	}
+
+	h.LastUpdateTime = now
+}
@@ -0,0 +1,186 @@
+package humidistat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+func createTestHumidistat(settings Settings, calls *[]bool) *Humidistat {
+	settings.Name = "Crawlspace"
+	settings.Input = "input"
+	settings.SwitchEntityId = "dehumidifier"
+
+	return CreateHumidistat(
+		1,
+		settings,
+		func(pmaasEntityId string, on bool) error {
+			*calls = append(*calls, on)
+			return nil
+		},
+		tracking.Config{})
+}
+
+func humidityLookup(temperature *float32, humidity *float32) func(string) (spienvironment.SensorData, bool) {
+	return func(name string) (spienvironment.SensorData, bool) {
+		return spienvironment.SensorData{Temperature: *temperature, HasHumidity: true, Humidity: *humidity}, true
+	}
+}
+
+func TestHumidistat_Evaluate_DehumidifiesWithHysteresis(t *testing.T) {
+	// Arrange
+	var calls []bool
+	var events []any
+	temperature := float32(18)
+	humidity := float32(66)
+	h := createTestHumidistat(Settings{
+		Mode:       entities.HumidistatModeDehumidify,
+		Setpoint:   60,
+		Hysteresis: 4,
+	}, &calls)
+	publish := func(pmaasEntityId string, event any) { events = append(events, event) }
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lookup := humidityLookup(&temperature, &humidity)
+
+	// Act
+	h.Evaluate(start, lookup, publish)
+	stateAboveBand := h.State
+	humidity = 59
+	h.Evaluate(start.Add(10*time.Minute), lookup, publish)
+	stateInsideBand := h.State
+	humidity = 57.9
+	h.Evaluate(start.Add(20*time.Minute), lookup, publish)
+
+	// Assert
+	if stateAboveBand != entities.HumidistatStateRunning || stateInsideBand != entities.HumidistatStateRunning {
+		t.Fatalf("expected running above and inside the band, got %v and %v", stateAboveBand, stateInsideBand)
+	}
+
+	if h.State != entities.HumidistatStateIdle {
+		t.Fatalf("expected idle below the band, got %v", h.State)
+	}
+
+	if len(calls) != 2 || len(events) != 2 {
+		t.Fatalf("expected 2 switch calls and 2 events, got %v and %v", calls, events)
+	}
+
+	if h.RunTimeToday != 20*time.Minute {
+		t.Fatalf("expected 20m of run time, got %v", h.RunTimeToday)
+	}
+}
+
+func TestHumidistat_Evaluate_DewPoint(t *testing.T) {
+	// Arrange
+	var calls []bool
+	temperature := float32(20)
+	humidity := float32(50)
+	h := createTestHumidistat(Settings{
+		Mode:            entities.HumidistatModeDehumidify,
+		ControlVariable: entities.HumidityVariableDewPoint,
+		Setpoint:        8,
+		Hysteresis:      1,
+	}, &calls)
+
+	// Act
+	h.Evaluate(time.Now(), humidityLookup(&temperature, &humidity), func(string, any) {})
+
+	// Assert
+	if !h.Output.On {
+		t.Fatalf("expected the dehumidifier to run at a dew point of %v", h.DewPoint)
+	}
+}
+
+func TestHumidistat_Evaluate_DewPointOfDryAir(t *testing.T) {
+	// Arrange
+	var calls []bool
+	temperature := float32(20)
+	humidity := float32(0)
+	h := createTestHumidistat(Settings{
+		Mode:            entities.HumidistatModeHumidify,
+		ControlVariable: entities.HumidityVariableDewPoint,
+		Setpoint:        8,
+		Hysteresis:      1,
+	}, &calls)
+
+	// Act
+	h.Evaluate(time.Now(), humidityLookup(&temperature, &humidity), func(string, any) {})
+
+	// Assert
+	if h.HasInput || h.HasDewPoint || h.Output.On {
+		t.Fatalf("expected no input without a dew point, got %+v", h)
+	}
+
+	if h.Data().Data.(data.HumidistatData).HasDewPoint {
+		t.Fatalf("expected no tracked dew point")
+	}
+}
+
+func TestHumidistat_Evaluate_MaxRunTimeStartsCooldown(t *testing.T) {
+	// Arrange
+	var calls []bool
+	temperature := float32(18)
+	humidity := float32(70)
+	h := createTestHumidistat(Settings{
+		Mode:         entities.HumidistatModeDehumidify,
+		Setpoint:     60,
+		Hysteresis:   4,
+		MinOffTime:   10 * time.Minute,
+		MaxRunTime:   time.Hour,
+		CooldownTime: 20 * time.Minute,
+	}, &calls)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lookup := humidityLookup(&temperature, &humidity)
+	publish := func(string, any) {}
+
+	// Act
+	h.Evaluate(start, lookup, publish)
+	h.Evaluate(start.Add(time.Hour), lookup, publish)
+	stateAfterMaxRunTime := h.State
+	h.Evaluate(start.Add(time.Hour+15*time.Minute), lookup, publish)
+	stateDuringCooldown := h.State
+	h.Evaluate(start.Add(time.Hour+20*time.Minute), lookup, publish)
+
+	// Assert
+	if stateAfterMaxRunTime != entities.HumidistatStateCooldown || stateDuringCooldown != entities.HumidistatStateCooldown {
+		t.Fatalf("expected cooldown, got %v and %v", stateAfterMaxRunTime, stateDuringCooldown)
+	}
+
+	if h.State != entities.HumidistatStateRunning {
+		t.Fatalf("expected running after the cooldown, got %v", h.State)
+	}
+}
+
+func TestHumidistat_Evaluate_MaxRunTimeOverridesMinOnTime(t *testing.T) {
+	// Arrange
+	var calls []bool
+	temperature := float32(18)
+	humidity := float32(70)
+	h := createTestHumidistat(Settings{
+		Mode:         entities.HumidistatModeDehumidify,
+		Setpoint:     60,
+		Hysteresis:   4,
+		MinOnTime:    2 * time.Hour,
+		MaxRunTime:   time.Hour,
+		CooldownTime: 20 * time.Minute,
+	}, &calls)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lookup := humidityLookup(&temperature, &humidity)
+	publish := func(string, any) {}
+
+	// Act
+	h.Evaluate(start, lookup, publish)
+	h.Evaluate(start.Add(time.Hour), lookup, publish)
+
+	// Assert
+	if h.Output.On || h.State != entities.HumidistatStateCooldown {
+		t.Fatalf("expected the output to turn off at the max run time, got %v", h.State)
+	}
+
+	if len(calls) != 2 || calls[1] {
+		t.Fatalf("expected the switch to be turned on and off, got %v", calls)
+	}
+}
@@ -0,0 +1,69 @@
+package humidistat
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type humidistatStub struct {
+	id                     string
+	closeFn                func() error
+	entityWrapperReference atomic.Pointer[common.ThreadSafeEntityWrapper[entities.Humidistat]]
+}
+
+func (s *humidistatStub) TrackingConfig() tracking.Config {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.Humidistat) tracking.Config { return target.TrackingConfig() })
+}
+
+func (s *humidistatStub) Data() tracking.DataSample {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.Humidistat) tracking.DataSample { return target.Data() })
+}
+
+func (s *humidistatStub) GetSortKey() string {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.Humidistat) string { return target.GetSortKey() })
+}
+
+func newHumidistatStub(
+	id string,
+	entityWrapper *common.ThreadSafeEntityWrapper[entities.Humidistat]) *humidistatStub {
+	instance := &humidistatStub{
+		id: id,
+	}
+
+	instance.entityWrapperReference.Store(entityWrapper)
+
+	instance.closeFn = func() error {
+		if instance.entityWrapperReference.CompareAndSwap(entityWrapper, nil) {
+			instance.closeFn = nil
+			return nil
+		}
+
+		return fmt.Errorf("failed to clear entity wrapper, current value does not match expected value")
+	}
+
+	return instance
+}
+
+func (s *humidistatStub) close() {
+	closeFn := s.closeFn
+
+	if closeFn == nil {
+		return
+	}
+
+	err := closeFn()
+
+	if err != nil {
+		fmt.Printf("Failed to close humidistat stub %s: %v", s.id, err)
+	}
+}
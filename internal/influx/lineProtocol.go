@@ -0,0 +1,133 @@
+package influx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Point is a single InfluxDB data point.  Tags with empty values are omitted.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]any
+	Time        time.Time
+}
+
+var measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+var keyEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+var stringFieldEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+
+// ErrNonFinite is returned for NaN and infinite field values, which line protocol can't represent.
+var ErrNonFinite = errors.New("non-finite value")
+
+// Encode formats the point as a line of InfluxDB line protocol with a nanosecond timestamp, without the trailing
+// newline.  Fields with non-finite values are left out, since InfluxDB would reject the whole line; a point without
+// any other fields returns an error.
+func (p Point) Encode() (string, error) {
+	if p.Measurement == "" {
+		return "", fmt.Errorf("point has no measurement")
+	}
+
+	if len(p.Fields) == 0 {
+		return "", fmt.Errorf("point %s has no fields", p.Measurement)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(measurementEscaper.Replace(p.Measurement))
+
+	for _, key := range sortedKeys(p.Tags) {
+		value := p.Tags[key]
+
+		if value == "" {
+			continue
+		}
+
+		builder.WriteByte(',')
+		builder.WriteString(keyEscaper.Replace(key))
+		builder.WriteByte('=')
+		builder.WriteString(keyEscaper.Replace(value))
+	}
+
+	fieldCount := 0
+
+	for _, key := range sortedKeys(p.Fields) {
+		value, err := formatField(p.Fields[key])
+
+		if errors.Is(err, ErrNonFinite) {
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", key, err)
+		}
+
+		fieldCount = fieldCount + 1
+
+		if fieldCount == 1 {
+			builder.WriteByte(' ')
+		} else {
+			builder.WriteByte(',')
+		}
+
+		builder.WriteString(keyEscaper.Replace(key))
+		builder.WriteByte('=')
+		builder.WriteString(value)
+	}
+
+	if fieldCount == 0 {
+		return "", fmt.Errorf("point %s has no finite fields", p.Measurement)
+	}
+
+	if !p.Time.IsZero() {
+		builder.WriteByte(' ')
+		builder.WriteString(strconv.FormatInt(p.Time.UnixNano(), 10))
+	}
+
+	return builder.String(), nil
+}
+
+func formatField(value any) (string, error) {
+	switch typedValue := value.(type) {
+	case float32:
+		return formatFloat(float64(typedValue), 32)
+	case float64:
+		return formatFloat(typedValue, 64)
+	case int:
+		return strconv.FormatInt(int64(typedValue), 10) + "i", nil
+	case int32:
+		return strconv.FormatInt(int64(typedValue), 10) + "i", nil
+	case int64:
+		return strconv.FormatInt(typedValue, 10) + "i", nil
+	case bool:
+		return strconv.FormatBool(typedValue), nil
+	case string:
+		return `"` + stringFieldEscaper.Replace(typedValue) + `"`, nil
+	default:
+		return "", fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func formatFloat(value float64, bitSize int) (string, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", ErrNonFinite
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, bitSize), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
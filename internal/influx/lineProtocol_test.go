@@ -0,0 +1,77 @@
+package influx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPoint_Encode(t *testing.T) {
+	// Arrange
+	point := Point{
+		Measurement: "environment",
+		Tags:        map[string]string{"name": "Living Room", "id": "a,b=c", "zone": ""},
+		Fields: map[string]any{
+			"temperature":   float32(21.5),
+			"battery_level": int32(80),
+			"note":          `say "hi"`,
+			"online":        true,
+		},
+		Time: time.Unix(1700000000, 5),
+	}
+
+	// Act
+	line, err := point.Encode()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `environment,id=a\,b\=c,name=Living\ Room battery_level=80i,note="say \"hi\"",online=true,` +
+		`temperature=21.5 1700000000000000005`
+
+	if line != expected {
+		t.Fatalf("expected %s, got %s", expected, line)
+	}
+}
+
+func TestPoint_EncodeRequiresFields(t *testing.T) {
+	// Act
+	_, err := Point{Measurement: "environment"}.Encode()
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected an error for a point without fields")
+	}
+}
+
+func TestPoint_EncodeLeavesOutNonFiniteFields(t *testing.T) {
+	// Arrange
+	point := Point{
+		Measurement: "environment",
+		Fields: map[string]any{
+			"dew_point":   float32(math.Inf(-1)),
+			"humidity":    math.NaN(),
+			"temperature": float32(21.5),
+		},
+	}
+
+	// Act
+	line, err := point.Encode()
+
+	// Assert
+	if err != nil || line != "environment temperature=21.5" {
+		t.Fatalf("unexpected line %s, error %v", line, err)
+	}
+}
+
+func TestPoint_EncodeRequiresFiniteFields(t *testing.T) {
+	// Act
+	_, err := Point{Measurement: "environment", Fields: map[string]any{"temperature": math.NaN()}}.Encode()
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected an error for a point without finite fields")
+	}
+}
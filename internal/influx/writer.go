@@ -0,0 +1,428 @@
+package influx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// URL is the full write endpoint including its query, for example
+	// http://localhost:8086/api/v2/write?org=home&bucket=environment&precision=ns.
+	URL string
+
+	// Token is sent as "Authorization: Token <Token>" when set.
+	Token string
+
+	// FilePath receives the lines when URL is empty.
+	FilePath string
+
+	// BufferPath receives the lines that couldn't be written.  They're written ahead of new lines on the next
+	// flush, including after a restart.  Leave empty to drop lines that couldn't be written.
+	BufferPath string
+
+	// MaxBufferSize is the maximum size of the buffer in bytes.  Lines that would make it larger are dropped.
+	MaxBufferSize int64
+
+	// BatchSize is the maximum number of lines per write.  Lines are also written every FlushInterval.
+	BatchSize     int
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times a failed write is retried, with RetryDelay doubled after each attempt.
+	MaxRetries int
+	RetryDelay time.Duration
+
+	// QueueSize is the number of lines buffered in memory between flushes.
+	QueueSize int
+
+	HttpClient *http.Client
+}
+
+// Writer writes lines of InfluxDB line protocol in batches on its own goroutine.
+type Writer struct {
+	options  Options
+	lines    chan string
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	buffered bool
+}
+
+// permanentError is returned for writes that will never succeed, like ones the server rejects as malformed.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func NewWriter(options Options) *Writer {
+	if options.BatchSize <= 0 {
+		options.BatchSize = 500
+	}
+
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = 10 * time.Second
+	}
+
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = time.Second
+	}
+
+	if options.QueueSize <= 0 {
+		options.QueueSize = 10000
+	}
+
+	if options.MaxBufferSize <= 0 {
+		options.MaxBufferSize = 64 * 1024 * 1024
+	}
+
+	if options.HttpClient == nil {
+		options.HttpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Writer{
+		options: options,
+		lines:   make(chan string, options.QueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start starts the writer goroutine.
+func (w *Writer) Start() {
+	if w.options.BufferPath != "" {
+		info, err := os.Stat(w.options.BufferPath)
+		w.buffered = err == nil && info.Size() > 0
+	}
+
+	go w.run()
+}
+
+// Stop flushes the queued lines, making a single attempt, and stops the writer.  The returned channel is closed once
+// the writer goroutine exits.
+func (w *Writer) Stop() <-chan struct{} {
+	w.stopOnce.Do(func() { close(w.stop) })
+
+	return w.done
+}
+
+// Write queues a line for writing.  It doesn't block; an error is returned if the queue is full.
+func (w *Writer) Write(line string) error {
+	select {
+	case <-w.stop:
+		return errors.New("writer is stopped")
+	default:
+	}
+
+	select {
+	case w.lines <- line:
+		return nil
+	default:
+		return errors.New("queue full, dropping line")
+	}
+}
+
+// WritePoint encodes and queues a point.  Points that can't be encoded are returned as errors without affecting the
+// other queued lines.
+func (w *Writer) WritePoint(point Point) error {
+	line, err := point.Encode()
+
+	if err != nil {
+		return err
+	}
+
+	return w.Write(line)
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.options.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]string, 0, w.options.BatchSize)
+
+	for {
+		select {
+		case line := <-w.lines:
+			batch = append(batch, line)
+
+			if len(batch) >= w.options.BatchSize {
+				w.flush(batch, true)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 || w.buffered {
+				w.flush(batch, true)
+				batch = batch[:0]
+			}
+		case <-w.stop:
+			batch = w.drain(batch)
+
+			if len(batch) > 0 || w.buffered {
+				w.flush(batch, false)
+			}
+
+			return
+		}
+	}
+}
+
+// drain appends the lines queued before Stop to the batch.
+func (w *Writer) drain(batch []string) []string {
+	for {
+		select {
+		case line := <-w.lines:
+			batch = append(batch, line)
+		default:
+			return batch
+		}
+	}
+}
+
+// flush writes the buffered lines followed by the batch, BatchSize lines at a time, streaming the buffer rather than
+// reading it into memory.  Whatever can't be written stays in, or is appended to, the buffer.
+func (w *Writer) flush(batch []string, retry bool) {
+	var buffer *os.File
+	var reader *bufio.Reader
+
+	if w.buffered {
+		file, err := os.Open(w.options.BufferPath)
+
+		if err != nil {
+			fmt.Printf("Unable to read InfluxDB buffer %s: %v\n", w.options.BufferPath, err)
+			w.appendToBuffer(batch)
+			return
+		}
+
+		defer file.Close()
+		buffer = file
+		reader = bufio.NewReader(file)
+	}
+
+	// sent is the number of bytes at the start of the buffer that have been written or dropped.
+	var sent int64
+	lines := make([]string, 0, w.options.BatchSize)
+
+	for {
+		lines = lines[:0]
+		var size int64
+
+		for reader != nil && len(lines) < w.options.BatchSize {
+			line, err := reader.ReadString('\n')
+			size = size + int64(len(line))
+
+			if line = strings.TrimSuffix(line, "\n"); line != "" {
+				lines = append(lines, line)
+			}
+
+			if errors.Is(err, io.EOF) {
+				reader = nil
+			} else if err != nil {
+				fmt.Printf("Unable to read InfluxDB buffer %s: %v\n", w.options.BufferPath, err)
+				w.keepUnsent(buffer, sent, batch)
+				return
+			}
+		}
+
+		fromBatch := min(w.options.BatchSize-len(lines), len(batch))
+		lines = append(lines, batch[:fromBatch]...)
+
+		if len(lines) == 0 {
+			break
+		}
+
+		err := w.send(lines, retry)
+
+		var permanent permanentError
+
+		if errors.As(err, &permanent) {
+			fmt.Printf("Dropping %d InfluxDB lines: %v\n", len(lines), err)
+		} else if err != nil {
+			fmt.Printf("Unable to write %d InfluxDB lines: %v\n", len(lines), err)
+			w.keepUnsent(buffer, sent, batch)
+			return
+		}
+
+		sent = sent + size
+		batch = batch[fromBatch:]
+	}
+
+	if buffer != nil {
+		w.removeBuffer()
+	}
+}
+
+func (w *Writer) send(lines []string, retry bool) error {
+	delay := w.options.RetryDelay
+	attempts := 1
+
+	if retry {
+		attempts = attempts + w.options.MaxRetries
+	}
+
+	var err error
+
+	for attempt := 1; ; attempt = attempt + 1 {
+		if w.options.URL == "" {
+			err = w.appendToFile(w.options.FilePath, lines)
+		} else {
+			err = w.post(lines)
+		}
+
+		var permanent permanentError
+
+		if err == nil || errors.As(err, &permanent) || attempt >= attempts {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-w.stop:
+			return err
+		}
+
+		delay = delay * 2
+	}
+}
+
+func (w *Writer) post(lines []string) error {
+	request, err := http.NewRequest(http.MethodPost, w.options.URL, strings.NewReader(strings.Join(lines, "\n")))
+
+	if err != nil {
+		return permanentError{err}
+	}
+
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	if w.options.Token != "" {
+		request.Header.Set("Authorization", "Token "+w.options.Token)
+	}
+
+	response, err := w.options.HttpClient.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("write failed with status %s: %s", response.Status, strings.TrimSpace(string(body)))
+
+	if response.StatusCode >= 400 && response.StatusCode < 500 &&
+		response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusRequestTimeout {
+		return permanentError{err}
+	}
+
+	return err
+}
+
+func (w *Writer) appendToFile(path string, lines []string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(strings.Join(lines, "\n") + "\n")
+
+	return errors.Join(err, file.Close())
+}
+
+// keepUnsent removes the sent bytes from the start of the buffer, if it's open, and appends the unsent lines of the
+// batch to it.  A buffer that can't be rewritten is kept as it is, so some of its lines may be written again.
+func (w *Writer) keepUnsent(buffer *os.File, sent int64, batch []string) {
+	if buffer != nil && sent > 0 {
+		err := w.truncateBuffer(buffer, sent)
+
+		if err != nil {
+			fmt.Printf("Unable to remove written lines from InfluxDB buffer %s: %v\n", w.options.BufferPath, err)
+		}
+	}
+
+	w.appendToBuffer(batch)
+}
+
+// truncateBuffer removes the first size bytes of the buffer by copying the rest of it to a new file.
+func (w *Writer) truncateBuffer(buffer *os.File, size int64) error {
+	_, err := buffer.Seek(size, io.SeekStart)
+
+	if err != nil {
+		return err
+	}
+
+	temporaryPath := w.options.BufferPath + ".tmp"
+	temporary, err := os.Create(temporaryPath)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(temporary, buffer)
+	err = errors.Join(err, temporary.Close())
+
+	if err == nil {
+		err = os.Rename(temporaryPath, w.options.BufferPath)
+	}
+
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+	}
+
+	return err
+}
+
+// appendToBuffer appends lines to the buffer, unless that would make it larger than MaxBufferSize.
+func (w *Writer) appendToBuffer(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	if w.options.BufferPath == "" {
+		fmt.Printf("No InfluxDB buffer configured, dropping %d lines\n", len(lines))
+		return
+	}
+
+	var size int64
+	info, err := os.Stat(w.options.BufferPath)
+
+	if err == nil {
+		size = info.Size()
+	}
+
+	if size+int64(len(strings.Join(lines, "\n"))+1) > w.options.MaxBufferSize {
+		fmt.Printf("InfluxDB buffer %s is full, dropping %d lines\n", w.options.BufferPath, len(lines))
+		return
+	}
+
+	err = w.appendToFile(w.options.BufferPath, lines)
+
+	if err != nil {
+		fmt.Printf("Unable to write InfluxDB buffer %s, dropping %d lines: %v\n", w.options.BufferPath, len(lines), err)
+		return
+	}
+
+	w.buffered = true
+}
+
+func (w *Writer) removeBuffer() {
+	err := os.Remove(w.options.BufferPath)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Unable to remove InfluxDB buffer %s: %v\n", w.options.BufferPath, err)
+		return
+	}
+
+	w.buffered = false
+}
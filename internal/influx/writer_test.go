@@ -0,0 +1,289 @@
+package influx
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []string
+	statuses []int
+}
+
+// startTestServer starts a server that responds with the passed statuses in order, and 204 after that.
+func startTestServer(t *testing.T, statuses ...int) *testServer {
+	server := &testServer{statuses: statuses}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+
+		if r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		server.requests = append(server.requests, string(body))
+
+		if len(server.statuses) > 0 {
+			status := server.statuses[0]
+			server.statuses = server.statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *testServer) getRequests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+func (s *testServer) waitForRequests(t *testing.T, count int) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for len(s.getRequests()) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d requests, got %q", count, s.getRequests())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func createTestWriter(url string, options Options) *Writer {
+	options.URL = url
+	options.Token = "secret"
+	options.FlushInterval = time.Hour
+	options.RetryDelay = time.Millisecond
+
+	return NewWriter(options)
+}
+
+func TestWriter_WritesBatches(t *testing.T) {
+	// Arrange
+	server := startTestServer(t)
+	writer := createTestWriter(server.URL, Options{BatchSize: 2})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=1i 1")
+	_ = writer.Write("m f=2i 2")
+	_ = writer.Write("m f=3i 3")
+	<-writer.Stop()
+
+	// Assert
+	requests := server.getRequests()
+
+	if len(requests) != 2 || requests[0] != "m f=1i 1\nm f=2i 2" || requests[1] != "m f=3i 3" {
+		t.Fatalf("unexpected requests %q", requests)
+	}
+}
+
+func TestWriter_WritePointKeepsBatchWithNonFiniteValues(t *testing.T) {
+	// Arrange
+	server := startTestServer(t)
+	writer := createTestWriter(server.URL, Options{BatchSize: 10})
+	writer.Start()
+
+	// Act
+	firstErr := writer.WritePoint(Point{Measurement: "m", Fields: map[string]any{"f": 1}, Time: time.Unix(0, 1)})
+	badErr := writer.WritePoint(Point{Measurement: "m", Fields: map[string]any{"f": math.NaN()}, Time: time.Unix(0, 2)})
+	partialErr := writer.WritePoint(Point{
+		Measurement: "m",
+		Fields:      map[string]any{"f": 3, "g": float32(math.Inf(-1))},
+		Time:        time.Unix(0, 3),
+	})
+	<-writer.Stop()
+
+	// Assert
+	if firstErr != nil || badErr == nil || partialErr != nil {
+		t.Fatalf("unexpected errors %v, %v, %v", firstErr, badErr, partialErr)
+	}
+
+	if requests := server.getRequests(); len(requests) != 1 || requests[0] != "m f=1i 1\nm f=3i 3" {
+		t.Fatalf("unexpected requests %q", requests)
+	}
+}
+
+func TestWriter_RetriesFailedWrites(t *testing.T) {
+	// Arrange
+	server := startTestServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	writer := createTestWriter(server.URL, Options{BatchSize: 1, MaxRetries: 2})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=1i 1")
+	server.waitForRequests(t, 3)
+	<-writer.Stop()
+
+	// Assert
+	requests := server.getRequests()
+
+	if len(requests) != 3 || requests[2] != "m f=1i 1" {
+		t.Fatalf("expected 3 attempts, got %q", requests)
+	}
+}
+
+func TestWriter_DropsRejectedWrites(t *testing.T) {
+	// Arrange
+	bufferPath := filepath.Join(t.TempDir(), "buffer.lp")
+	server := startTestServer(t, http.StatusBadRequest)
+	writer := createTestWriter(server.URL, Options{BatchSize: 1, MaxRetries: 2, BufferPath: bufferPath})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=1i 1")
+	<-writer.Stop()
+
+	// Assert
+	if requests := server.getRequests(); len(requests) != 1 {
+		t.Fatalf("expected a single attempt, got %q", requests)
+	}
+
+	if _, err := os.Stat(bufferPath); err == nil {
+		t.Fatalf("expected rejected lines not to be buffered")
+	}
+}
+
+func TestWriter_BuffersToDiskWhileEndpointIsDown(t *testing.T) {
+	// Arrange
+	bufferPath := filepath.Join(t.TempDir(), "buffer.lp")
+	downServer := startTestServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	writer := createTestWriter(downServer.URL, Options{BufferPath: bufferPath})
+	writer.Start()
+	_ = writer.Write("m f=1i 1")
+	_ = writer.Write("m f=2i 2")
+	<-writer.Stop()
+
+	buffered, _ := os.ReadFile(bufferPath)
+
+	if string(buffered) != "m f=1i 1\nm f=2i 2\n" {
+		t.Fatalf("unexpected buffer contents %q", buffered)
+	}
+
+	server := startTestServer(t)
+	writer = createTestWriter(server.URL, Options{BufferPath: bufferPath})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=3i 3")
+	<-writer.Stop()
+
+	// Assert
+	requests := server.getRequests()
+
+	if len(requests) != 1 || requests[0] != "m f=1i 1\nm f=2i 2\nm f=3i 3" {
+		t.Fatalf("unexpected requests %q", requests)
+	}
+
+	if _, err := os.Stat(bufferPath); err == nil {
+		t.Fatalf("expected the buffer to be removed")
+	}
+}
+
+func TestWriter_WritesToFile(t *testing.T) {
+	// Arrange
+	filePath := filepath.Join(t.TempDir(), "environment.lp")
+	writer := NewWriter(Options{FilePath: filePath})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=1i 1")
+	_ = writer.Write("m f=2i 2")
+	<-writer.Stop()
+
+	// Assert
+	contents, _ := os.ReadFile(filePath)
+
+	if strings.Count(string(contents), "\n") != 2 {
+		t.Fatalf("unexpected file contents %q", contents)
+	}
+}
+
+func TestWriter_KeepsUnwrittenPartOfBuffer(t *testing.T) {
+	// Arrange
+	bufferPath := filepath.Join(t.TempDir(), "buffer.lp")
+	_ = os.WriteFile(bufferPath, []byte("m f=1i 1\nm f=2i 2\nm f=3i 3\n"), 0644)
+	server := startTestServer(t, http.StatusNoContent, http.StatusServiceUnavailable)
+	writer := createTestWriter(server.URL, Options{BufferPath: bufferPath, BatchSize: 2})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=4i 4")
+	<-writer.Stop()
+
+	// Assert
+	requests := server.getRequests()
+
+	if len(requests) != 2 || requests[0] != "m f=1i 1\nm f=2i 2" {
+		t.Fatalf("unexpected requests %q", requests)
+	}
+
+	buffered, _ := os.ReadFile(bufferPath)
+
+	if string(buffered) != "m f=3i 3\nm f=4i 4\n" {
+		t.Fatalf("unexpected buffer contents %q", buffered)
+	}
+}
+
+func TestWriter_LeavesUnreadableBufferAlone(t *testing.T) {
+	// Arrange
+	// A directory can be opened but not read as a file.
+	bufferPath := filepath.Join(t.TempDir(), "buffer.lp")
+	_ = os.Mkdir(bufferPath, 0755)
+	_ = os.WriteFile(filepath.Join(bufferPath, "lines"), []byte("m f=1i 1\n"), 0644)
+	server := startTestServer(t)
+	writer := createTestWriter(server.URL, Options{BufferPath: bufferPath})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=2i 2")
+	<-writer.Stop()
+
+	// Assert
+	if requests := server.getRequests(); len(requests) != 0 {
+		t.Fatalf("expected nothing to be written ahead of the buffer, got %q", requests)
+	}
+
+	if _, err := os.Stat(filepath.Join(bufferPath, "lines")); err != nil {
+		t.Fatalf("expected the buffer to be left alone: %v", err)
+	}
+}
+
+func TestWriter_LimitsBufferSize(t *testing.T) {
+	// Arrange
+	bufferPath := filepath.Join(t.TempDir(), "buffer.lp")
+	_ = os.WriteFile(bufferPath, []byte("m f=1i 1\n"), 0644)
+	downServer := startTestServer(t, http.StatusServiceUnavailable)
+	writer := createTestWriter(downServer.URL, Options{BufferPath: bufferPath, MaxBufferSize: 12})
+	writer.Start()
+
+	// Act
+	_ = writer.Write("m f=2i 2")
+	<-writer.Stop()
+
+	// Assert
+	buffered, _ := os.ReadFile(bufferPath)
+
+	if string(buffered) != "m f=1i 1\n" {
+		t.Fatalf("unexpected buffer contents %q", buffered)
+	}
+}
@@ -0,0 +1,431 @@
+package mqtt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxQoS is the highest QoS the client supports.  Higher QoS values are lowered to it, for published messages as well
+// as the last will and subscriptions, since the client can't complete the QoS 2 handshake in either direction.
+const MaxQoS byte = 1
+
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	Retain  bool
+}
+
+type Options struct {
+	// Broker is the TCP address of the broker, as host:port.
+	Broker   string
+	ClientId string
+	Username string
+	Password string
+
+	KeepAlive      time.Duration
+	ConnectTimeout time.Duration
+
+	// WriteTimeout limits each write to the broker, so a broker that stops reading ends the connection instead of
+	// blocking the client.
+	WriteTimeout time.Duration
+
+	// MinReconnectDelay is doubled after each failed connection attempt, up to MaxReconnectDelay.
+	MinReconnectDelay time.Duration
+	MaxReconnectDelay time.Duration
+
+	// The last will is published by the broker if the connection drops without a DISCONNECT.
+	WillTopic   string
+	WillPayload []byte
+	WillQoS     byte
+	WillRetain  bool
+
+	// Subscriptions are (re)established after every connect, with SubscriptionQoS.
+	Subscriptions   []string
+	SubscriptionQoS byte
+
+	// QueueSize is the number of messages buffered while the client is disconnected.
+	QueueSize int
+
+	// MaxInflight is the number of QoS 1 messages that may await acknowledgement.  Once reached, queued messages are
+	// held back until the broker acknowledges some.
+	MaxInflight int
+
+	// OnConnect is called on the client goroutine after every successful connect.  It must not block.
+	OnConnect func(client *Client)
+
+	// OnMessage is called on the client goroutine for messages received on Subscriptions.  It must not block.
+	OnMessage func(message Message)
+}
+
+// Client is a minimal MQTT 3.1.1 client that supports publishing with QoS 0 and 1, subscriptions, a last will, and
+// reconnecting with exponential backoff.  QoS 1 messages that weren't acknowledged are resent after reconnecting.
+type Client struct {
+	options      Options
+	outgoing     chan Message
+	stop         chan struct{}
+	done         chan struct{}
+	stopOnce     sync.Once
+	connected    atomic.Bool
+	nextPacketId uint16
+	inflight     map[uint16]Message
+	inflightIds  []uint16
+
+	// subscribeId is the packet identifier of the SUBSCRIBE awaiting its SUBACK, or 0.
+	subscribeId uint16
+}
+
+func NewClient(options Options) *Client {
+	if options.KeepAlive <= 0 {
+		options.KeepAlive = 60 * time.Second
+	}
+
+	if options.ConnectTimeout <= 0 {
+		options.ConnectTimeout = 10 * time.Second
+	}
+
+	if options.WriteTimeout <= 0 {
+		options.WriteTimeout = 10 * time.Second
+	}
+
+	if options.MinReconnectDelay <= 0 {
+		options.MinReconnectDelay = time.Second
+	}
+
+	if options.MaxReconnectDelay < options.MinReconnectDelay {
+		options.MaxReconnectDelay = options.MinReconnectDelay
+	}
+
+	if options.QueueSize <= 0 {
+		options.QueueSize = 1000
+	}
+
+	if options.MaxInflight <= 0 {
+		options.MaxInflight = 100
+	}
+
+	// Leave packet identifiers for the SUBSCRIBE and the next message
+	options.MaxInflight = min(options.MaxInflight, math.MaxUint16-1)
+
+	options.WillQoS = min(options.WillQoS, MaxQoS)
+	options.SubscriptionQoS = min(options.SubscriptionQoS, MaxQoS)
+
+	return &Client{
+		options:  options,
+		outgoing: make(chan Message, options.QueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		inflight: make(map[uint16]Message),
+	}
+}
+
+// Start connects to the broker on a new goroutine, and keeps reconnecting until Stop is called.
+func (c *Client) Start() {
+	go c.run()
+}
+
+// Stop disconnects from the broker.  The returned channel is closed once the client goroutine exits.
+func (c *Client) Stop() <-chan struct{} {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	return c.done
+}
+
+func (c *Client) IsConnected() bool {
+	return c.connected.Load()
+}
+
+// Publish queues a message for delivery.  It doesn't block; an error is returned if the queue is full.
+func (c *Client) Publish(message Message) error {
+	select {
+	case <-c.stop:
+		return errors.New("client is stopped")
+	default:
+	}
+
+	message.QoS = min(message.QoS, MaxQoS)
+
+	select {
+	case c.outgoing <- message:
+		return nil
+	default:
+		return fmt.Errorf("queue full, dropping message for %s", message.Topic)
+	}
+}
+
+func (c *Client) run() {
+	defer close(c.done)
+	delay := c.options.MinReconnectDelay
+
+	for {
+		connected, err := c.session()
+
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+
+		if connected {
+			delay = c.options.MinReconnectDelay
+		}
+
+		fmt.Printf("MQTT connection to %s lost, reconnecting in %v: %v\n", c.options.Broker, delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-c.stop:
+			return
+		}
+
+		delay = min(delay*2, c.options.MaxReconnectDelay)
+	}
+}
+
+// session runs a single connection to the broker.  Returns whether the connection was established, and the reason it
+// ended.
+func (c *Client) session() (bool, error) {
+	conn, err := net.DialTimeout("tcp", c.options.Broker, c.options.ConnectTimeout)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	err = c.connect(conn, reader)
+
+	if err != nil {
+		return false, err
+	}
+
+	c.connected.Store(true)
+	defer c.connected.Store(false)
+
+	incoming := make(chan packet, 16)
+	readErr := make(chan error, 1)
+	sessionDone := make(chan struct{})
+	defer close(sessionDone)
+
+	go func() {
+		for {
+			p, err := readPacket(reader)
+
+			if err != nil {
+				readErr <- err
+				return
+			}
+
+			select {
+			case incoming <- p:
+			case <-sessionDone:
+				return
+			}
+		}
+	}()
+
+	if len(c.options.Subscriptions) > 0 {
+		c.subscribeId = 0
+		c.subscribeId = c.packetId()
+		err = c.send(conn, encodeSubscribe(c.subscribeId, c.options.Subscriptions, c.options.SubscriptionQoS))
+
+		if err != nil {
+			return true, err
+		}
+	}
+
+	// Resend unacknowledged messages from the previous connection
+	for _, packetId := range c.inflightIds {
+		err = c.send(conn, encodePublish(c.inflight[packetId], packetId, true))
+
+		if err != nil {
+			return true, err
+		}
+	}
+
+	if c.options.OnConnect != nil {
+		c.options.OnConnect(c)
+	}
+
+	pingTicker := time.NewTicker(c.options.KeepAlive / 2)
+	defer pingTicker.Stop()
+	lastReceived := time.Now()
+
+	for {
+		// Hold back queued messages while too many are awaiting acknowledgement
+		outgoing := c.outgoing
+
+		if len(c.inflight) >= c.options.MaxInflight {
+			outgoing = nil
+		}
+
+		select {
+		case message := <-outgoing:
+			err = c.write(conn, message)
+		case p := <-incoming:
+			lastReceived = time.Now()
+			err = c.handle(conn, p)
+		case <-pingTicker.C:
+			if time.Since(lastReceived) > c.options.KeepAlive*3/2 {
+				return true, errors.New("keep alive timeout")
+			}
+
+			err = c.send(conn, encodePacket(packetPingReq, 0, nil))
+		case err = <-readErr:
+			return true, err
+		case <-c.stop:
+			c.drain(conn)
+			_ = c.send(conn, encodePacket(packetDisconnect, 0, nil))
+			return true, nil
+		}
+
+		if err != nil {
+			return true, err
+		}
+	}
+}
+
+// drain writes the messages queued before Stop was called, until MaxInflight messages await acknowledgement.
+func (c *Client) drain(conn net.Conn) {
+	for len(c.inflight) < c.options.MaxInflight {
+		select {
+		case message := <-c.outgoing:
+			if c.write(conn, message) != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (c *Client) connect(conn net.Conn, reader *bufio.Reader) error {
+	err := conn.SetDeadline(time.Now().Add(c.options.ConnectTimeout))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(encodeConnect(&c.options))
+
+	if err != nil {
+		return err
+	}
+
+	p, err := readPacket(reader)
+
+	if err != nil {
+		return err
+	}
+
+	if p.packetType != packetConnAck || len(p.body) < 2 {
+		return fmt.Errorf("expected CONNACK, received packet type %d", p.packetType)
+	}
+
+	if p.body[1] != 0 {
+		return fmt.Errorf("connection refused, return code %d", p.body[1])
+	}
+
+	return conn.SetDeadline(time.Time{})
+}
+
+func (c *Client) write(conn net.Conn, message Message) error {
+	var packetId uint16 = 0
+
+	if message.QoS > 0 {
+		packetId = c.packetId()
+		c.inflight[packetId] = message
+		c.inflightIds = append(c.inflightIds, packetId)
+	}
+
+	return c.send(conn, encodePublish(message, packetId, false))
+}
+
+// send writes a packet, failing if the broker doesn't accept it within WriteTimeout.
+func (c *Client) send(conn net.Conn, data []byte) error {
+	err := conn.SetWriteDeadline(time.Now().Add(c.options.WriteTimeout))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(data)
+
+	return err
+}
+
+func (c *Client) handle(conn net.Conn, p packet) error {
+	switch p.packetType {
+	case packetPubAck:
+		packetId, err := decodePacketId(p)
+
+		if err != nil {
+			return err
+		}
+
+		c.acknowledge(packetId)
+	case packetPublish:
+		message, packetId, err := decodePublish(p)
+
+		if err != nil {
+			return err
+		}
+
+		if message.QoS > 0 {
+			err = c.send(conn, encodePacketId(packetPubAck, 0, packetId))
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if c.options.OnMessage != nil {
+			c.options.OnMessage(message)
+		}
+	case packetSubAck:
+		c.subscribeId = 0
+	case packetPingResp:
+		// Nothing to do
+	default:
+		return fmt.Errorf("unexpected packet type %d", p.packetType)
+	}
+
+	return nil
+}
+
+func (c *Client) acknowledge(packetId uint16) {
+	if _, ok := c.inflight[packetId]; !ok {
+		return
+	}
+
+	delete(c.inflight, packetId)
+
+	for i, inflightId := range c.inflightIds {
+		if inflightId == packetId {
+			c.inflightIds = append(c.inflightIds[:i], c.inflightIds[i+1:]...)
+			break
+		}
+	}
+}
+
+// packetId returns the next non-zero packet identifier that isn't in use by an unacknowledged message or SUBSCRIBE.
+// MaxInflight leaves at least one identifier free.
+func (c *Client) packetId() uint16 {
+	for {
+		c.nextPacketId = c.nextPacketId + 1
+
+		if c.nextPacketId == 0 || c.nextPacketId == c.subscribeId {
+			continue
+		}
+
+		if _, ok := c.inflight[c.nextPacketId]; !ok {
+			return c.nextPacketId
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package mqtt
+
+import (
+	"bufio"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+func createTestClient(broker *testBroker, options Options) *Client {
+	options.Broker = broker.address()
+	options.ClientId = "test"
+	options.MinReconnectDelay = 10 * time.Millisecond
+	options.MaxReconnectDelay = 50 * time.Millisecond
+	options.WillTopic = "test/status"
+	options.WillPayload = []byte("offline")
+	options.WillRetain = true
+
+	return NewClient(options)
+}
+
+func TestClient_Publish(t *testing.T) {
+	// Arrange
+	broker := startTestBroker(t)
+	client := createTestClient(broker, Options{})
+	client.Start()
+	defer client.Stop()
+
+	// Act
+	err := client.Publish(Message{Topic: "test/state", Payload: []byte("{}"), QoS: 1, Retain: true})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	message := broker.expectMessage(t)
+
+	if message.Topic != "test/state" || string(message.Payload) != "{}" || message.QoS != 1 || !message.Retain {
+		t.Fatalf("unexpected message %+v", message)
+	}
+}
+
+func TestClient_LowersUnsupportedQoS(t *testing.T) {
+	// Arrange
+	broker := startTestBroker(t)
+	client := createTestClient(broker, Options{WillQoS: 2, SubscriptionQoS: 2})
+	client.Start()
+	defer client.Stop()
+
+	// Act
+	_ = client.Publish(Message{Topic: "test/state", Payload: []byte("{}"), QoS: 2})
+
+	// Assert
+	if client.options.WillQoS != 1 || client.options.SubscriptionQoS != 1 {
+		t.Fatalf("expected the will and subscription QoS to be lowered, got %+v", client.options)
+	}
+
+	if message := broker.expectMessage(t); message.QoS != 1 {
+		t.Fatalf("expected QoS 1, got %+v", message)
+	}
+
+	broker.dropConnections()
+
+	if message := broker.expectMessage(t); message.Topic != "test/status" || message.QoS != 1 {
+		t.Fatalf("expected the last will with QoS 1, got %+v", message)
+	}
+}
+
+func TestClient_ReconnectsAndPublishesLastWill(t *testing.T) {
+	// Arrange
+	broker := startTestBroker(t)
+	connected := make(chan struct{}, 10)
+	client := createTestClient(broker, Options{
+		OnConnect: func(client *Client) {
+			_ = client.Publish(Message{Topic: "test/status", Payload: []byte("online"), Retain: true})
+			connected <- struct{}{}
+		},
+	})
+	client.Start()
+	defer client.Stop()
+	<-connected
+
+	if message := broker.expectMessage(t); string(message.Payload) != "online" {
+		t.Fatalf("expected online status, got %+v", message)
+	}
+
+	// Act
+	broker.dropConnections()
+
+	// Assert
+	if message := broker.expectMessage(t); message.Topic != "test/status" || string(message.Payload) != "offline" {
+		t.Fatalf("expected the last will, got %+v", message)
+	}
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("client did not reconnect")
+	}
+
+	if message := broker.expectMessage(t); string(message.Payload) != "online" {
+		t.Fatalf("expected online status after reconnecting, got %+v", message)
+	}
+
+	if broker.connectCount() != 2 {
+		t.Fatalf("expected 2 connections, got %d", broker.connectCount())
+	}
+}
+
+func TestClient_StopSendsDisconnect(t *testing.T) {
+	// Arrange
+	broker := startTestBroker(t)
+	connected := make(chan struct{}, 1)
+	client := createTestClient(broker, Options{OnConnect: func(*Client) { connected <- struct{}{} }})
+	client.Start()
+	<-connected
+
+	// Act
+	_ = client.Publish(Message{Topic: "test/status", Payload: []byte("offline"), Retain: true})
+	done := client.Stop()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("client did not stop")
+	}
+
+	if message := broker.expectMessage(t); string(message.Payload) != "offline" {
+		t.Fatalf("expected the queued message to be sent before disconnecting, got %+v", message)
+	}
+
+	select {
+	case message := <-broker.messages:
+		t.Fatalf("expected no last will after a clean disconnect, got %+v", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := client.Publish(Message{Topic: "test/state"}); err == nil {
+		t.Fatalf("expected an error publishing after stop")
+	}
+}
+
+func TestClient_ReceivesSubscribedMessages(t *testing.T) {
+	// Arrange
+	broker := startTestBroker(t)
+	received := make(chan Message, 1)
+	connected := make(chan struct{}, 1)
+	client := createTestClient(broker, Options{
+		Subscriptions: []string{"homeassistant/status"},
+		OnConnect:     func(*Client) { connected <- struct{}{} },
+		OnMessage:     func(message Message) { received <- message },
+	})
+	client.Start()
+	defer client.Stop()
+	<-connected
+	broker.waitForSubscriber(t)
+
+	// Act
+	publisher := NewClient(Options{Broker: broker.address(), ClientId: "publisher"})
+	publisher.Start()
+	defer publisher.Stop()
+	_ = publisher.Publish(Message{Topic: "homeassistant/status", Payload: []byte("online")})
+
+	// Assert
+	select {
+	case message := <-received:
+		if string(message.Payload) != "online" {
+			t.Fatalf("unexpected message %+v", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the subscribed message")
+	}
+}
+
+func TestClient_LimitsInflightMessages(t *testing.T) {
+	// Arrange
+	broker := startTestBroker(t)
+	broker.withholdAcks.Store(true)
+	client := createTestClient(broker, Options{MaxInflight: 2})
+	client.Start()
+	defer client.Stop()
+
+	// Act
+	for i := 0; i < 5; i = i + 1 {
+		_ = client.Publish(Message{Topic: "test/state", Payload: []byte{byte('0' + i)}, QoS: 1})
+	}
+
+	// Assert
+	broker.expectMessage(t)
+	broker.expectMessage(t)
+	broker.expectNoMessage(t, 100*time.Millisecond)
+}
+
+func TestClient_PacketIdSkipsIdsInUse(t *testing.T) {
+	// Arrange
+	client := NewClient(Options{})
+	client.nextPacketId = math.MaxUint16 - 1
+	client.inflight[math.MaxUint16] = Message{}
+	client.inflight[1] = Message{}
+	client.subscribeId = 2
+
+	// Act
+	packetId := client.packetId()
+
+	// Assert
+	if packetId != 3 {
+		t.Fatalf("expected packet ID 3, got %d", packetId)
+	}
+}
+
+func TestClient_ReconnectsWhenBrokerStopsReading(t *testing.T) {
+	// Arrange
+	// The broker accepts the connection, and then never reads from it.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	defer listener.Close()
+	accepted := make(chan net.Conn, 2)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			_, _ = readPacket(bufio.NewReader(conn))
+			_, _ = conn.Write(encodePacket(packetConnAck, 0, []byte{0, 0}))
+			accepted <- conn
+		}
+	}()
+
+	client := NewClient(Options{
+		Broker:            listener.Addr().String(),
+		ClientId:          "test",
+		WriteTimeout:      50 * time.Millisecond,
+		MinReconnectDelay: 10 * time.Millisecond,
+	})
+	client.Start()
+	defer client.Stop()
+	conn := <-accepted
+	defer conn.Close()
+
+	// Act
+	_ = client.Publish(Message{Topic: "test/state", Payload: make([]byte, 64*1024*1024)})
+
+	// Assert
+	select {
+	case conn := <-accepted:
+		conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the client to reconnect after the write timed out")
+	}
+}
@@ -0,0 +1,41 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// EventPayload marshals an event as JSON.  Returns the name of the event type, for use in the topic.  The entity
+// type, which doesn't serialize meaningfully, is omitted.
+func EventPayload(event any) (string, []byte, error) {
+	eventType := reflect.TypeOf(event)
+
+	if eventType.Kind() == reflect.Ptr {
+		eventType = eventType.Elem()
+	}
+
+	encoded, err := json.Marshal(event)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	fields := make(map[string]any)
+	err = json.Unmarshal(encoded, &fields)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	delete(fields, "EntityType")
+	fields["Type"] = eventType.Name()
+	payload, err := json.Marshal(fields)
+
+	return eventType.Name(), payload, err
+}
+
+// TopicLevel converts a name into a single topic level, replacing characters that have a special meaning in topics.
+func TopicLevel(name string) string {
+	return strings.NewReplacer(" ", "_", "/", "_", "+", "_", "#", "_", "'", "").Replace(name)
+}
@@ -0,0 +1,46 @@
+package mqtt
+
+import (
+	"reflect"
+	"testing"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/events"
+)
+
+func TestEventPayload(t *testing.T) {
+	// Arrange
+	event := spienvironment.TemperatureChangeEvent{
+		EntityEvent: events.EntityEvent{
+			Id:         "entity_1",
+			EntityType: reflect.TypeOf(t),
+			Name:       "Garage",
+		},
+		NewValue: 20.5,
+		OldValue: 20,
+	}
+
+	// Act
+	name, payload, err := EventPayload(event)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"Id":"entity_1","Name":"Garage","NewValue":20.5,"OldValue":20,"Type":"TemperatureChangeEvent"}`
+
+	if name != "TemperatureChangeEvent" || string(payload) != expected {
+		t.Fatalf("expected %s %s, got %s %s", "TemperatureChangeEvent", expected, name, payload)
+	}
+}
+
+func TestTopicLevel(t *testing.T) {
+	// Act
+	result := TopicLevel("Kid's Room/Upstairs #2")
+
+	// Assert
+	if result != "Kids_Room_Upstairs__2" {
+		t.Fatalf("unexpected topic level %s", result)
+	}
+}
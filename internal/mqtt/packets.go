@@ -0,0 +1,233 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	packetConnect    byte = 1
+	packetConnAck    byte = 2
+	packetPublish    byte = 3
+	packetPubAck     byte = 4
+	packetSubscribe  byte = 8
+	packetSubAck     byte = 9
+	packetPingReq    byte = 12
+	packetPingResp   byte = 13
+	packetDisconnect byte = 14
+)
+
+const maxRemainingLength = 268435455
+
+type packet struct {
+	packetType byte
+	flags      byte
+	body       []byte
+}
+
+func encodePacket(packetType byte, flags byte, body []byte) []byte {
+	result := make([]byte, 0, len(body)+5)
+	result = append(result, packetType<<4|flags&0x0f)
+	length := len(body)
+
+	for {
+		encodedByte := byte(length % 128)
+		length = length / 128
+
+		if length > 0 {
+			encodedByte = encodedByte | 0x80
+		}
+
+		result = append(result, encodedByte)
+
+		if length == 0 {
+			break
+		}
+	}
+
+	return append(result, body...)
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+
+	if err != nil {
+		return packet{}, err
+	}
+
+	length := 0
+	multiplier := 1
+
+	for {
+		encodedByte, err := r.ReadByte()
+
+		if err != nil {
+			return packet{}, err
+		}
+
+		length = length + int(encodedByte&0x7f)*multiplier
+
+		if length > maxRemainingLength {
+			return packet{}, errors.New("malformed remaining length")
+		}
+
+		if encodedByte&0x80 == 0 {
+			break
+		}
+
+		multiplier = multiplier * 128
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+
+	if err != nil {
+		return packet{}, err
+	}
+
+	return packet{packetType: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+func appendString(buffer []byte, value string) []byte {
+	return appendBytes(buffer, []byte(value))
+}
+
+func appendBytes(buffer []byte, value []byte) []byte {
+	buffer = binary.BigEndian.AppendUint16(buffer, uint16(len(value)))
+	return append(buffer, value...)
+}
+
+func readString(body []byte) (string, []byte, error) {
+	if len(body) < 2 {
+		return "", nil, errors.New("truncated string")
+	}
+
+	length := int(binary.BigEndian.Uint16(body))
+
+	if len(body) < 2+length {
+		return "", nil, errors.New("truncated string")
+	}
+
+	return string(body[2 : 2+length]), body[2+length:], nil
+}
+
+func encodeConnect(options *Options) []byte {
+	body := appendString(nil, "MQTT")
+	// Protocol level 4 is MQTT 3.1.1
+	body = append(body, 4)
+	// Always start a clean session; the client republishes retained state after connecting.
+	var flags byte = 0x02
+
+	if options.WillTopic != "" {
+		flags = flags | 0x04 | (options.WillQoS&0x03)<<3
+
+		if options.WillRetain {
+			flags = flags | 0x20
+		}
+	}
+
+	if options.Username != "" {
+		flags = flags | 0x80
+
+		if options.Password != "" {
+			flags = flags | 0x40
+		}
+	}
+
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(options.KeepAlive.Seconds()))
+	body = appendString(body, options.ClientId)
+
+	if options.WillTopic != "" {
+		body = appendString(body, options.WillTopic)
+		body = appendBytes(body, options.WillPayload)
+	}
+
+	if options.Username != "" {
+		body = appendString(body, options.Username)
+
+		if options.Password != "" {
+			body = appendString(body, options.Password)
+		}
+	}
+
+	return encodePacket(packetConnect, 0, body)
+}
+
+func encodePublish(message Message, packetId uint16, dup bool) []byte {
+	var flags byte = (message.QoS & 0x03) << 1
+
+	if message.Retain {
+		flags = flags | 0x01
+	}
+
+	if dup {
+		flags = flags | 0x08
+	}
+
+	body := appendString(nil, message.Topic)
+
+	if message.QoS > 0 {
+		body = binary.BigEndian.AppendUint16(body, packetId)
+	}
+
+	body = append(body, message.Payload...)
+
+	return encodePacket(packetPublish, flags, body)
+}
+
+func decodePublish(p packet) (Message, uint16, error) {
+	topic, rest, err := readString(p.body)
+
+	if err != nil {
+		return Message{}, 0, err
+	}
+
+	message := Message{
+		Topic:  topic,
+		QoS:    (p.flags >> 1) & 0x03,
+		Retain: p.flags&0x01 != 0,
+	}
+	var packetId uint16 = 0
+
+	if message.QoS > 0 {
+		if len(rest) < 2 {
+			return Message{}, 0, errors.New("truncated publish packet")
+		}
+
+		packetId = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+
+	message.Payload = rest
+
+	return message, packetId, nil
+}
+
+func encodePacketId(packetType byte, flags byte, packetId uint16) []byte {
+	return encodePacket(packetType, flags, binary.BigEndian.AppendUint16(nil, packetId))
+}
+
+func decodePacketId(p packet) (uint16, error) {
+	if len(p.body) < 2 {
+		return 0, fmt.Errorf("truncated packet of type %d", p.packetType)
+	}
+
+	return binary.BigEndian.Uint16(p.body), nil
+}
+
+func encodeSubscribe(packetId uint16, topics []string, qos byte) []byte {
+	body := binary.BigEndian.AppendUint16(nil, packetId)
+
+	for _, topic := range topics {
+		body = appendString(body, topic)
+		body = append(body, qos&0x03)
+	}
+
+	// The fixed header flags of SUBSCRIBE are reserved and must be 0b0010
+	return encodePacket(packetSubscribe, 0x02, body)
+}
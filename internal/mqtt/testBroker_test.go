@@ -0,0 +1,222 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testBroker is a minimal in-process MQTT broker.  It records published messages, delivers the last will of
+// connections that drop without a DISCONNECT, and forwards messages to subscribed clients.
+type testBroker struct {
+	listener    net.Listener
+	messages    chan Message
+	mutex       sync.Mutex
+	connections []net.Conn
+	subscribers map[net.Conn][]string
+	connects    int
+
+	// withholdAcks stops the broker from acknowledging QoS 1 messages.
+	withholdAcks atomic.Bool
+}
+
+func startTestBroker(t *testing.T) *testBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	broker := &testBroker{
+		listener:    listener,
+		messages:    make(chan Message, 100),
+		subscribers: make(map[net.Conn][]string),
+	}
+
+	go broker.accept()
+	t.Cleanup(func() { broker.close() })
+
+	return broker
+}
+
+func (b *testBroker) address() string {
+	return b.listener.Addr().String()
+}
+
+func (b *testBroker) accept() {
+	for {
+		conn, err := b.listener.Accept()
+
+		if err != nil {
+			return
+		}
+
+		b.mutex.Lock()
+		b.connections = append(b.connections, conn)
+		b.connects = b.connects + 1
+		b.mutex.Unlock()
+
+		go b.serve(conn)
+	}
+}
+
+func (b *testBroker) connectCount() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.connects
+}
+
+// dropConnections closes all client connections without a DISCONNECT, as if the network failed.
+func (b *testBroker) dropConnections() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, conn := range b.connections {
+		_ = conn.Close()
+	}
+
+	b.connections = nil
+}
+
+func (b *testBroker) close() {
+	_ = b.listener.Close()
+	b.dropConnections()
+}
+
+func (b *testBroker) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	var will *Message = nil
+	disconnected := false
+
+	defer func() {
+		b.mutex.Lock()
+		delete(b.subscribers, conn)
+		b.mutex.Unlock()
+
+		if will != nil && !disconnected {
+			b.messages <- *will
+		}
+	}()
+
+	for {
+		p, err := readPacket(reader)
+
+		if err != nil {
+			return
+		}
+
+		switch p.packetType {
+		case packetConnect:
+			will = decodeWill(p)
+			_, _ = conn.Write(encodePacket(packetConnAck, 0, []byte{0, 0}))
+		case packetPublish:
+			message, packetId, _ := decodePublish(p)
+			b.messages <- message
+
+			if message.QoS > 0 && !b.withholdAcks.Load() {
+				_, _ = conn.Write(encodePacketId(packetPubAck, 0, packetId))
+			}
+
+			b.forward(message)
+		case packetSubscribe:
+			packetId := binary.BigEndian.Uint16(p.body)
+			rest := p.body[2:]
+			topics := make([]string, 0)
+
+			for len(rest) > 0 {
+				var topic string
+				topic, rest, _ = readString(rest)
+				topics = append(topics, topic)
+				rest = rest[1:]
+			}
+
+			b.mutex.Lock()
+			b.subscribers[conn] = topics
+			b.mutex.Unlock()
+			_, _ = conn.Write(encodePacket(packetSubAck, 0, binary.BigEndian.AppendUint16(nil, packetId)))
+		case packetPingReq:
+			_, _ = conn.Write(encodePacket(packetPingResp, 0, nil))
+		case packetDisconnect:
+			disconnected = true
+			return
+		}
+	}
+}
+
+func (b *testBroker) waitForSubscriber(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		b.mutex.Lock()
+		count := len(b.subscribers)
+		b.mutex.Unlock()
+
+		if count > 0 {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for a subscriber")
+}
+
+func (b *testBroker) forward(message Message) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for conn, topics := range b.subscribers {
+		for _, topic := range topics {
+			if topic == message.Topic {
+				message.QoS = 0
+				_, _ = conn.Write(encodePublish(message, 0, false))
+			}
+		}
+	}
+}
+
+func decodeWill(p packet) *Message {
+	// Skip the protocol name, level, flags and keep alive
+	_, rest, _ := readString(p.body)
+	flags := rest[1]
+	rest = rest[4:]
+	_, rest, _ = readString(rest)
+
+	if flags&0x04 == 0 {
+		return nil
+	}
+
+	topic, rest, _ := readString(rest)
+	payload, _, _ := readString(rest)
+
+	return &Message{Topic: topic, Payload: []byte(payload), QoS: (flags >> 3) & 0x03, Retain: flags&0x20 != 0}
+}
+
+func (b *testBroker) expectMessage(t *testing.T) Message {
+	t.Helper()
+
+	select {
+	case message := <-b.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for a message")
+	}
+
+	return Message{}
+}
+
+func (b *testBroker) expectNoMessage(t *testing.T, wait time.Duration) {
+	t.Helper()
+
+	select {
+	case message := <-b.messages:
+		t.Fatalf("unexpected message %+v", message)
+	case <-time.After(wait):
+	}
+}
@@ -0,0 +1,37 @@
+package psychrometrics
+
+import (
+	"math"
+)
+
+// Magnus formula coefficients (Alduchov and Eskridge, 1996), valid from -40 C to 50 C.
+const (
+	magnusA = 17.625
+	magnusB = 243.04
+)
+
+// DewPoint returns the dew point, in Celsius, of air at the passed temperature and relative humidity.  The dew point
+// of perfectly dry air is undefined, so DewPoint reports false for a relative humidity of 0 or less.
+func DewPoint(temperature float32, relativeHumidity float32) (float32, bool) {
+	if relativeHumidity <= 0 {
+		return 0, false
+	}
+
+	gamma := math.Log(float64(relativeHumidity)/100) +
+		magnusA*float64(temperature)/(magnusB+float64(temperature))
+
+	return float32(magnusB * gamma / (magnusA - gamma)), true
+}
+
+// SaturationVaporPressure returns the saturation vapor pressure of water, in hPa, at the passed temperature.
+func SaturationVaporPressure(temperature float32) float32 {
+	return float32(6.1094 * math.Exp(magnusA*float64(temperature)/(magnusB+float64(temperature))))
+}
+
+// AbsoluteHumidity returns the mass of water vapor, in g/m3, in air at the passed temperature and relative humidity.
+func AbsoluteHumidity(temperature float32, relativeHumidity float32) float32 {
+	vaporPressure := SaturationVaporPressure(temperature) * relativeHumidity / 100
+
+	// Ideal gas law, with the specific gas constant of water vapor, 461.5 J/(kg K)
+	return vaporPressure * 100 / (461.5 * (temperature + 273.15)) * 1000
+}
@@ -0,0 +1,50 @@
+package psychrometrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDewPoint(t *testing.T) {
+	cases := []struct {
+		temperature      float32
+		relativeHumidity float32
+		expected         float32
+	}{
+		{20, 50, 9.26},
+		{25, 80, 21.31},
+		{0, 100, 0},
+	}
+
+	for _, c := range cases {
+		// Act
+		result, ok := DewPoint(c.temperature, c.relativeHumidity)
+
+		// Assert
+		if !ok || math.Abs(float64(result-c.expected)) > 0.05 {
+			t.Fatalf("DewPoint(%v, %v): expected %v, got %v", c.temperature, c.relativeHumidity, c.expected, result)
+		}
+	}
+}
+
+func TestDewPoint_UndefinedForDryAir(t *testing.T) {
+	for _, relativeHumidity := range []float32{0, -1} {
+		// Act
+		_, ok := DewPoint(20, relativeHumidity)
+
+		// Assert
+		if ok {
+			t.Fatalf("expected no dew point at %v%%", relativeHumidity)
+		}
+	}
+}
+
+func TestAbsoluteHumidity(t *testing.T) {
+	// Act
+	result := AbsoluteHumidity(20, 50)
+
+	// Assert
+	if math.Abs(float64(result-8.64)) > 0.05 {
+		t.Fatalf("expected 8.64 g/m3, got %v", result)
+	}
+}
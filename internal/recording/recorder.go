@@ -0,0 +1,89 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// Entry is a recorded state change of a wireless thermometer.
+type Entry struct {
+	Time     time.Time
+	EntityId string
+	Name     string
+	State    spienvironment.WirelessThermometer
+}
+
+// Recorder appends entries to a JSON-lines file.  It isn't safe for concurrent use.
+type Recorder struct {
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewRecorder opens the file for appending, creating it if needed.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(file)
+
+	return &Recorder{file: file, writer: writer, encoder: json.NewEncoder(writer)}, nil
+}
+
+// Record appends an entry.  Entries are flushed right away, so a crash loses at most the entry being written.
+func (r *Recorder) Record(entry Entry) error {
+	err := r.encoder.Encode(entry)
+
+	if err != nil {
+		return err
+	}
+
+	return r.writer.Flush()
+}
+
+func (r *Recorder) Close() error {
+	return errors.Join(r.writer.Flush(), r.file.Close())
+}
+
+// Read reads the entries of a recording.
+func Read(reader io.Reader) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	decoder := json.NewDecoder(reader)
+
+	for {
+		var entry Entry
+		err := decoder.Decode(&entry)
+
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+// ReadFile reads the entries of a recording file.
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return Read(file)
+}
@@ -0,0 +1,76 @@
+package recording
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+func newEntry(entryTime time.Time, temperature float32) Entry {
+	entry := Entry{
+		Time:     entryTime,
+		EntityId: "source_1",
+		Name:     "Kitchen",
+		State:    spienvironment.WirelessThermometer{Name: "Kitchen"},
+	}
+	entry.State.SensorData.Temperature = temperature
+	entry.State.SensorData.LastUpdateTime = entryTime
+
+	return entry
+}
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	recorder, err := NewRecorder(path)
+
+	if err != nil {
+		t.Fatalf("unable to create recorder: %v", err)
+	}
+
+	// Act
+	_ = recorder.Record(newEntry(start, 20))
+	_ = recorder.Record(newEntry(start.Add(time.Minute), 21))
+	_ = recorder.Close()
+	entries, err := ReadFile(path)
+
+	// Assert
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v %v", entries, err)
+	}
+
+	if !entries[1].Time.Equal(start.Add(time.Minute)) || entries[1].State.SensorData.Temperature != 21 {
+		t.Fatalf("unexpected entry %+v", entries[1])
+	}
+}
+
+func TestReplay_UsesRecordedTimes(t *testing.T) {
+	// Arrange
+	location := time.FixedZone("Test", 0)
+	entries := []Entry{
+		newEntry(time.Date(2024, 3, 1, 23, 0, 0, 0, location), 20),
+		newEntry(time.Date(2024, 3, 1, 23, 30, 0, 0, location), 15),
+		newEntry(time.Date(2024, 3, 2, 0, 30, 0, 0, location), 18),
+	}
+
+	// Act
+	result := Replay(entries, ReplayOptions{})
+
+	// Assert
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+
+	wt := result.Thermometers["source_1"]
+
+	if wt.LowTemperature != 18 || wt.HighTemperature != 18 || !wt.LowTemperatureTime.Equal(entries[2].Time) {
+		t.Fatalf("expected the extremes to reset at midnight, got %+v", wt.Thermometer)
+	}
+
+	if len(result.Events) != 3 || !result.Events[2].Time.Equal(entries[2].Time) || result.Events[2].EntityId != "source_1" {
+		t.Fatalf("unexpected events %v", result.Events)
+	}
+}
@@ -0,0 +1,82 @@
+package recording
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+// Event is an event published while replaying.
+type Event struct {
+	Time     time.Time
+	EntityId string
+	Event    any
+}
+
+type ReplayOptions struct {
+	// Configure is called for each thermometer created during the replay, to apply the same filters, smoothing and
+	// event gates as the plugin.  Optional.
+	Configure func(wt *thermometer.WirelessThermometer)
+}
+
+type ReplayResult struct {
+	// Thermometers maps the recorded entity IDs to the final state of their thermometers.
+	Thermometers map[string]thermometer.WirelessThermometer
+	Events       []Event
+
+	// Errors holds the errors returned by ProcessNewState.
+	Errors []error
+}
+
+// Replay feeds the entries into the ProcessNewState of one thermometer per recorded entity, in order, with a clock
+// that is set to the time of each entry.
+func Replay(entries []Entry, options ReplayOptions) ReplayResult {
+	result := ReplayResult{
+		Thermometers: make(map[string]thermometer.WirelessThermometer),
+		Events:       make([]Event, 0),
+		Errors:       make([]error, 0),
+	}
+
+	if len(entries) == 0 {
+		return result
+	}
+
+	virtualClock := clock.NewFake(entries[0].Time)
+	thermometers := make(map[string]*thermometer.WirelessThermometer)
+
+	for _, entry := range entries {
+		virtualClock.Set(entry.Time)
+		wt, ok := thermometers[entry.EntityId]
+
+		if !ok {
+			wt = thermometer.CreateWirelessThermometer(
+				len(thermometers)+1, entry.EntityId, entry.Name, entities.WirelessThermometerType, tracking.Config{},
+				virtualClock)
+			wt.PmaasEntityId = entry.EntityId
+
+			if options.Configure != nil {
+				options.Configure(wt)
+			}
+
+			thermometers[entry.EntityId] = wt
+		}
+
+		err := wt.ProcessNewState(entry.State, func(pmaasEntityId string, event any) {
+			result.Events = append(result.Events, Event{Time: entry.Time, EntityId: pmaasEntityId, Event: event})
+		})
+
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("entry at %v for %s: %w", entry.Time, entry.EntityId, err))
+		}
+	}
+
+	for entityId, wt := range thermometers {
+		result.Thermometers[entityId] = wt.GetState().(thermometer.WirelessThermometer)
+	}
+
+	return result
+}
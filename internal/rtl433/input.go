@@ -0,0 +1,194 @@
+package rtl433
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Input is a source of rtl_433 JSON lines:
+//   - "-" reads standard input
+//   - "tcp://host:port" reads a TCP stream
+//   - "http://..." and "https://..." read a streaming HTTP response, like the /stream or /events endpoints of
+//     rtl_433's HTTP output
+//   - anything else is the path of a file or named pipe
+//
+// Streams and named pipes are reopened when they fail or end.  Files and standard input are read once.
+type Input string
+
+func (i Input) reopens() bool {
+	source := string(i)
+
+	if i.isStream() {
+		return true
+	}
+
+	if source == "-" {
+		return false
+	}
+
+	info, err := os.Stat(source)
+
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+func (i Input) isStream() bool {
+	source := string(i)
+
+	return strings.HasPrefix(source, "tcp://") ||
+		strings.HasPrefix(source, "http://") ||
+		strings.HasPrefix(source, "https://")
+}
+
+func (i Input) open(ctx context.Context) (io.ReadCloser, error) {
+	source := string(i)
+
+	switch {
+	case source == "-":
+		return io.NopCloser(&cancelableReader{ctx: ctx, reader: os.Stdin}), nil
+	case strings.HasPrefix(source, "tcp://"):
+		var dialer net.Dialer
+
+		return dialer.DialContext(ctx, "tcp", strings.TrimPrefix(source, "tcp://"))
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := http.DefaultClient.Do(request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode != http.StatusOK {
+			_ = response.Body.Close()
+			return nil, fmt.Errorf("unexpected status %s", response.Status)
+		}
+
+		return response.Body, nil
+	default:
+		return os.Open(source)
+	}
+}
+
+// cancelableReader abandons reads when ctx is done, for readers like standard input that can't be closed to unblock
+// them.  An abandoned read keeps its goroutine until the underlying read returns.
+type cancelableReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+type readResult struct {
+	count int
+	err   error
+}
+
+func (r *cancelableReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	buffer := make([]byte, len(p))
+	results := make(chan readResult, 1)
+
+	go func() {
+		count, err := r.reader.Read(buffer)
+		results <- readResult{count: count, err: err}
+	}()
+
+	select {
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	case result := <-results:
+		return copy(p, buffer[:result.count]), result.err
+	}
+}
+
+// Run reads input until ctx is done, or until the input ends if it isn't reopened.  Thermometer messages are passed
+// to handle, and failures to handleError.  Both are called on Run's goroutine.
+func Run(
+	ctx context.Context,
+	input Input,
+	retryInterval time.Duration,
+	handle func(Message),
+	handleError func(error)) {
+	for {
+		err := read(ctx, input, handle, handleError)
+
+		if err != nil && ctx.Err() == nil {
+			handleError(fmt.Errorf("rtl_433 input %s: %w", input, err))
+		}
+
+		if !input.reopens() {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func read(ctx context.Context, input Input, handle func(Message), handleError func(error)) error {
+	reader, err := input.open(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// Closing the reader unblocks the scanner when ctx is done
+	finished := make(chan struct{})
+	defer close(finished)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = reader.Close()
+		case <-finished:
+			_ = reader.Close()
+		}
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for ctx.Err() == nil && scanner.Scan() {
+		// Server-sent events, like rtl_433's /events endpoint, prefix the JSON with "data:"
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "data:"))
+
+		if line == "" || !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		message, err := Decode([]byte(line))
+
+		if errors.Is(err, ErrNotThermometer) {
+			continue
+		}
+
+		if err != nil {
+			handleError(fmt.Errorf("rtl_433 input %s: %w", input, err))
+			continue
+		}
+
+		handle(message)
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,80 @@
+package rtl433
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// Mapper maps rtl_433 messages to wireless thermometer states.  Most devices don't send every field in every
+// message, so each message is merged into the last known state of its device.  It isn't safe for concurrent use.
+type Mapper struct {
+	names  map[string]string
+	states map[string]spienvironment.WirelessThermometer
+}
+
+// NewMapper creates a Mapper.  names maps device keys, see Message.Key, to thermometer names.  Devices that aren't
+// listed are named after their key.
+func NewMapper(names map[string]string) *Mapper {
+	return &Mapper{
+		names:  names,
+		states: make(map[string]spienvironment.WirelessThermometer),
+	}
+}
+
+// EntityId returns the stable id of the device that sent message.
+func EntityId(message Message) string {
+	var builder strings.Builder
+	builder.WriteString("rtl433_")
+
+	for _, r := range message.Key() {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune('_')
+		}
+	}
+
+	return builder.String()
+}
+
+// Apply merges message into the state of its device.  It returns the device's entity id, its new state, and whether
+// it's the first message of the device.
+func (m *Mapper) Apply(message Message, now time.Time) (string, spienvironment.WirelessThermometer, bool) {
+	id := EntityId(message)
+	state, ok := m.states[id]
+
+	if !ok {
+		name, named := m.names[message.Key()]
+
+		if !named {
+			name = strings.ReplaceAll(message.Key(), "/", " ")
+		}
+
+		state = spienvironment.WirelessThermometer{Name: name}
+	}
+
+	state.SensorData.Temperature = message.Temperature
+	state.SensorData.LastUpdateTime = now
+
+	if message.HasHumidity {
+		state.SensorData.HasHumidity = true
+		state.SensorData.Humidity = message.Humidity
+	}
+
+	if message.HasBattery {
+		state.BatteryData.Level = int(math.Round(float64(message.BatteryOk) * 100))
+		state.BatteryData.LastUpdateTime = now
+	}
+
+	if message.HasRSSI {
+		state.RSSIData.RSSI = message.RSSI
+		state.RSSIData.LastUpdateTime = now
+	}
+
+	m.states[id] = state
+
+	return id, state, !ok
+}
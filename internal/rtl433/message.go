@@ -0,0 +1,96 @@
+package rtl433
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNotThermometer is returned for messages of devices that don't report a temperature, like door sensors.
+var ErrNotThermometer = errors.New("message has no temperature")
+
+// Message is the thermometer data of a decoded rtl_433 JSON line.
+type Message struct {
+	Model   string
+	Id      string
+	Channel string
+
+	// Temperature is always in Celsius
+	Temperature float32
+	HasHumidity bool
+	Humidity    float32
+	HasBattery  bool
+	BatteryOk   float32
+	HasRSSI     bool
+	RSSI        int
+}
+
+// Key identifies the device that sent the message, as "model/id" or "model/id/channel".
+func (m Message) Key() string {
+	if m.Channel == "" {
+		return m.Model + "/" + m.Id
+	}
+
+	return m.Model + "/" + m.Id + "/" + m.Channel
+}
+
+// Decode decodes a line of rtl_433 JSON output, as produced by "rtl_433 -F json".
+func Decode(line []byte) (Message, error) {
+	fields := make(map[string]any)
+
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return Message{}, fmt.Errorf("invalid rtl_433 JSON: %w", err)
+	}
+
+	model, ok := fields["model"].(string)
+
+	if !ok || model == "" {
+		return Message{}, errors.New("rtl_433 message has no model")
+	}
+
+	message := Message{
+		Model:   model,
+		Id:      formatField(fields["id"]),
+		Channel: formatField(fields["channel"]),
+	}
+
+	if celsius, ok := fields["temperature_C"].(float64); ok {
+		message.Temperature = float32(celsius)
+	} else if fahrenheit, ok := fields["temperature_F"].(float64); ok {
+		message.Temperature = float32((fahrenheit - 32) * 5 / 9)
+	} else {
+		return Message{}, ErrNotThermometer
+	}
+
+	if humidity, ok := fields["humidity"].(float64); ok {
+		message.HasHumidity = true
+		message.Humidity = float32(humidity)
+	}
+
+	if batteryOk, ok := fields["battery_ok"].(float64); ok {
+		message.HasBattery = true
+		message.BatteryOk = float32(math.Max(0, math.Min(1, batteryOk)))
+	}
+
+	if rssi, ok := fields["rssi"].(float64); ok {
+		message.HasRSSI = true
+		message.RSSI = int(math.Round(rssi))
+	}
+
+	return message, nil
+}
+
+// formatField formats an id or channel, which rtl_433 reports as a number or a string depending on the device.
+func formatField(value any) string {
+	switch typed := value.(type) {
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case string:
+		return strings.TrimSpace(typed)
+	default:
+		return ""
+	}
+}
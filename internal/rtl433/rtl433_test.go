@@ -0,0 +1,202 @@
+package rtl433
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const acuriteTower = `{"time" : "2024-06-01 12:00:00", "model" : "Acurite-Tower", "id" : 11111, "channel" : "A", ` +
+	`"battery_ok" : 1, "temperature_C" : 21.4, "humidity" : 48, "mic" : "CHECKSUM", "rssi" : -7.9}`
+const laCrosse = `{"time" : "2024-06-01 12:00:01", "model" : "LaCrosse-TX141THBv2", "id" : 72, "channel" : 0, ` +
+	`"battery_ok" : 0, "temperature_C" : -3.2, "humidity" : 81, "test" : "No"}`
+const fineOffset = `{"time" : "2024-06-01 12:00:02", "model" : "Fineoffset-WH2", "id" : 205, "temperature_F" : 68.0}`
+const doorSensor = `{"time" : "2024-06-01 12:00:03", "model" : "Generic-Remote", "id" : 1, "cmd" : 2}`
+
+func TestDecode(t *testing.T) {
+	for _, test := range []struct {
+		line     string
+		expected Message
+	}{
+		{acuriteTower, Message{Model: "Acurite-Tower", Id: "11111", Channel: "A", Temperature: 21.4,
+			HasHumidity: true, Humidity: 48, HasBattery: true, BatteryOk: 1, HasRSSI: true, RSSI: -8}},
+		{laCrosse, Message{Model: "LaCrosse-TX141THBv2", Id: "72", Channel: "0", Temperature: -3.2,
+			HasHumidity: true, Humidity: 81, HasBattery: true, BatteryOk: 0}},
+		{fineOffset, Message{Model: "Fineoffset-WH2", Id: "205", Temperature: 20}},
+	} {
+		// Act
+		message, err := Decode([]byte(test.line))
+
+		// Assert
+		if err != nil || message != test.expected {
+			t.Fatalf("expected %+v, got %+v %v", test.expected, message, err)
+		}
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	// Act
+	_, notThermometerErr := Decode([]byte(doorSensor))
+	_, invalidErr := Decode([]byte(`{"model": `))
+	_, noModelErr := Decode([]byte(`{"temperature_C": 20}`))
+
+	// Assert
+	if !errors.Is(notThermometerErr, ErrNotThermometer) {
+		t.Fatalf("expected ErrNotThermometer, got %v", notThermometerErr)
+	}
+
+	if invalidErr == nil || noModelErr == nil {
+		t.Fatalf("expected errors, got %v and %v", invalidErr, noModelErr)
+	}
+}
+
+func TestMapper_MergesMessagesOfADevice(t *testing.T) {
+	// Arrange
+	mapper := NewMapper(map[string]string{"Acurite-Tower/11111/A": "Garden"})
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	first, _ := Decode([]byte(acuriteTower))
+	second := Message{Model: "Acurite-Tower", Id: "11111", Channel: "A", Temperature: 22}
+
+	// Act
+	firstId, _, firstIsNew := mapper.Apply(first, now)
+	secondId, state, secondIsNew := mapper.Apply(second, now.Add(time.Minute))
+
+	// Assert
+	if firstId != "rtl433_Acurite_Tower_11111_A" || secondId != firstId || !firstIsNew || secondIsNew {
+		t.Fatalf("unexpected ids %s %s, new %v %v", firstId, secondId, firstIsNew, secondIsNew)
+	}
+
+	if state.Name != "Garden" || state.SensorData.Temperature != 22 || state.SensorData.Humidity != 48 ||
+		state.BatteryData.Level != 100 || state.RSSIData.RSSI != -8 {
+		t.Fatalf("expected the last known fields to be kept, got %+v", state)
+	}
+}
+
+func TestMapper_NamesUnlistedDevicesByKey(t *testing.T) {
+	// Arrange
+	mapper := NewMapper(nil)
+	message, _ := Decode([]byte(laCrosse))
+
+	// Act
+	_, state, _ := mapper.Apply(message, time.Now())
+
+	// Assert
+	if state.Name != "LaCrosse-TX141THBv2 72 0" || state.BatteryData.Level != 0 {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
+
+func collect(ctx context.Context, input Input) ([]Message, []error) {
+	messages := make([]Message, 0)
+	errs := make([]error, 0)
+	Run(ctx, input, 10*time.Millisecond,
+		func(message Message) { messages = append(messages, message) },
+		func(err error) { errs = append(errs, err) })
+
+	return messages, errs
+}
+
+func TestRun_ReadsFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "rtl_433.json")
+	content := acuriteTower + "\n" + doorSensor + "\nnot json\n" + fineOffset + "\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+
+	// Act
+	messages, errs := collect(context.Background(), Input(path))
+
+	// Assert
+	if len(messages) != 2 || messages[1].Model != "Fineoffset-WH2" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestRun_ReconnectsToTcpStreams(t *testing.T) {
+	// Arrange
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Skipf("unable to listen: %v", err)
+	}
+
+	defer listener.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		for _, line := range []string{acuriteTower, laCrosse} {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			_, _ = conn.Write([]byte(line + "\n"))
+			_ = conn.Close()
+		}
+
+		// Wait for the reconnection before stopping
+		conn, err := listener.Accept()
+
+		if err == nil {
+			cancel()
+			_ = conn.Close()
+		}
+	}()
+
+	// Act
+	messages, _ := collect(ctx, Input("tcp://"+listener.Addr().String()))
+
+	// Assert
+	if len(messages) != 2 || messages[0].Model != "Acurite-Tower" || messages[1].Model != "LaCrosse-TX141THBv2" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+
+	if math.Abs(float64(messages[1].Temperature)+3.2) > 0.001 {
+		t.Fatalf("unexpected temperature %v", messages[1].Temperature)
+	}
+}
+
+func TestRun_StopsReadingStandardInput(t *testing.T) {
+	// Arrange
+	reader, writer, err := os.Pipe()
+
+	if err != nil {
+		t.Fatalf("unable to create pipe: %v", err)
+	}
+
+	defer reader.Close()
+	defer writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		collect(ctx, Input("-"))
+	}()
+
+	// Act
+	cancel()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected Run to return once cancelled")
+	}
+}
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+// Setpoints are the values a schedule period, hold or vacation applies to a controller.
+type Setpoints struct {
+	Heat     float32
+	Cool     float32
+	Humidity float32
+}
+
+type Period struct {
+	Name string
+
+	// Start is the offset from local midnight at which the period begins.
+	Start     time.Duration
+	Setpoints Setpoints
+}
+
+// Schedule is a weekly program.  A period lasts until the next period starts, which may be on a following day.
+type Schedule struct {
+	Name string
+	days [7][]Period
+}
+
+// CreateSchedule builds a schedule from weekday and weekend programs.  Entries in days replace the program of a
+// specific day of the week.
+func CreateSchedule(name string, weekday []Period, weekend []Period, days map[time.Weekday][]Period) *Schedule {
+	s := &Schedule{Name: name}
+
+	for day := time.Sunday; day <= time.Saturday; day = day + 1 {
+		periods, ok := days[day]
+
+		if !ok {
+			if day == time.Saturday || day == time.Sunday {
+				periods = weekend
+			} else {
+				periods = weekday
+			}
+		}
+
+		sorted := make([]Period, len(periods))
+		copy(sorted, periods)
+		sort.SliceStable(sorted, func(i int, j int) bool { return sorted[i].Start < sorted[j].Start })
+		s.days[day] = sorted
+	}
+
+	return s
+}
+
+// PeriodAt returns the period in effect at the passed time, along with the time it started.  Returns false if the
+// schedule doesn't have any periods.
+func (s *Schedule) PeriodAt(t time.Time) (Period, time.Time, bool) {
+	midnight := startOfDay(t)
+	offset := wallClockOffset(t)
+
+	for i := 0; i <= 7; i = i + 1 {
+		day := midnight.AddDate(0, 0, -i)
+		periods := s.days[day.Weekday()]
+
+		for j := len(periods) - 1; j >= 0; j = j - 1 {
+			if i > 0 || periods[j].Start <= offset {
+				return periods[j], atOffset(day, periods[j].Start), true
+			}
+		}
+	}
+
+	return Period{}, time.Time{}, false
+}
+
+// NextPeriodAfter returns the first period starting after the passed time, along with its start time.
+func (s *Schedule) NextPeriodAfter(t time.Time) (Period, time.Time, bool) {
+	midnight := startOfDay(t)
+	offset := wallClockOffset(t)
+
+	for i := 0; i <= 7; i = i + 1 {
+		day := midnight.AddDate(0, 0, i)
+
+		for _, period := range s.days[day.Weekday()] {
+			if i > 0 || period.Start > offset {
+				return period, atOffset(day, period.Start), true
+			}
+		}
+	}
+
+	return Period{}, time.Time{}, false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// wallClockOffset returns the wall clock time of day as an offset from midnight, to compare with period starts.  It
+// differs from the time elapsed since midnight on daylight saving transition days.
+func wallClockOffset(t time.Time) time.Duration {
+	hour, minute, second := t.Clock()
+
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second +
+		time.Duration(t.Nanosecond())
+}
+
+// atOffset converts an offset from midnight into a wall clock time, so periods keep their local start time across
+// daylight saving transitions.
+func atOffset(day time.Time, offset time.Duration) time.Time {
+	hours := int(offset / time.Hour)
+	minutes := int((offset % time.Hour) / time.Minute)
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hours, minutes, 0, 0, day.Location())
+}
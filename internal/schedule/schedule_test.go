@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func createTestSchedule() *Schedule {
+	return CreateSchedule(
+		"test",
+		[]Period{
+			{Name: "Night", Start: 22 * time.Hour, Setpoints: Setpoints{Heat: 17, Cool: 27}},
+			{Name: "Day", Start: 6*time.Hour + 30*time.Minute, Setpoints: Setpoints{Heat: 21, Cool: 25}},
+		},
+		[]Period{
+			{Name: "Day", Start: 8 * time.Hour, Setpoints: Setpoints{Heat: 21, Cool: 25}},
+			{Name: "Night", Start: 23 * time.Hour, Setpoints: Setpoints{Heat: 17, Cool: 27}},
+		},
+		nil)
+}
+
+func TestSchedule_PeriodAt(t *testing.T) {
+	// Arrange
+	s := createTestSchedule()
+	// Monday, 2024-01-01
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		at            time.Time
+		expectedName  string
+		expectedStart time.Time
+	}{
+		// Before the first Monday period, the last period of Sunday is still in effect.
+		{monday.Add(5 * time.Hour), "Night", monday.Add(-1 * time.Hour)},
+		{monday.Add(6*time.Hour + 30*time.Minute), "Day", monday.Add(6*time.Hour + 30*time.Minute)},
+		{monday.Add(23 * time.Hour), "Night", monday.Add(22 * time.Hour)},
+		// Saturday uses the weekend program
+		{monday.AddDate(0, 0, 5).Add(7 * time.Hour), "Night", monday.AddDate(0, 0, 4).Add(22 * time.Hour)},
+	}
+
+	for _, c := range cases {
+		// Act
+		period, start, ok := s.PeriodAt(c.at)
+
+		// Assert
+		if !ok || period.Name != c.expectedName || !start.Equal(c.expectedStart) {
+			t.Fatalf("at %v: expected %s from %v, got %s from %v (%v)",
+				c.at, c.expectedName, c.expectedStart, period.Name, start, ok)
+		}
+	}
+}
+
+func TestSchedule_NextPeriodAfter(t *testing.T) {
+	// Arrange
+	s := createTestSchedule()
+	// Friday, 2024-01-05 at 23:00
+	friday := time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC)
+
+	// Act
+	period, start, ok := s.NextPeriodAfter(friday)
+
+	// Assert
+	expectedStart := time.Date(2024, 1, 6, 8, 0, 0, 0, time.UTC)
+
+	if !ok || period.Name != "Day" || !start.Equal(expectedStart) {
+		t.Fatalf("expected Day at %v, got %s at %v (%v)", expectedStart, period.Name, start, ok)
+	}
+}
+
+func TestSchedule_PeriodAt_KeepsWallClockAcrossDaylightSavingTime(t *testing.T) {
+	// Arrange
+	location, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	s := createTestSchedule()
+	// DST starts on Sunday, 2024-03-10 at 02:00 local time, so Monday's 06:30 is 10:30 UTC, not 11:30.
+	at := time.Date(2024, 3, 11, 7, 0, 0, 0, location)
+
+	// Act
+	_, start, _ := s.PeriodAt(at)
+
+	// Assert
+	expectedStart := time.Date(2024, 3, 11, 10, 30, 0, 0, time.UTC)
+
+	if !start.Equal(expectedStart) {
+		t.Fatalf("expected %v, got %v", expectedStart, start.UTC())
+	}
+}
+
+func TestSchedule_OnDaylightSavingTimeDays(t *testing.T) {
+	// Arrange
+	location, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	s := createTestSchedule()
+
+	cases := []struct {
+		at                time.Time
+		expectedName      string
+		expectedStart     time.Time
+		expectedNextName  string
+		expectedNextStart time.Time
+	}{
+		// DST starts on Sunday, 2026-03-08 at 02:00, so only 7.5 hours have elapsed since midnight at 08:30.
+		{
+			time.Date(2026, 3, 8, 8, 30, 0, 0, location),
+			"Day", time.Date(2026, 3, 8, 8, 0, 0, 0, location),
+			"Night", time.Date(2026, 3, 8, 23, 0, 0, 0, location),
+		},
+		// DST ends on Sunday, 2026-11-01 at 02:00, so 8.5 hours have elapsed since midnight at 07:30.
+		{
+			time.Date(2026, 11, 1, 7, 30, 0, 0, location),
+			"Night", time.Date(2026, 10, 31, 23, 0, 0, 0, location),
+			"Day", time.Date(2026, 11, 1, 8, 0, 0, 0, location),
+		},
+	}
+
+	for _, c := range cases {
+		// Act
+		period, start, ok := s.PeriodAt(c.at)
+		next, nextStart, nextOk := s.NextPeriodAfter(c.at)
+
+		// Assert
+		if !ok || period.Name != c.expectedName || !start.Equal(c.expectedStart) {
+			t.Fatalf("at %v: expected %s from %v, got %s from %v (%v)",
+				c.at, c.expectedName, c.expectedStart, period.Name, start, ok)
+		}
+
+		if !nextOk || next.Name != c.expectedNextName || !nextStart.Equal(c.expectedNextStart) {
+			t.Fatalf("at %v: expected next %s at %v, got %s at %v (%v)",
+				c.at, c.expectedNextName, c.expectedNextStart, next.Name, nextStart, nextOk)
+		}
+	}
+}
+
+func TestSchedule_Empty(t *testing.T) {
+	// Arrange
+	s := CreateSchedule("empty", nil, nil, nil)
+
+	// Act
+	_, _, ok := s.PeriodAt(time.Now())
+
+	// Assert
+	if ok {
+		t.Fatalf("expected no period for an empty schedule")
+	}
+}
@@ -0,0 +1,137 @@
+package schedule
+
+import (
+	"time"
+)
+
+type Source int
+
+const (
+	SourceNone Source = iota
+	SourceSchedule
+	SourceHold
+	SourceVacation
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceNone:
+		return "none"
+	case SourceSchedule:
+		return "schedule"
+	case SourceHold:
+		return "hold"
+	case SourceVacation:
+		return "vacation"
+	}
+
+	return "unknown"
+}
+
+type Hold struct {
+	Active    bool
+	Until     time.Time
+	Setpoints Setpoints
+}
+
+type Vacation struct {
+	Active    bool
+	From      time.Time
+	To        time.Time
+	Setpoints Setpoints
+}
+
+// Scheduler selects the setpoints of a controller from its schedule, a temporary hold or a vacation.  It is a value
+// type so controller state snapshots can be handed to other goroutines; the Schedule itself is never modified.
+type Scheduler struct {
+	Schedule           *Schedule
+	Source             Source
+	Setpoints          Setpoints
+	CurrentPeriod      Period
+	CurrentPeriodStart time.Time
+	HasCurrentPeriod   bool
+	NextPeriod         Period
+	NextPeriodStart    time.Time
+	HasNextPeriod      bool
+	Hold               Hold
+	Vacation           Vacation
+}
+
+func CreateScheduler(schedule *Schedule) Scheduler {
+	return Scheduler{Schedule: schedule}
+}
+
+func (s Scheduler) Enabled() bool {
+	return s.Schedule != nil
+}
+
+// Evaluate updates the current and next periods and returns the setpoints that apply at the passed time.  The second
+// return value is true if the setpoints, or their source, changed since the previous evaluation.
+func (s *Scheduler) Evaluate(now time.Time) (Setpoints, bool) {
+	if s.Schedule == nil {
+		return Setpoints{}, false
+	}
+
+	s.CurrentPeriod, s.CurrentPeriodStart, s.HasCurrentPeriod = s.Schedule.PeriodAt(now)
+	s.NextPeriod, s.NextPeriodStart, s.HasNextPeriod = s.Schedule.NextPeriodAfter(now)
+
+	if s.Hold.Active && !now.Before(s.Hold.Until) {
+		s.Hold = Hold{}
+	}
+
+	if s.Vacation.Active && !now.Before(s.Vacation.To) {
+		s.Vacation = Vacation{}
+	}
+
+	source := SourceNone
+	setpoints := Setpoints{}
+
+	switch {
+	case s.Vacation.Active && !now.Before(s.Vacation.From):
+		source = SourceVacation
+		setpoints = s.Vacation.Setpoints
+	case s.Hold.Active:
+		source = SourceHold
+		setpoints = s.Hold.Setpoints
+	case s.HasCurrentPeriod:
+		source = SourceSchedule
+		setpoints = s.CurrentPeriod.Setpoints
+	}
+
+	changed := source != s.Source || setpoints != s.Setpoints
+	s.Source = source
+	s.Setpoints = setpoints
+
+	return setpoints, changed && source != SourceNone
+}
+
+// HoldUntil overrides the schedule with the passed setpoints until the passed time.  A zero time holds until the
+// start of the next period.
+func (s *Scheduler) HoldUntil(setpoints Setpoints, until time.Time, now time.Time) {
+	if until.IsZero() {
+		_, until, _ = s.Schedule.NextPeriodAfter(now)
+	}
+
+	s.Hold = Hold{Active: true, Until: until, Setpoints: setpoints}
+}
+
+// Resume cancels a hold, returning control to the schedule.
+func (s *Scheduler) Resume() {
+	s.Hold = Hold{}
+}
+
+func (s *Scheduler) SetVacation(from time.Time, to time.Time, setpoints Setpoints) {
+	s.Vacation = Vacation{Active: true, From: from, To: to, Setpoints: setpoints}
+}
+
+func (s *Scheduler) CancelVacation() {
+	s.Vacation = Vacation{}
+}
+
+// IScheduled is implemented by controllers whose setpoints can be driven by a Scheduler.
+type IScheduled interface {
+	GetScheduler() *Scheduler
+
+	// ValidateSetpoints checks whether the passed setpoints are acceptable for the controller.
+	ValidateSetpoints(setpoints Setpoints) error
+}
@@ -0,0 +1,58 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduler_Evaluate_HoldExpiresAtNextPeriod(t *testing.T) {
+	// Arrange
+	scheduler := CreateScheduler(createTestSchedule())
+	// Monday, 2024-01-01 at 12:00
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	scheduler.Evaluate(now)
+	holdSetpoints := Setpoints{Heat: 23, Cool: 26}
+
+	// Act
+	scheduler.HoldUntil(holdSetpoints, time.Time{}, now)
+	heldSetpoints, heldChanged := scheduler.Evaluate(now.Add(time.Hour))
+	resumedSetpoints, resumedChanged := scheduler.Evaluate(now.Add(10 * time.Hour))
+
+	// Assert
+	if !heldChanged || heldSetpoints != holdSetpoints {
+		t.Fatalf("expected hold setpoints %v, got %v (%v)", holdSetpoints, heldSetpoints, heldChanged)
+	}
+
+	if !resumedChanged || resumedSetpoints != (Setpoints{Heat: 17, Cool: 27}) || scheduler.Source != SourceSchedule {
+		t.Fatalf("expected night setpoints after the hold, got %v from %v (%v)",
+			resumedSetpoints, scheduler.Source, resumedChanged)
+	}
+}
+
+func TestScheduler_Evaluate_VacationOverridesHold(t *testing.T) {
+	// Arrange
+	scheduler := CreateScheduler(createTestSchedule())
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	vacationSetpoints := Setpoints{Heat: 12, Cool: 30}
+	scheduler.HoldUntil(Setpoints{Heat: 23, Cool: 26}, now.Add(time.Hour), now)
+	scheduler.SetVacation(now.Add(30*time.Minute), now.AddDate(0, 0, 7), vacationSetpoints)
+
+	// Act
+	beforeVacation, _ := scheduler.Evaluate(now)
+	duringVacation, _ := scheduler.Evaluate(now.Add(45 * time.Minute))
+	sourceDuringVacation := scheduler.Source
+	afterVacation, _ := scheduler.Evaluate(now.AddDate(0, 0, 7).Add(time.Minute))
+
+	// Assert
+	if beforeVacation.Heat != 23 {
+		t.Fatalf("expected the hold before the vacation starts, got %v", beforeVacation)
+	}
+
+	if duringVacation != vacationSetpoints || sourceDuringVacation != SourceVacation {
+		t.Fatalf("expected vacation setpoints, got %v from %v", duringVacation, sourceDuringVacation)
+	}
+
+	if afterVacation.Heat != 21 || scheduler.Vacation.Active {
+		t.Fatalf("expected the schedule after the vacation, got %v", afterVacation)
+	}
+}
@@ -0,0 +1,159 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+)
+
+// Config configures a set of synthetic wireless thermometers.
+type Config struct {
+	Count int
+
+	// Seed makes the generated sensors and readings repeatable.
+	Seed uint64
+
+	// DropoutRate is the probability, between 0 and 1, that a sensor misses a reading.
+	DropoutRate float64
+
+	// BatteryDrainPerDay is the battery percentage each sensor loses per day.
+	BatteryDrainPerDay float64
+}
+
+// Reading is the state of a synthetic thermometer at a point in time.
+type Reading struct {
+	Id    string
+	Name  string
+	State spienvironment.WirelessThermometer
+}
+
+type sensor struct {
+	id   string
+	name string
+
+	// The daily temperature curve peaks at peakHour, and swings amplitude degrees around baseTemperature
+	baseTemperature float64
+	amplitude       float64
+	peakHour        float64
+
+	// Relative humidity drops by humiditySlope percent per degree above baseTemperature
+	baseHumidity  float64
+	humiditySlope float64
+
+	initialBattery float64
+	rssi           float64
+}
+
+// Simulator produces realistic readings for synthetic thermometers: diurnal temperature and humidity curves with
+// noise, battery drain, RSSI noise and occasional dropouts.  It isn't safe for concurrent use.
+type Simulator struct {
+	config  Config
+	random  *rand.Rand
+	sensors []sensor
+	start   time.Time
+}
+
+func NewSimulator(config Config, start time.Time) *Simulator {
+	if config.Count < 0 {
+		config.Count = 0
+	}
+
+	if config.DropoutRate < 0 || config.DropoutRate > 1 {
+		config.DropoutRate = 0.02
+	}
+
+	if config.BatteryDrainPerDay < 0 {
+		config.BatteryDrainPerDay = 0
+	}
+
+	random := rand.New(rand.NewPCG(config.Seed, config.Seed^0x5deece66d))
+	sensors := make([]sensor, config.Count)
+
+	for i := 0; i < config.Count; i = i + 1 {
+		s := sensor{
+			id:              fmt.Sprintf("simulator_%d", i+1),
+			name:            fmt.Sprintf("Simulated %d", i+1),
+			baseTemperature: 19 + random.Float64()*4,
+			amplitude:       0.5 + random.Float64()*1.5,
+			peakHour:        14 + random.Float64()*3,
+			baseHumidity:    35 + random.Float64()*15,
+			humiditySlope:   1.5 + random.Float64(),
+			initialBattery:  60 + random.Float64()*40,
+			rssi:            -90 + random.Float64()*45,
+		}
+
+		// Every fourth sensor is outdoors, with a cooler, wider and more humid curve
+		if i%4 == 3 {
+			s.name = fmt.Sprintf("Simulated Outdoor %d", i+1)
+			s.baseTemperature = 8 + random.Float64()*10
+			s.amplitude = 4 + random.Float64()*4
+			s.baseHumidity = 60 + random.Float64()*15
+			s.humiditySlope = 3 + random.Float64()
+		}
+
+		sensors[i] = s
+	}
+
+	return &Simulator{
+		config:  config,
+		random:  random,
+		sensors: sensors,
+		start:   start,
+	}
+}
+
+// Sensors returns the ids and names of the synthetic thermometers.
+func (s *Simulator) Sensors() []Reading {
+	result := make([]Reading, len(s.sensors))
+
+	for i, sensor := range s.sensors {
+		result[i] = Reading{Id: sensor.id, Name: sensor.name}
+	}
+
+	return result
+}
+
+// Sample returns the readings of the sensors at now.  Sensors that drop out are omitted.
+func (s *Simulator) Sample(now time.Time) []Reading {
+	result := make([]Reading, 0, len(s.sensors))
+	hour := float64(now.Hour()) + float64(now.Minute())/60 + float64(now.Second())/3600
+	days := now.Sub(s.start).Hours() / 24
+
+	for _, sensor := range s.sensors {
+		if s.random.Float64() < s.config.DropoutRate {
+			continue
+		}
+
+		offset := sensor.amplitude*math.Cos(2*math.Pi*(hour-sensor.peakHour)/24) + s.random.NormFloat64()*0.05
+		humidity := sensor.baseHumidity - sensor.humiditySlope*offset + s.random.NormFloat64()*0.3
+		battery := sensor.initialBattery - s.config.BatteryDrainPerDay*days
+		rssi := sensor.rssi + s.random.NormFloat64()*3
+
+		state := spienvironment.WirelessThermometer{Name: sensor.name}
+		state.SensorData = spienvironment.SensorData{
+			LastUpdateTime: now,
+			Temperature:    round(sensor.baseTemperature+offset, 10),
+			HasHumidity:    true,
+			Humidity:       round(math.Max(1, math.Min(99, humidity)), 10),
+		}
+		state.BatteryData = spienvironment.BatteryData{
+			LastUpdateTime: now,
+			Level:          int(math.Max(0, math.Ceil(battery))),
+		}
+		state.RSSIData = spienvironment.RSSIData{
+			LastUpdateTime: now,
+			RSSI:           int(math.Round(rssi)),
+		}
+		result = append(result, Reading{Id: sensor.id, Name: sensor.name, State: state})
+	}
+
+	return result
+}
+
+// round rounds value to 1/scale, as the sensors report.
+func round(value float64, scale float64) float32 {
+	return float32(math.Round(value*scale) / scale)
+}
@@ -0,0 +1,83 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSimulator_Sensors(t *testing.T) {
+	// Arrange
+	simulator := NewSimulator(Config{Count: 4, Seed: 1}, start)
+
+	// Act
+	sensors := simulator.Sensors()
+
+	// Assert
+	if len(sensors) != 4 || sensors[0].Id != "simulator_1" || sensors[3].Name != "Simulated Outdoor 4" {
+		t.Fatalf("unexpected sensors %+v", sensors)
+	}
+}
+
+func TestSimulator_IsRepeatable(t *testing.T) {
+	// Arrange
+	first := NewSimulator(Config{Count: 3, Seed: 42}, start)
+	second := NewSimulator(Config{Count: 3, Seed: 42}, start)
+
+	// Act
+	firstReadings := first.Sample(start.Add(time.Hour))
+	secondReadings := second.Sample(start.Add(time.Hour))
+
+	// Assert
+	for i := range firstReadings {
+		if firstReadings[i] != secondReadings[i] {
+			t.Fatalf("expected equal readings, got %+v and %+v", firstReadings[i], secondReadings[i])
+		}
+	}
+}
+
+func TestSimulator_FollowsDiurnalCurve(t *testing.T) {
+	// Arrange
+	simulator := NewSimulator(Config{Count: 1, Seed: 7}, start)
+
+	// Act
+	night := simulator.Sample(start.Add(3 * time.Hour))[0].State.SensorData
+	afternoon := simulator.Sample(start.Add(15 * time.Hour))[0].State.SensorData
+
+	// Assert
+	if afternoon.Temperature <= night.Temperature {
+		t.Fatalf("expected the afternoon to be warmer, got %v and %v", afternoon.Temperature, night.Temperature)
+	}
+
+	if afternoon.Humidity >= night.Humidity {
+		t.Fatalf("expected the afternoon to be drier, got %v and %v", afternoon.Humidity, night.Humidity)
+	}
+}
+
+func TestSimulator_DrainsBattery(t *testing.T) {
+	// Arrange
+	simulator := NewSimulator(Config{Count: 1, Seed: 7, BatteryDrainPerDay: 2}, start)
+
+	// Act
+	first := simulator.Sample(start)[0].State.BatteryData.Level
+	later := simulator.Sample(start.Add(10 * 24 * time.Hour))[0].State.BatteryData.Level
+
+	// Assert
+	if first-later < 19 || first-later > 21 {
+		t.Fatalf("expected the battery to drain by 20, got %v and %v", first, later)
+	}
+}
+
+func TestSimulator_DropsOutReadings(t *testing.T) {
+	// Arrange
+	simulator := NewSimulator(Config{Count: 5, Seed: 7, DropoutRate: 1}, start)
+
+	// Act
+	readings := simulator.Sample(start)
+
+	// Assert
+	if len(readings) != 0 {
+		t.Fatalf("expected every reading to drop out, got %v", readings)
+	}
+}
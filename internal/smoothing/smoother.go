@@ -0,0 +1,107 @@
+package smoothing
+
+type Method int
+
+const (
+	MethodNone Method = iota
+	MethodExponential
+	MethodMovingAverage
+	MethodKalman
+)
+
+// Config configures the smoothing of a single kind of reading.
+type Config struct {
+	Method Method
+
+	// Alpha is the weight of each new reading with MethodExponential, between 0 and 1.
+	Alpha float32
+
+	// Window is the number of readings averaged with MethodMovingAverage.
+	Window int
+
+	// ProcessNoise and MeasurementNoise are the variances of the actual value between readings, and of the sensor,
+	// with MethodKalman.  Higher measurement noise relative to process noise means more smoothing.
+	ProcessNoise     float32
+	MeasurementNoise float32
+}
+
+// Smoother smooths a stream of readings.  It isn't safe for concurrent use.
+type Smoother struct {
+	config   Config
+	hasValue bool
+	value    float32
+
+	// The readings in the moving average window
+	window []float32
+
+	// The variance of the Kalman estimate
+	variance float32
+}
+
+func NewSmoother(config Config) *Smoother {
+	if config.Alpha <= 0 || config.Alpha > 1 {
+		config.Alpha = 0.3
+	}
+
+	if config.Window < 1 {
+		config.Window = 5
+	}
+
+	if config.ProcessNoise <= 0 {
+		config.ProcessNoise = 0.01
+	}
+
+	if config.MeasurementNoise <= 0 {
+		config.MeasurementNoise = 0.1
+	}
+
+	return &Smoother{
+		config: config,
+		window: make([]float32, 0, config.Window),
+	}
+}
+
+func (s *Smoother) Enabled() bool {
+	return s.config.Method != MethodNone
+}
+
+// Apply adds a reading and returns the smoothed value.
+func (s *Smoother) Apply(reading float32) float32 {
+	if !s.hasValue {
+		s.hasValue = true
+		s.value = reading
+		s.variance = s.config.MeasurementNoise
+		s.window = append(s.window, reading)
+
+		return reading
+	}
+
+	switch s.config.Method {
+	case MethodExponential:
+		s.value = s.value + s.config.Alpha*(reading-s.value)
+	case MethodMovingAverage:
+		if len(s.window) == s.config.Window {
+			s.window = append(s.window[:0], s.window[1:]...)
+		}
+
+		s.window = append(s.window, reading)
+		var sum float32 = 0
+
+		for _, value := range s.window {
+			sum = sum + value
+		}
+
+		s.value = sum / float32(len(s.window))
+	case MethodKalman:
+		// A constant value model: predict the value unchanged with added process noise, then correct towards the
+		// reading.
+		variance := s.variance + s.config.ProcessNoise
+		gain := variance / (variance + s.config.MeasurementNoise)
+		s.value = s.value + gain*(reading-s.value)
+		s.variance = (1 - gain) * variance
+	default:
+		s.value = reading
+	}
+
+	return s.value
+}
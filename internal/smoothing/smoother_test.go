@@ -0,0 +1,83 @@
+package smoothing
+
+import (
+	"math"
+	"testing"
+)
+
+func applyAll(smoother *Smoother, readings ...float32) float32 {
+	var result float32 = 0
+
+	for _, reading := range readings {
+		result = smoother.Apply(reading)
+	}
+
+	return result
+}
+
+func TestSmoother_None(t *testing.T) {
+	// Arrange
+	smoother := NewSmoother(Config{})
+
+	// Act
+	result := applyAll(smoother, 20, 25)
+
+	// Assert
+	if smoother.Enabled() || result != 25 {
+		t.Fatalf("expected the reading unchanged, got %v", result)
+	}
+}
+
+func TestSmoother_Exponential(t *testing.T) {
+	// Arrange
+	smoother := NewSmoother(Config{Method: MethodExponential, Alpha: 0.5})
+
+	// Act
+	result := applyAll(smoother, 20, 22, 24)
+
+	// Assert
+	if result != 22.5 {
+		t.Fatalf("expected 22.5, got %v", result)
+	}
+}
+
+func TestSmoother_MovingAverage(t *testing.T) {
+	// Arrange
+	smoother := NewSmoother(Config{Method: MethodMovingAverage, Window: 3})
+
+	// Act
+	result := applyAll(smoother, 10, 20, 21, 22, 23)
+
+	// Assert
+	if result != 22 {
+		t.Fatalf("expected 22, got %v", result)
+	}
+}
+
+func TestSmoother_KalmanDampensNoise(t *testing.T) {
+	// Arrange
+	smoother := NewSmoother(Config{Method: MethodKalman, ProcessNoise: 0.001, MeasurementNoise: 0.1})
+	applyAll(smoother, 20, 20.1, 19.9, 20.1, 19.9, 20.1, 19.9, 20.1)
+
+	// Act
+	result := smoother.Apply(20.1)
+
+	// Assert
+	if math.Abs(float64(result)-20) > 0.05 {
+		t.Fatalf("expected about 20, got %v", result)
+	}
+}
+
+func TestSmoother_KalmanFollowsStepChange(t *testing.T) {
+	// Arrange
+	smoother := NewSmoother(Config{Method: MethodKalman, ProcessNoise: 0.05, MeasurementNoise: 0.1})
+	applyAll(smoother, 20, 20, 20)
+
+	// Act
+	result := applyAll(smoother, 25, 25, 25, 25, 25, 25, 25, 25, 25, 25)
+
+	// Assert
+	if math.Abs(float64(result)-25) > 0.2 {
+		t.Fatalf("expected about 25, got %v", result)
+	}
+}
@@ -5,8 +5,15 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/avanha/pmaas-plugin-environment/clock"
 	"github.com/avanha/pmaas-plugin-environment/data"
 	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/deadband"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-plugin-environment/internal/filter"
+	"github.com/avanha/pmaas-plugin-environment/internal/history"
+	"github.com/avanha/pmaas-plugin-environment/internal/psychrometrics"
+	"github.com/avanha/pmaas-plugin-environment/internal/smoothing"
 	"github.com/avanha/pmaas-plugin-environment/internal/wrapper"
 	"github.com/avanha/pmaas-spi"
 	spicommon "github.com/avanha/pmaas-spi/common"
@@ -20,11 +27,12 @@ func CreateWirelessThermometer(
 	targetEntityId string,
 	name string,
 	entityType reflect.Type,
-	trackingConfig tracking.Config) *WirelessThermometer {
+	trackingConfig tracking.Config,
+	c clock.Clock) *WirelessThermometer {
 	return &WirelessThermometer{
 		Thermometer: Thermometer{
 			WrappedEntity: wrapper.CreateWrappedEntity(
-				"WirelessThermometer", instanceId, targetEntityId, name, entityType),
+				"WirelessThermometer", instanceId, targetEntityId, name, entityType, c.Now()),
 			LowTemperature:  1000,
 			HighTemperature: -1000,
 			LowHumidity:     1000,
@@ -33,6 +41,7 @@ func CreateWirelessThermometer(
 		},
 		BatteryData: spienvironment.BatteryData{},
 		RSSIData:    spienvironment.RSSIData{},
+		clock:       c,
 	}
 }
 
@@ -40,7 +49,75 @@ type WirelessThermometer struct {
 	Thermometer
 	BatteryData spienvironment.BatteryData
 	RSSIData    spienvironment.RSSIData
-	stub        *wirelessThermometerStub
+
+	// TemperatureFilterStats and HumidityFilterStats count the readings accepted and rejected by the input filters.
+	TemperatureFilterStats filter.Stats
+	HumidityFilterStats    filter.Stats
+
+	// SmoothedTemperature and SmoothedHumidity are the readings after smoothing, or the raw readings when Smoothing is
+	// off.  With UseSmoothedValues, they drive events, the daily extremes and tracking instead of SensorData.
+	Smoothing           bool
+	UseSmoothedValues   bool
+	SmoothedTemperature float32
+	SmoothedHumidity    float32
+
+	// RecentReadings and Display are only populated on state copies that are rendered.
+	RecentReadings []history.Reading
+	Display        display.Preferences
+
+	temperatureFilter   *filter.Filter
+	humidityFilter      *filter.Filter
+	temperatureSmoother *smoothing.Smoother
+	humiditySmoother    *smoothing.Smoother
+
+	// The event gates limit change events to significant changes, and add heartbeats.  Without them, every change
+	// is published.
+	temperatureEventGate *deadband.Gate
+	humidityEventGate    *deadband.Gate
+	clock                clock.Clock
+	stub                 *wirelessThermometerStub
+}
+
+// SetInputFilters sets the filters incoming readings pass through before they update the state.
+func (wt *WirelessThermometer) SetInputFilters(temperature filter.Config, humidity filter.Config) {
+	wt.temperatureFilter = filter.NewFilter(temperature)
+	wt.humidityFilter = filter.NewFilter(humidity)
+}
+
+// SetSmoothing sets the smoothing of incoming readings, and whether the smoothed values drive events, the daily
+// extremes and tracking.
+func (wt *WirelessThermometer) SetSmoothing(
+	temperature smoothing.Config,
+	humidity smoothing.Config,
+	useSmoothedValues bool) {
+	wt.temperatureSmoother = smoothing.NewSmoother(temperature)
+	wt.humiditySmoother = smoothing.NewSmoother(humidity)
+	wt.Smoothing = wt.temperatureSmoother.Enabled() || wt.humiditySmoother.Enabled()
+	wt.UseSmoothedValues = useSmoothedValues
+}
+
+// SetEventGates sets the deadbands, minimum intervals and heartbeats of temperature and humidity change events.
+func (wt *WirelessThermometer) SetEventGates(temperature deadband.Config, humidity deadband.Config) {
+	wt.temperatureEventGate = deadband.NewGate(temperature)
+	wt.humidityEventGate = deadband.NewGate(humidity)
+}
+
+// EffectiveTemperature returns the temperature that drives events, the daily extremes and tracking.
+func (wt *WirelessThermometer) EffectiveTemperature() float32 {
+	if wt.UseSmoothedValues {
+		return wt.SmoothedTemperature
+	}
+
+	return wt.SensorData.Temperature
+}
+
+// EffectiveHumidity returns the humidity that drives events, the daily extremes and tracking.
+func (wt *WirelessThermometer) EffectiveHumidity() float32 {
+	if wt.UseSmoothedValues {
+		return wt.SmoothedHumidity
+	}
+
+	return wt.SensorData.Humidity
 }
 
 func (wt *WirelessThermometer) GetStub(container spi.IPMAASContainer) entities.WirelessThermometer {
@@ -62,12 +139,23 @@ func (wt *WirelessThermometer) TrackingConfig() tracking.Config {
 }
 
 func (wt *WirelessThermometer) Data() tracking.DataSample {
+	var dewPoint *float32 = nil
+	temperature := wt.EffectiveTemperature()
+	humidity := wt.EffectiveHumidity()
+
+	if wt.SensorData.HasHumidity {
+		if value, ok := psychrometrics.DewPoint(temperature, humidity); ok {
+			dewPoint = &value
+		}
+	}
+
 	return tracking.DataSample{
 		LastUpdateTime: wt.SensorData.LastUpdateTime,
 		Data: data.WirelessThermometerData{
-			Temperature:    wt.SensorData.Temperature,
+			Temperature:    temperature,
 			HasHumidity:    wt.SensorData.HasHumidity,
-			Humidity:       wt.SensorData.Humidity,
+			Humidity:       humidity,
+			DewPoint:       dewPoint,
 			BatteryLevel:   int32(wt.BatteryData.Level),
 			RSSI:           int32(wt.RSSIData.RSSI),
 			LastUpdateTime: wt.SensorData.LastUpdateTime,
@@ -113,9 +201,12 @@ func (wt *WirelessThermometer) ProcessNewState(newState any, publishEventFunc fu
 	temperatureUpdated := false
 	humidityUpdated := false
 	currentName := wt.Name
-	currentTemperature := wt.SensorData.Temperature
-	currentHumidity := wt.SensorData.Humidity
-	now := time.Now()
+	currentTemperature := wt.EffectiveTemperature()
+	currentHumidity := wt.EffectiveHumidity()
+	now := wt.clock.Now()
+
+	// Unlike SensorData.LastUpdateTime, this tracks every state received, even if the readings didn't change.
+	wt.WrappedEntity.LastUpdateTime = now
 
 	if currentName != newWirelessThermometerState.Name {
 		wt.Name = newWirelessThermometerState.Name
@@ -128,52 +219,85 @@ func (wt *WirelessThermometer) ProcessNewState(newState any, publishEventFunc fu
 		rssiUpdated = true
 	}
 
-	if wt.BatteryData.Level != newWirelessThermometerState.BatteryData.Level {
+	// A first report of an empty battery doesn't change the level, but it's data nonetheless
+	if wt.BatteryData.Level != newWirelessThermometerState.BatteryData.Level ||
+		(wt.BatteryData.IsEmpty() && !newWirelessThermometerState.BatteryData.IsEmpty()) {
 		wt.BatteryData.Level = newWirelessThermometerState.BatteryData.Level
 		wt.BatteryData.LastUpdateTime = newWirelessThermometerState.BatteryData.LastUpdateTime
 		batteryLevelUpdated = true
 	}
 
+	sensorData := newWirelessThermometerState.SensorData
+	newTemperature, temperatureAccepted := wt.applyInputFilter(
+		wt.temperatureFilter, &wt.TemperatureFilterStats, "temperature", sensorData.Temperature, now)
+	newHumidity, humidityAccepted := sensorData.Humidity, true
+
+	if sensorData.HasHumidity {
+		newHumidity, humidityAccepted = wt.applyInputFilter(
+			wt.humidityFilter, &wt.HumidityFilterStats, "humidity", sensorData.Humidity, now)
+	}
+
+	if temperatureAccepted {
+		if wt.SensorData.Temperature != newTemperature {
+			wt.SensorData.Temperature = newTemperature
+			wt.SensorData.LastUpdateTime = now
+		}
+
+		wt.SmoothedTemperature = smooth(wt.temperatureSmoother, newTemperature)
+		newTemperature = wt.EffectiveTemperature()
+	}
+
+	if humidityAccepted {
+		if wt.SensorData.Humidity != newHumidity {
+			wt.SensorData.Humidity = newHumidity
+			wt.SensorData.LastUpdateTime = now
+		}
+
+		if sensorData.HasHumidity {
+			wt.SmoothedHumidity = smooth(wt.humiditySmoother, newHumidity)
+		} else {
+			wt.SmoothedHumidity = newHumidity
+		}
+
+		newHumidity = wt.EffectiveHumidity()
+	}
+
 	// Temperature
-	if currentTemperature != newWirelessThermometerState.SensorData.Temperature {
-		wt.SensorData.Temperature = newWirelessThermometerState.SensorData.Temperature
-		wt.SensorData.LastUpdateTime = now
+	if temperatureAccepted && currentTemperature != newTemperature {
 		temperatureUpdated = true
 
-		if now.Day() != wt.HighTemperatureTime.Day() {
+		if !clock.SameDay(now, wt.HighTemperatureTime) {
 			wt.HighTemperature = -1000
 			wt.LowTemperature = 1000
 		}
 
-		if newWirelessThermometerState.SensorData.Temperature > wt.HighTemperature {
-			wt.HighTemperature = newWirelessThermometerState.SensorData.Temperature
+		if newTemperature > wt.HighTemperature {
+			wt.HighTemperature = newTemperature
 			wt.HighTemperatureTime = now
 		}
 
-		if newWirelessThermometerState.SensorData.Temperature < wt.LowTemperature {
-			wt.LowTemperature = newWirelessThermometerState.SensorData.Temperature
+		if newTemperature < wt.LowTemperature {
+			wt.LowTemperature = newTemperature
 			wt.LowTemperatureTime = now
 		}
 	}
 
 	// Humidity
-	if currentHumidity != newWirelessThermometerState.SensorData.Humidity {
-		wt.SensorData.Humidity = newWirelessThermometerState.SensorData.Humidity
-		wt.SensorData.LastUpdateTime = now
+	if humidityAccepted && currentHumidity != newHumidity {
 		humidityUpdated = true
 
-		if now.Day() != wt.HighHumidityTime.Day() {
+		if !clock.SameDay(now, wt.HighHumidityTime) {
 			wt.HighHumidity = -1000
 			wt.LowHumidity = 1000
 		}
 
-		if newWirelessThermometerState.SensorData.Humidity > wt.HighHumidity {
-			wt.HighHumidity = newWirelessThermometerState.SensorData.Humidity
+		if newHumidity > wt.HighHumidity {
+			wt.HighHumidity = newHumidity
 			wt.HighHumidityTime = now
 		}
 
-		if newWirelessThermometerState.SensorData.Humidity < wt.LowHumidity {
-			wt.LowHumidity = newWirelessThermometerState.SensorData.Humidity
+		if newHumidity < wt.LowHumidity {
+			wt.LowHumidity = newHumidity
 			wt.LowHumidityTime = now
 		}
 	}
@@ -195,20 +319,25 @@ func (wt *WirelessThermometer) ProcessNewState(newState any, publishEventFunc fu
 		// TODO: Publish BatteryLevelChangedEvent
 	}
 
-	if temperatureUpdated {
+	publishTemperature, publishedTemperature := checkEventGate(
+		wt.temperatureEventGate, temperatureAccepted, temperatureUpdated, newTemperature, currentTemperature, now)
+	publishHumidity, publishedHumidity := checkEventGate(
+		wt.humidityEventGate, humidityAccepted && sensorData.HasHumidity, humidityUpdated, newHumidity, currentHumidity, now)
+
+	if publishTemperature {
 		event := spienvironment.TemperatureChangeEvent{
 			EntityEvent: *getEntityEvent(),
-			NewValue:    newWirelessThermometerState.SensorData.Temperature,
-			OldValue:    currentTemperature,
+			NewValue:    newTemperature,
+			OldValue:    publishedTemperature,
 		}
 		publishEventFunc(wt.PmaasEntityId, event)
 	}
 
-	if humidityUpdated {
+	if publishHumidity {
 		event := spienvironment.HumidityChangeEvent{
 			EntityEvent: *getEntityEvent(),
-			NewValue:    newWirelessThermometerState.SensorData.Humidity,
-			OldValue:    currentHumidity,
+			NewValue:    newHumidity,
+			OldValue:    publishedHumidity,
 		}
 		publishEventFunc(wt.PmaasEntityId, event)
 	}
@@ -219,3 +348,54 @@ func (wt *WirelessThermometer) ProcessNewState(newState any, publishEventFunc fu
 
 	return nil
 }
+
+// applyInputFilter passes a reading through an optional filter.  Returns the value to use and whether the reading was
+// accepted.
+func (wt *WirelessThermometer) applyInputFilter(
+	inputFilter *filter.Filter,
+	stats *filter.Stats,
+	metric string,
+	value float32,
+	now time.Time) (float32, bool) {
+	if inputFilter == nil {
+		return value, true
+	}
+
+	filteredValue, result := inputFilter.Apply(value, now)
+	*stats = inputFilter.Stats()
+
+	if result != filter.Accepted {
+		fmt.Printf("Rejected %s reading %v for %s: %s\n", metric, value, wt.Id, result)
+		return value, false
+	}
+
+	return filteredValue, true
+}
+
+// checkEventGate returns whether a change event should be published for a reading, and the value to report as the old
+// one.  Without a gate, events are published when the value was updated.
+func checkEventGate(
+	gate *deadband.Gate,
+	accepted bool,
+	updated bool,
+	value float32,
+	current float32,
+	now time.Time) (bool, float32) {
+	if gate == nil {
+		return updated, current
+	}
+
+	if !accepted {
+		return false, current
+	}
+
+	return gate.Check(value, now)
+}
+
+func smooth(smoother *smoothing.Smoother, value float32) float32 {
+	if smoother == nil {
+		return value
+	}
+
+	return smoother.Apply(value)
+}
@@ -1,11 +1,18 @@
 package thermometer
 
 import (
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/avanha/pmaas-plugin-environment/clock"
 	data "github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/internal/deadband"
+	"github.com/avanha/pmaas-plugin-environment/internal/filter"
+	"github.com/avanha/pmaas-plugin-environment/internal/smoothing"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
 	"github.com/avanha/pmaas-spi/tracking"
 )
 
@@ -19,7 +26,8 @@ func TestWirelessThermometer_TrackingConfig(t *testing.T) {
 		tracking.Config{
 			TrackingMode:        tracking.ModePoll,
 			PollIntervalSeconds: 10,
-		})
+		},
+		clock.Real)
 
 	expectedTrackingConfig := tracking.Config{
 		TrackingMode:        tracking.ModePoll,
@@ -43,7 +51,8 @@ func TestWirelessThermometer_Data(t *testing.T) {
 		"targetEntityId",
 		"name",
 		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
-		tracking.Config{})
+		tracking.Config{},
+		clock.Real)
 	tm.SensorData.LastUpdateTime = now
 	tm.SensorData.Temperature = 25.0
 
@@ -65,3 +74,257 @@ func TestWirelessThermometer_Data(t *testing.T) {
 		t.Fatalf("expected %+v, got %+v", expectedSensorData, dataSample.Data)
 	}
 }
+
+func TestWirelessThermometer_Data_OmitsUndefinedDewPoint(t *testing.T) {
+	// Arrange
+	tm := CreateWirelessThermometer(1,
+		"targetEntityId",
+		"name",
+		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+		tracking.Config{},
+		clock.Real)
+	tm.SensorData = spienvironment.SensorData{Temperature: 20, HasHumidity: true, Humidity: 0}
+
+	// Act
+	sample := tm.Data().Data.(data.WirelessThermometerData)
+	payload, err := json.Marshal(sample)
+
+	// Assert
+	if sample.DewPoint != nil {
+		t.Fatalf("expected no dew point, got %v", *sample.DewPoint)
+	}
+
+	if err != nil || strings.Contains(string(payload), "DewPoint") || !strings.Contains(string(payload), `"Humidity":0`) {
+		t.Fatalf("unexpected payload %s, error %v", payload, err)
+	}
+}
+
+func TestWirelessThermometer_Data_DewPoint(t *testing.T) {
+	// Arrange
+	tm := CreateWirelessThermometer(1,
+		"targetEntityId",
+		"name",
+		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+		tracking.Config{},
+		clock.Real)
+	tm.SensorData = spienvironment.SensorData{Temperature: 20, HasHumidity: true, Humidity: 50}
+
+	// Act
+	sample := tm.Data().Data.(data.WirelessThermometerData)
+
+	// Assert
+	if sample.DewPoint == nil || *sample.DewPoint < 9.2 || *sample.DewPoint > 9.3 {
+		t.Fatalf("expected a dew point of 9.26, got %v", sample.DewPoint)
+	}
+}
+
+func TestWirelessThermometer_ProcessNewState_RejectsFilteredReadings(t *testing.T) {
+	// Arrange
+	tm := CreateWirelessThermometer(1,
+		"targetEntityId",
+		"name",
+		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+		tracking.Config{},
+		clock.Real)
+	tm.SetInputFilters(filter.Config{Min: -35, Max: 60}, filter.Config{Min: 0, Max: 99.9})
+	events := make([]any, 0)
+	publish := func(pmaasEntityId string, event any) { events = append(events, event) }
+	state := spienvironment.WirelessThermometer{Name: "name"}
+	state.SensorData = spienvironment.SensorData{Temperature: 20, HasHumidity: true, Humidity: 50}
+	_ = tm.ProcessNewState(state, publish)
+	events = events[:0]
+
+	// Act
+	state.SensorData = spienvironment.SensorData{Temperature: -40, HasHumidity: true, Humidity: 100}
+	err := tm.ProcessNewState(state, publish)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if tm.SensorData.Temperature != 20 || tm.LowTemperature != 20 || tm.SensorData.Humidity != 50 {
+		t.Fatalf("expected the rejected readings to be ignored, got %+v", tm.Thermometer)
+	}
+
+	if tm.TemperatureFilterStats.OutOfBounds != 1 || tm.HumidityFilterStats.OutOfBounds != 1 {
+		t.Fatalf("unexpected filter stats %+v %+v", tm.TemperatureFilterStats, tm.HumidityFilterStats)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("expected no events, got %v", events)
+	}
+}
+
+func TestWirelessThermometer_ProcessNewState_UsesSmoothedValues(t *testing.T) {
+	// Arrange
+	tm := CreateWirelessThermometer(1,
+		"targetEntityId",
+		"name",
+		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+		tracking.Config{},
+		clock.Real)
+	tm.SetSmoothing(
+		smoothing.Config{Method: smoothing.MethodExponential, Alpha: 0.5},
+		smoothing.Config{},
+		true)
+	events := make([]any, 0)
+	publish := func(pmaasEntityId string, event any) { events = append(events, event) }
+	state := spienvironment.WirelessThermometer{Name: "name"}
+	state.SensorData = spienvironment.SensorData{Temperature: 20}
+	_ = tm.ProcessNewState(state, publish)
+	events = events[:0]
+
+	// Act
+	state.SensorData = spienvironment.SensorData{Temperature: 22}
+	_ = tm.ProcessNewState(state, publish)
+
+	// Assert
+	if tm.SensorData.Temperature != 22 || tm.SmoothedTemperature != 21 {
+		t.Fatalf("expected raw 22 and smoothed 21, got %v and %v", tm.SensorData.Temperature, tm.SmoothedTemperature)
+	}
+
+	if tm.HighTemperature != 21 || tm.Data().Data.(data.WirelessThermometerData).Temperature != 21 {
+		t.Fatalf("expected the smoothed value to drive the extremes and tracking, got %+v", tm.Thermometer)
+	}
+
+	if len(events) != 1 || events[0].(spienvironment.TemperatureChangeEvent).NewValue != 21 {
+		t.Fatalf("expected a single event with the smoothed value, got %v", events)
+	}
+}
+
+func TestWirelessThermometer_ProcessNewState_AppliesEventDeadband(t *testing.T) {
+	// Arrange
+	tm := CreateWirelessThermometer(1,
+		"targetEntityId",
+		"name",
+		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+		tracking.Config{},
+		clock.Real)
+	tm.SetEventGates(deadband.Config{Absolute: 0.5}, deadband.Config{})
+	events := make([]any, 0)
+	publish := func(pmaasEntityId string, event any) { events = append(events, event) }
+	state := spienvironment.WirelessThermometer{Name: "name"}
+
+	// Act
+	for _, temperature := range []float32{20, 20.1, 20.2, 20.6} {
+		state.SensorData = spienvironment.SensorData{Temperature: temperature}
+		_ = tm.ProcessNewState(state, publish)
+	}
+
+	// Assert
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %v", events)
+	}
+
+	if event := events[1].(spienvironment.TemperatureChangeEvent); event.OldValue != 20 || event.NewValue != 20.6 {
+		t.Fatalf("expected a change from the last published value, got %+v", event)
+	}
+
+	if tm.SensorData.Temperature != 20.6 || tm.HighTemperature != 20.6 {
+		t.Fatalf("expected the state to follow every reading, got %+v", tm.Thermometer)
+	}
+}
+
+func processTemperatures(tm *WirelessThermometer, c *clock.Fake, step time.Duration, temperatures ...float32) {
+	state := spienvironment.WirelessThermometer{Name: "name"}
+
+	for _, temperature := range temperatures {
+		state.SensorData = spienvironment.SensorData{Temperature: temperature}
+		_ = tm.ProcessNewState(state, func(pmaasEntityId string, event any) {})
+		c.Advance(step)
+	}
+}
+
+func TestWirelessThermometer_ProcessNewState_ResetsExtremesAtMidnight(t *testing.T) {
+	// Arrange
+	c := clock.NewFake(time.Date(2024, time.January, 31, 23, 50, 0, 0, time.UTC))
+	tm := CreateWirelessThermometer(1,
+		"targetEntityId",
+		"name",
+		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+		tracking.Config{},
+		c)
+	processTemperatures(tm, c, 5*time.Minute, 10, 30)
+
+	// Act
+	processTemperatures(tm, c, 5*time.Minute, 20)
+
+	// Assert
+	expectedTime := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	if tm.HighTemperature != 20 || tm.LowTemperature != 20 {
+		t.Fatalf("expected the extremes to reset at midnight, got high %v and low %v",
+			tm.HighTemperature, tm.LowTemperature)
+	}
+
+	if !tm.HighTemperatureTime.Equal(expectedTime) || !tm.SensorData.LastUpdateTime.Equal(expectedTime) {
+		t.Fatalf("expected the readings to be timestamped by the clock, got %v", tm.SensorData.LastUpdateTime)
+	}
+}
+
+func TestWirelessThermometer_ProcessNewState_ResetsExtremesOnTheSameDayOfTheNextMonth(t *testing.T) {
+	// Arrange
+	c := clock.NewFake(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	tm := CreateWirelessThermometer(1,
+		"targetEntityId",
+		"name",
+		reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+		tracking.Config{},
+		c)
+	processTemperatures(tm, c, 0, 30)
+	c.Set(time.Date(2024, time.April, 1, 12, 0, 0, 0, time.UTC))
+
+	// Act
+	processTemperatures(tm, c, 0, 20)
+
+	// Assert
+	if tm.HighTemperature != 20 {
+		t.Fatalf("expected the extremes to reset, got high %v", tm.HighTemperature)
+	}
+}
+
+func TestWirelessThermometer_ProcessNewState_KeepsExtremesAcrossDaylightSavingTransitions(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	for _, start := range []time.Time{
+		// Spring forward: 01:59 EST is followed by 03:00 EDT
+		time.Date(2024, time.March, 10, 0, 30, 0, 0, location),
+		// Fall back: 01:59 EDT is followed by 01:00 EST
+		time.Date(2024, time.November, 3, 0, 30, 0, 0, location),
+	} {
+		t.Run(start.Format(time.DateOnly), func(t *testing.T) {
+			// Arrange
+			c := clock.NewFake(start)
+			tm := CreateWirelessThermometer(1,
+				"targetEntityId",
+				"name",
+				reflect.TypeOf((*WirelessThermometer)(nil)).Elem(),
+				tracking.Config{},
+				c)
+
+			// Act, hourly readings through the transition
+			processTemperatures(tm, c, time.Hour, 10, 30, 20, 15)
+
+			// Assert
+			if tm.HighTemperature != 30 || tm.LowTemperature != 10 {
+				t.Fatalf("expected the extremes to be kept, got high %v and low %v",
+					tm.HighTemperature, tm.LowTemperature)
+			}
+
+			// Act, the first reading after local midnight
+			c.Set(time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, location))
+			processTemperatures(tm, c, 0, 25)
+
+			// Assert
+			if tm.HighTemperature != 25 || tm.LowTemperature != 25 {
+				t.Fatalf("expected the extremes to reset at local midnight, got high %v and low %v",
+					tm.HighTemperature, tm.LowTemperature)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package thermostat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/controller"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-plugin-environment/internal/schedule"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	spievents "github.com/avanha/pmaas-spi/events"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type Settings struct {
+	Name               string
+	Inputs             []string
+	Aggregation        controller.Aggregation
+	Mode               entities.ThermostatMode
+	HeatSetpoint       float32
+	CoolSetpoint       float32
+	Deadband           float32
+	MinOnTime          time.Duration
+	MinOffTime         time.Duration
+	HeatSwitchEntityId string
+	CoolSwitchEntityId string
+	Schedule           *schedule.Schedule
+}
+
+func CreateThermostat(
+	instanceId int,
+	settings Settings,
+	switchFn controller.SwitchFunc,
+	trackingConfig tracking.Config) *Thermostat {
+	return &Thermostat{
+		Id:           fmt.Sprintf("Thermostat_%d", instanceId),
+		Name:         settings.Name,
+		Inputs:       settings.Inputs,
+		Aggregation:  settings.Aggregation,
+		Mode:         settings.Mode,
+		HeatSetpoint: settings.HeatSetpoint,
+		CoolSetpoint: settings.CoolSetpoint,
+		Deadband:     settings.Deadband,
+		HeatOutput: controller.CreateSwitchOutput(
+			settings.HeatSwitchEntityId, settings.MinOnTime, settings.MinOffTime, switchFn),
+		CoolOutput: controller.CreateSwitchOutput(
+			settings.CoolSwitchEntityId, settings.MinOnTime, settings.MinOffTime, switchFn),
+		Scheduler:      schedule.CreateScheduler(settings.Schedule),
+		trackingConfig: trackingConfig,
+	}
+}
+
+type Thermostat struct {
+	Id             string
+	PmaasEntityId  string
+	Name           string
+	Inputs         []string
+	Aggregation    controller.Aggregation
+	Mode           entities.ThermostatMode
+	Action         entities.ThermostatAction
+	HeatSetpoint   float32
+	CoolSetpoint   float32
+	Deadband       float32
+	HasTemperature bool
+	Temperature    float32
+	HeatOutput     controller.SwitchOutput
+	CoolOutput     controller.SwitchOutput
+	Scheduler      schedule.Scheduler
+	LastUpdateTime time.Time
+
+	// Display is only populated on state copies that are rendered.
+	Display display.Preferences
+
+	trackingConfig tracking.Config
+	stub           *thermostatStub
+}
+
+// Force implementation of schedule.IScheduled
+var _ schedule.IScheduled = (*Thermostat)(nil)
+
+func (t *Thermostat) GetStub(container spi.IPMAASContainer) entities.Thermostat {
+	if t.stub == nil {
+		t.stub = newThermostatStub(
+			t.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.Thermostat]{
+				Container: container,
+				Entity:    t,
+			})
+	}
+
+	return t.stub
+}
+
+func (t *Thermostat) TrackingConfig() tracking.Config {
+	return t.trackingConfig
+}
+
+func (t *Thermostat) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: t.LastUpdateTime,
+		Data: data.ThermostatData{
+			HasTemperature: t.HasTemperature,
+			Temperature:    t.Temperature,
+			Mode:           int32(t.Mode),
+			Action:         int32(t.Action),
+			HeatSetpoint:   t.HeatSetpoint,
+			CoolSetpoint:   t.CoolSetpoint,
+			LastUpdateTime: t.LastUpdateTime,
+		},
+	}
+}
+
+func (t *Thermostat) GetSortKey() string {
+	return t.Name
+}
+
+func (t *Thermostat) GetState() any {
+	return *t
+}
+
+func (t *Thermostat) entityEvent() spievents.EntityEvent {
+	return spievents.EntityEvent{
+		Id:         t.PmaasEntityId,
+		EntityType: entities.ThermostatType,
+		Name:       t.Name,
+	}
+}
+
+// SetMode changes the operating mode.  The outputs are updated on the next evaluation.
+func (t *Thermostat) SetMode(mode entities.ThermostatMode, publishEventFunc func(pmaasEntityId string, event any)) {
+	if t.Mode == mode {
+		return
+	}
+
+	oldMode := t.Mode
+	t.Mode = mode
+	publishEventFunc(t.PmaasEntityId, entities.ThermostatModeChangeEvent{
+		EntityEvent: t.entityEvent(),
+		NewMode:     mode,
+		OldMode:     oldMode,
+	})
+}
+
+func (t *Thermostat) GetScheduler() *schedule.Scheduler {
+	return &t.Scheduler
+}
+
+// ValidateSetpoints requires the setpoints to be at least one deadband apart, so the thermostat can't heat and cool
+// at the same time in auto mode.
+func (t *Thermostat) ValidateSetpoints(setpoints schedule.Setpoints) error {
+	if setpoints.Cool-setpoints.Heat < t.Deadband {
+		return fmt.Errorf(
+			"cooling setpoint %.1f must be at least %.1f above heating setpoint %.1f",
+			setpoints.Cool, t.Deadband, setpoints.Heat)
+	}
+
+	return nil
+}
+
+// SetSetpoints changes the heating and cooling setpoints.  On a scheduled thermostat, the change is a hold that lasts
+// until the next period starts.
+func (t *Thermostat) SetSetpoints(
+	heatSetpoint float32,
+	coolSetpoint float32,
+	now time.Time,
+	publishEventFunc func(pmaasEntityId string, event any)) error {
+	setpoints := schedule.Setpoints{Heat: heatSetpoint, Cool: coolSetpoint}
+	err := t.ValidateSetpoints(setpoints)
+
+	if err != nil {
+		return err
+	}
+
+	if t.Scheduler.Enabled() {
+		if t.HeatSetpoint == heatSetpoint && t.CoolSetpoint == coolSetpoint {
+			return nil
+		}
+
+		t.Scheduler.HoldUntil(setpoints, time.Time{}, now)
+		t.Scheduler.Evaluate(now)
+	}
+
+	t.applySetpoints(setpoints, publishEventFunc)
+
+	return nil
+}
+
+func (t *Thermostat) applySetpoints(
+	setpoints schedule.Setpoints,
+	publishEventFunc func(pmaasEntityId string, event any)) {
+	if t.HeatSetpoint == setpoints.Heat && t.CoolSetpoint == setpoints.Cool {
+		return
+	}
+
+	event := entities.ThermostatSetpointChangeEvent{
+		EntityEvent:     t.entityEvent(),
+		HeatSetpoint:    setpoints.Heat,
+		CoolSetpoint:    setpoints.Cool,
+		OldHeatSetpoint: t.HeatSetpoint,
+		OldCoolSetpoint: t.CoolSetpoint,
+	}
+	t.HeatSetpoint = setpoints.Heat
+	t.CoolSetpoint = setpoints.Cool
+	publishEventFunc(t.PmaasEntityId, event)
+}
+
+func (t *Thermostat) Evaluate(
+	now time.Time,
+	lookup controller.SensorLookupFunc,
+	publishEventFunc func(pmaasEntityId string, event any)) {
+	t.Temperature, t.HasTemperature = controller.AggregateTemperature(t.Inputs, t.Aggregation, lookup)
+	t.LastUpdateTime = now
+
+	if setpoints, changed := t.Scheduler.Evaluate(now); changed {
+		err := t.ValidateSetpoints(setpoints)
+
+		if err == nil {
+			t.applySetpoints(setpoints, publishEventFunc)
+		} else {
+			fmt.Printf("Thermostat %s: ignoring %s setpoints: %v\n", t.Id, t.Scheduler.Source, err)
+		}
+	}
+
+	desiredAction := entities.ThermostatActionIdle
+
+	if t.HasTemperature {
+		desiredAction = t.desiredAction(t.Temperature)
+	}
+
+	heatOn := desiredAction == entities.ThermostatActionHeating
+	coolOn := desiredAction == entities.ThermostatActionCooling
+
+	// Turn outputs off first, and only turn an output on if the other one is off, so heating and cooling never
+	// overlap, even when an output is held on by its minimum on time.
+	if !heatOn {
+		t.setOutput(&t.HeatOutput, false, now)
+	}
+
+	if !coolOn {
+		t.setOutput(&t.CoolOutput, false, now)
+	}
+
+	if heatOn && !t.CoolOutput.On {
+		t.setOutput(&t.HeatOutput, true, now)
+	}
+
+	if coolOn && !t.HeatOutput.On {
+		t.setOutput(&t.CoolOutput, true, now)
+	}
+
+	currentAction := t.Action
+
+	switch {
+	case t.HeatOutput.On:
+		t.Action = entities.ThermostatActionHeating
+	case t.CoolOutput.On:
+		t.Action = entities.ThermostatActionCooling
+	default:
+		t.Action = entities.ThermostatActionIdle
+	}
+
+	if currentAction != t.Action {
+		publishEventFunc(t.PmaasEntityId, entities.ThermostatActionChangeEvent{
+			EntityEvent: t.entityEvent(),
+			NewAction:   t.Action,
+			OldAction:   currentAction,
+		})
+	}
+}
+
+func (t *Thermostat) setOutput(output *controller.SwitchOutput, on bool, now time.Time) {
+	_, err := output.Set(on, now)
+
+	if err != nil {
+		fmt.Printf("Thermostat %s: unable to switch %s: %v\n", t.Id, output.SwitchEntityId, err)
+	}
+}
+
+// desiredAction applies the deadband, centred on the setpoint, to decide what the thermostat should be doing.
+// The current action determines which edge of the band applies, so the outputs don't chatter around the setpoint.
+func (t *Thermostat) desiredAction(temperature float32) entities.ThermostatAction {
+	halfBand := t.Deadband / 2
+	heatingDemand := func() bool {
+		if t.Action == entities.ThermostatActionHeating {
+			return temperature < t.HeatSetpoint+halfBand
+		}
+
+		return temperature <= t.HeatSetpoint-halfBand
+	}
+	coolingDemand := func() bool {
+		if t.Action == entities.ThermostatActionCooling {
+			return temperature > t.CoolSetpoint-halfBand
+		}
+
+		return temperature >= t.CoolSetpoint+halfBand
+	}
+
+	switch t.Mode {
+	case entities.ThermostatModeHeat:
+		if heatingDemand() {
+			return entities.ThermostatActionHeating
+		}
+	case entities.ThermostatModeCool:
+		if coolingDemand() {
+			return entities.ThermostatActionCooling
+		}
+	case entities.ThermostatModeAuto:
+		if heatingDemand() {
+			return entities.ThermostatActionHeating
+		}
+
+		if coolingDemand() {
+			return entities.ThermostatActionCooling
+		}
+	}
+
+	return entities.ThermostatActionIdle
+}
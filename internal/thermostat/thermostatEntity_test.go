@@ -0,0 +1,129 @@
+package thermostat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type switchCall struct {
+	id string
+	on bool
+}
+
+func createTestThermostat(mode entities.ThermostatMode, calls *[]switchCall) *Thermostat {
+	return CreateThermostat(
+		1,
+		Settings{
+			Name:               "Living Room",
+			Inputs:             []string{"input"},
+			Mode:               mode,
+			HeatSetpoint:       20,
+			CoolSetpoint:       25,
+			Deadband:           1,
+			MinOnTime:          3 * time.Minute,
+			MinOffTime:         5 * time.Minute,
+			HeatSwitchEntityId: "heat",
+			CoolSwitchEntityId: "cool",
+		},
+		func(pmaasEntityId string, on bool) error {
+			*calls = append(*calls, switchCall{id: pmaasEntityId, on: on})
+			return nil
+		},
+		tracking.Config{})
+}
+
+func temperatureLookup(temperature *float32) func(string) (spienvironment.SensorData, bool) {
+	return func(name string) (spienvironment.SensorData, bool) {
+		return spienvironment.SensorData{Temperature: *temperature}, true
+	}
+}
+
+func TestThermostat_Evaluate_HeatsWithinDeadband(t *testing.T) {
+	// Arrange
+	var calls []switchCall
+	var events []any
+	temperature := float32(19.4)
+	tm := createTestThermostat(entities.ThermostatModeHeat, &calls)
+	publish := func(pmaasEntityId string, event any) { events = append(events, event) }
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	tm.Evaluate(start, temperatureLookup(&temperature), publish)
+	actionAfterStart := tm.Action
+	temperature = 20.2
+	tm.Evaluate(start.Add(5*time.Minute), temperatureLookup(&temperature), publish)
+	actionInsideBand := tm.Action
+	temperature = 20.5
+	tm.Evaluate(start.Add(6*time.Minute), temperatureLookup(&temperature), publish)
+
+	// Assert
+	if actionAfterStart != entities.ThermostatActionHeating {
+		t.Fatalf("expected heating below the band, got %v", actionAfterStart)
+	}
+
+	if actionInsideBand != entities.ThermostatActionHeating {
+		t.Fatalf("expected heating to continue inside the band, got %v", actionInsideBand)
+	}
+
+	if tm.Action != entities.ThermostatActionIdle {
+		t.Fatalf("expected idle above the band, got %v", tm.Action)
+	}
+
+	expectedCalls := []switchCall{{id: "heat", on: true}, {id: "heat", on: false}}
+
+	if len(calls) != len(expectedCalls) || calls[0] != expectedCalls[0] || calls[1] != expectedCalls[1] {
+		t.Fatalf("expected switch calls %v, got %v", expectedCalls, calls)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 action change events, got %v", events)
+	}
+}
+
+func TestThermostat_Evaluate_MinimumOnTimeDelaysSwitchOff(t *testing.T) {
+	// Arrange
+	var calls []switchCall
+	temperature := float32(26)
+	tm := createTestThermostat(entities.ThermostatModeAuto, &calls)
+	publish := func(pmaasEntityId string, event any) {}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	tm.Evaluate(start, temperatureLookup(&temperature), publish)
+	temperature = 18
+	tm.Evaluate(start.Add(time.Minute), temperatureLookup(&temperature), publish)
+	actionDuringMinOnTime := tm.Action
+	tm.Evaluate(start.Add(3*time.Minute), temperatureLookup(&temperature), publish)
+	actionAfterMinOnTime := tm.Action
+
+	// Assert
+	if actionDuringMinOnTime != entities.ThermostatActionCooling {
+		t.Fatalf("expected cooling to continue during the minimum on time, got %v", actionDuringMinOnTime)
+	}
+
+	if actionAfterMinOnTime != entities.ThermostatActionHeating {
+		t.Fatalf("expected heating after the minimum on time, got %v", actionAfterMinOnTime)
+	}
+
+	if tm.HeatOutput.On && tm.CoolOutput.On {
+		t.Fatalf("heating and cooling are both on")
+	}
+}
+
+func TestThermostat_SetSetpoints_RejectsOverlap(t *testing.T) {
+	// Arrange
+	var calls []switchCall
+	tm := createTestThermostat(entities.ThermostatModeAuto, &calls)
+
+	// Act
+	err := tm.SetSetpoints(22, 22.5, time.Now(), func(pmaasEntityId string, event any) {})
+
+	// Assert
+	if err == nil {
+		t.Fatalf("expected an error for setpoints closer than the deadband")
+	}
+}
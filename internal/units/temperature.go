@@ -0,0 +1,61 @@
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+type TemperatureUnit string
+
+const (
+	Celsius    TemperatureUnit = "C"
+	Fahrenheit TemperatureUnit = "F"
+	Kelvin     TemperatureUnit = "K"
+)
+
+// ParseTemperatureUnit parses a unit such as "C", "°F", or "kelvin", ignoring case.
+func ParseTemperatureUnit(value string) (TemperatureUnit, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "°") {
+	case "c", "celsius":
+		return Celsius, nil
+	case "f", "fahrenheit":
+		return Fahrenheit, nil
+	case "k", "kelvin":
+		return Kelvin, nil
+	default:
+		return Celsius, fmt.Errorf("unknown temperature unit %q", value)
+	}
+}
+
+// FromCelsius converts a temperature in degrees Celsius to the unit.
+func (u TemperatureUnit) FromCelsius(value float32) float32 {
+	switch u {
+	case Fahrenheit:
+		return value*float32(9)/float32(5) + float32(32)
+	case Kelvin:
+		return value + float32(273.15)
+	default:
+		return value
+	}
+}
+
+// ToCelsius converts a temperature in the unit to degrees Celsius.
+func (u TemperatureUnit) ToCelsius(value float32) float32 {
+	switch u {
+	case Fahrenheit:
+		return (value - float32(32)) * float32(5) / float32(9)
+	case Kelvin:
+		return value - float32(273.15)
+	default:
+		return value
+	}
+}
+
+// Symbol returns the symbol of the unit, for example "°F".
+func (u TemperatureUnit) Symbol() string {
+	if u == Kelvin {
+		return "K"
+	}
+
+	return "°" + string(u)
+}
@@ -0,0 +1,38 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseTemperatureUnit(t *testing.T) {
+	for input, expected := range map[string]TemperatureUnit{
+		"C": Celsius, "°f": Fahrenheit, "Kelvin": Kelvin, " celsius ": Celsius,
+	} {
+		unit, err := ParseTemperatureUnit(input)
+
+		if err != nil || unit != expected {
+			t.Fatalf("ParseTemperatureUnit(%q) = %v, %v, expected %v", input, unit, err, expected)
+		}
+	}
+
+	if _, err := ParseTemperatureUnit("R"); err == nil {
+		t.Fatalf("expected an error for an unknown unit")
+	}
+}
+
+func TestTemperatureUnit_FromCelsius(t *testing.T) {
+	for unit, expected := range map[TemperatureUnit]float64{Celsius: 20, Fahrenheit: 68, Kelvin: 293.15} {
+		if result := unit.FromCelsius(20); math.Abs(float64(result)-expected) > 0.001 {
+			t.Fatalf("%s.FromCelsius(20) = %v, expected %v", unit, result, expected)
+		}
+	}
+}
+
+func TestTemperatureUnit_ToCelsius(t *testing.T) {
+	for unit, value := range map[TemperatureUnit]float32{Celsius: 20, Fahrenheit: 68, Kelvin: 293.15} {
+		if result := unit.ToCelsius(value); math.Abs(float64(result)-20) > 0.001 {
+			t.Fatalf("%s.ToCelsius(%v) = %v, expected 20", unit, value, result)
+		}
+	}
+}
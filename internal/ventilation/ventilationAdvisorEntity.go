@@ -0,0 +1,213 @@
+package ventilation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/controller"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-plugin-environment/internal/psychrometrics"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	spievents "github.com/avanha/pmaas-spi/events"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type Settings struct {
+	Name    string
+	Indoor  string
+	Outdoor string
+
+	// ReduceHumidity and ReduceTemperature select what ventilation should achieve.
+	ReduceHumidity    bool
+	ReduceTemperature bool
+
+	// TargetTemperature is the indoor temperature below which there's no benefit in cooling.
+	TargetTemperature float32
+	HumidityMargin    float32
+	TemperatureMargin float32
+
+	FanSwitchEntityId string
+	MinOnTime         time.Duration
+	MinOffTime        time.Duration
+}
+
+func CreateVentilationAdvisor(
+	instanceId int,
+	settings Settings,
+	switchFn controller.SwitchFunc,
+	trackingConfig tracking.Config) *VentilationAdvisor {
+	return &VentilationAdvisor{
+		Id:                fmt.Sprintf("VentilationAdvisor_%d", instanceId),
+		Name:              settings.Name,
+		Indoor:            settings.Indoor,
+		Outdoor:           settings.Outdoor,
+		ReduceHumidity:    settings.ReduceHumidity,
+		ReduceTemperature: settings.ReduceTemperature,
+		TargetTemperature: settings.TargetTemperature,
+		HumidityMargin:    settings.HumidityMargin,
+		TemperatureMargin: settings.TemperatureMargin,
+		FanOutput: controller.CreateSwitchOutput(
+			settings.FanSwitchEntityId, settings.MinOnTime, settings.MinOffTime, switchFn),
+		trackingConfig: trackingConfig,
+	}
+}
+
+// VentilationAdvisor compares indoor and outdoor conditions to recommend whether ventilating would lower the indoor
+// absolute humidity or temperature.  If a fan switch is configured, it runs the fan while the recommendation is open.
+type VentilationAdvisor struct {
+	Id                      string
+	PmaasEntityId           string
+	Name                    string
+	Indoor                  string
+	Outdoor                 string
+	ReduceHumidity          bool
+	ReduceTemperature       bool
+	TargetTemperature       float32
+	HumidityMargin          float32
+	TemperatureMargin       float32
+	HasInput                bool
+	IndoorTemperature       float32
+	OutdoorTemperature      float32
+	IndoorAbsoluteHumidity  float32
+	OutdoorAbsoluteHumidity float32
+	Recommendation          entities.VentilationRecommendation
+	Reason                  string
+	FanOutput               controller.SwitchOutput
+	LastUpdateTime          time.Time
+
+	// Display is only populated on state copies that are rendered.
+	Display display.Preferences
+
+	trackingConfig tracking.Config
+	stub           *ventilationAdvisorStub
+}
+
+func (v *VentilationAdvisor) GetStub(container spi.IPMAASContainer) entities.VentilationAdvisor {
+	if v.stub == nil {
+		v.stub = newVentilationAdvisorStub(
+			v.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.VentilationAdvisor]{
+				Container: container,
+				Entity:    v,
+			})
+	}
+
+	return v.stub
+}
+
+func (v *VentilationAdvisor) TrackingConfig() tracking.Config {
+	return v.trackingConfig
+}
+
+func (v *VentilationAdvisor) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: v.LastUpdateTime,
+		Data: data.VentilationAdvisorData{
+			HasInput:                v.HasInput,
+			IndoorTemperature:       v.IndoorTemperature,
+			OutdoorTemperature:      v.OutdoorTemperature,
+			IndoorAbsoluteHumidity:  v.IndoorAbsoluteHumidity,
+			OutdoorAbsoluteHumidity: v.OutdoorAbsoluteHumidity,
+			Recommendation:          int32(v.Recommendation),
+			LastUpdateTime:          v.LastUpdateTime,
+		},
+	}
+}
+
+func (v *VentilationAdvisor) GetSortKey() string {
+	return v.Name
+}
+
+func (v *VentilationAdvisor) GetState() any {
+	return *v
+}
+
+func (v *VentilationAdvisor) Evaluate(
+	now time.Time,
+	lookup controller.SensorLookupFunc,
+	publishEventFunc func(pmaasEntityId string, event any)) {
+	v.LastUpdateTime = now
+	indoor, indoorOk := lookup(v.Indoor)
+	outdoor, outdoorOk := lookup(v.Outdoor)
+	v.HasInput = indoorOk && outdoorOk &&
+		(!v.ReduceHumidity || indoor.HasHumidity && outdoor.HasHumidity)
+	recommendation := entities.VentilationRecommendationNeutral
+
+	if v.HasInput {
+		v.IndoorTemperature = indoor.Temperature
+		v.OutdoorTemperature = outdoor.Temperature
+		v.IndoorAbsoluteHumidity = 0
+		v.OutdoorAbsoluteHumidity = 0
+
+		if indoor.HasHumidity && outdoor.HasHumidity {
+			v.IndoorAbsoluteHumidity = psychrometrics.AbsoluteHumidity(indoor.Temperature, indoor.Humidity)
+			v.OutdoorAbsoluteHumidity = psychrometrics.AbsoluteHumidity(outdoor.Temperature, outdoor.Humidity)
+		}
+
+		recommendation, v.Reason = v.recommend()
+	} else {
+		v.Reason = "waiting for data"
+	}
+
+	_, err := v.FanOutput.Set(recommendation == entities.VentilationRecommendationOpen, now)
+
+	if err != nil {
+		fmt.Printf("VentilationAdvisor %s: unable to switch %s: %v\n", v.Id, v.FanOutput.SwitchEntityId, err)
+	}
+
+	if recommendation != v.Recommendation {
+		oldValue := v.Recommendation
+		v.Recommendation = recommendation
+		publishEventFunc(v.PmaasEntityId, entities.VentilationRecommendationChangeEvent{
+			EntityEvent: spievents.EntityEvent{
+				Id:         v.PmaasEntityId,
+				EntityType: entities.VentilationAdvisorType,
+				Name:       v.Name,
+			},
+			NewValue: recommendation,
+			OldValue: oldValue,
+		})
+	}
+}
+
+// recommend weighs the effect of outdoor air on each selected goal.  Ventilation is recommended if it helps at least
+// one goal without hurting another, and discouraged if it only hurts.  Differences within the margins don't count
+// either way.
+func (v *VentilationAdvisor) recommend() (entities.VentilationRecommendation, string) {
+	benefits := make([]string, 0, 2)
+	harms := make([]string, 0, 2)
+
+	if v.ReduceHumidity {
+		difference := v.OutdoorAbsoluteHumidity - v.IndoorAbsoluteHumidity
+
+		if difference < -v.HumidityMargin {
+			benefits = append(benefits, "outdoor air is drier")
+		} else if difference > v.HumidityMargin {
+			harms = append(harms, "outdoor air is more humid")
+		}
+	}
+
+	if v.ReduceTemperature && v.IndoorTemperature > v.TargetTemperature {
+		difference := v.OutdoorTemperature - v.IndoorTemperature
+
+		if difference < -v.TemperatureMargin {
+			benefits = append(benefits, "outdoor air is cooler")
+		} else if difference > v.TemperatureMargin {
+			harms = append(harms, "outdoor air is warmer")
+		}
+	}
+
+	switch {
+	case len(benefits) > 0 && len(harms) == 0:
+		return entities.VentilationRecommendationOpen, benefits[0]
+	case len(harms) > 0 && len(benefits) == 0:
+		return entities.VentilationRecommendationClose, harms[0]
+	case len(harms) > 0:
+		return entities.VentilationRecommendationNeutral, fmt.Sprintf("%s, but %s", benefits[0], harms[0])
+	}
+
+	return entities.VentilationRecommendationNeutral, "no significant difference"
+}
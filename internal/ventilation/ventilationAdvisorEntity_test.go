@@ -0,0 +1,113 @@
+package ventilation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+func createTestAdvisor(calls *[]bool) *VentilationAdvisor {
+	return CreateVentilationAdvisor(
+		1,
+		Settings{
+			Name:              "Basement",
+			Indoor:            "indoor",
+			Outdoor:           "outdoor",
+			ReduceHumidity:    true,
+			ReduceTemperature: true,
+			TargetTemperature: 21,
+			HumidityMargin:    1,
+			TemperatureMargin: 1,
+			FanSwitchEntityId: "fan",
+		},
+		func(pmaasEntityId string, on bool) error {
+			*calls = append(*calls, on)
+			return nil
+		},
+		tracking.Config{})
+}
+
+func lookupOf(readings map[string]spienvironment.SensorData) func(string) (spienvironment.SensorData, bool) {
+	return func(name string) (spienvironment.SensorData, bool) {
+		sensorData, ok := readings[name]
+		return sensorData, ok
+	}
+}
+
+func TestVentilationAdvisor_Evaluate(t *testing.T) {
+	cases := []struct {
+		name     string
+		indoor   spienvironment.SensorData
+		outdoor  spienvironment.SensorData
+		expected entities.VentilationRecommendation
+	}{
+		{
+			// Outdoor air at 15 C and 80% holds about 10.2 g/m3, indoor air at 20 C and 70% about 12.1 g/m3.
+			name:     "cool and drier outside",
+			indoor:   spienvironment.SensorData{Temperature: 20, HasHumidity: true, Humidity: 70},
+			outdoor:  spienvironment.SensorData{Temperature: 15, HasHumidity: true, Humidity: 80},
+			expected: entities.VentilationRecommendationOpen,
+		},
+		{
+			// Despite the higher relative humidity indoors, warm outdoor air holds more water.
+			name:     "warm and humid outside",
+			indoor:   spienvironment.SensorData{Temperature: 20, HasHumidity: true, Humidity: 65},
+			outdoor:  spienvironment.SensorData{Temperature: 28, HasHumidity: true, Humidity: 60},
+			expected: entities.VentilationRecommendationClose,
+		},
+		{
+			name:     "cooler but more humid outside",
+			indoor:   spienvironment.SensorData{Temperature: 26, HasHumidity: true, Humidity: 40},
+			outdoor:  spienvironment.SensorData{Temperature: 22, HasHumidity: true, Humidity: 90},
+			expected: entities.VentilationRecommendationNeutral,
+		},
+	}
+
+	for _, c := range cases {
+		// Arrange
+		var calls []bool
+		var events []any
+		advisor := createTestAdvisor(&calls)
+		lookup := lookupOf(map[string]spienvironment.SensorData{"indoor": c.indoor, "outdoor": c.outdoor})
+
+		// Act
+		advisor.Evaluate(time.Now(), lookup, func(pmaasEntityId string, event any) { events = append(events, event) })
+
+		// Assert
+		if advisor.Recommendation != c.expected {
+			t.Fatalf("%s: expected %v, got %v (%s)", c.name, c.expected, advisor.Recommendation, advisor.Reason)
+		}
+
+		expectFan := c.expected == entities.VentilationRecommendationOpen
+
+		if advisor.FanOutput.On != expectFan || expectFan != (len(calls) == 1) {
+			t.Fatalf("%s: expected fan on=%v, got %v with calls %v", c.name, expectFan, advisor.FanOutput.On, calls)
+		}
+
+		expectEvent := c.expected != entities.VentilationRecommendationNeutral
+
+		if expectEvent != (len(events) == 1) {
+			t.Fatalf("%s: unexpected events %v", c.name, events)
+		}
+	}
+}
+
+func TestVentilationAdvisor_Evaluate_MissingInput(t *testing.T) {
+	// Arrange
+	var calls []bool
+	advisor := createTestAdvisor(&calls)
+	lookup := lookupOf(map[string]spienvironment.SensorData{
+		"indoor": {Temperature: 20, HasHumidity: true, Humidity: 70},
+	})
+
+	// Act
+	advisor.Evaluate(time.Now(), lookup, func(string, any) {})
+
+	// Assert
+	if advisor.HasInput || advisor.Recommendation != entities.VentilationRecommendationNeutral {
+		t.Fatalf("expected a neutral recommendation without outdoor data, got %v", advisor.Recommendation)
+	}
+}
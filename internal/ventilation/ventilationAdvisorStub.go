@@ -0,0 +1,69 @@
+package ventilation
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+type ventilationAdvisorStub struct {
+	id                     string
+	closeFn                func() error
+	entityWrapperReference atomic.Pointer[common.ThreadSafeEntityWrapper[entities.VentilationAdvisor]]
+}
+
+func (s *ventilationAdvisorStub) TrackingConfig() tracking.Config {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.VentilationAdvisor) tracking.Config { return target.TrackingConfig() })
+}
+
+func (s *ventilationAdvisorStub) Data() tracking.DataSample {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.VentilationAdvisor) tracking.DataSample { return target.Data() })
+}
+
+func (s *ventilationAdvisorStub) GetSortKey() string {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target entities.VentilationAdvisor) string { return target.GetSortKey() })
+}
+
+func newVentilationAdvisorStub(
+	id string,
+	entityWrapper *common.ThreadSafeEntityWrapper[entities.VentilationAdvisor]) *ventilationAdvisorStub {
+	instance := &ventilationAdvisorStub{
+		id: id,
+	}
+
+	instance.entityWrapperReference.Store(entityWrapper)
+
+	instance.closeFn = func() error {
+		if instance.entityWrapperReference.CompareAndSwap(entityWrapper, nil) {
+			instance.closeFn = nil
+			return nil
+		}
+
+		return fmt.Errorf("failed to clear entity wrapper, current value does not match expected value")
+	}
+
+	return instance
+}
+
+func (s *ventilationAdvisorStub) close() {
+	closeFn := s.closeFn
+
+	if closeFn == nil {
+		return
+	}
+
+	err := closeFn()
+
+	if err != nil {
+		fmt.Printf("Failed to close ventilationAdvisor stub %s: %v", s.id, err)
+	}
+}
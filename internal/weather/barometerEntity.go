@@ -0,0 +1,94 @@
+package weather
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+func CreateBarometer(instanceId int, name string, trackingConfig tracking.Config) *Barometer {
+	return &Barometer{
+		Id:             fmt.Sprintf("Barometer_%d", instanceId),
+		Name:           name,
+		trackingConfig: trackingConfig,
+	}
+}
+
+// Barometer is the pressure sensor of a weather station.
+type Barometer struct {
+	Id            string
+	PmaasEntityId string
+	Name          string
+
+	// Pressures are in hPa.  The highs and lows are of the current local day.
+	Pressure         float32
+	HighPressure     float32
+	HighPressureTime time.Time
+	LowPressure      float32
+	LowPressureTime  time.Time
+	LastUpdateTime   time.Time
+	trackingConfig   tracking.Config
+	stub             *sensorStub[entities.Barometer]
+}
+
+func (b *Barometer) GetStub(container spi.IPMAASContainer) entities.Barometer {
+	if b.stub == nil {
+		b.stub = newSensorStub(
+			b.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.Barometer]{
+				Container: container,
+				Entity:    b,
+			})
+	}
+
+	return b.stub
+}
+
+func (b *Barometer) TrackingConfig() tracking.Config {
+	return b.trackingConfig
+}
+
+func (b *Barometer) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: b.LastUpdateTime,
+		Data: data.BarometerData{
+			Pressure:       b.Pressure,
+			LastUpdateTime: b.LastUpdateTime,
+		},
+	}
+}
+
+func (b *Barometer) GetSortKey() string {
+	return b.Name
+}
+
+func (b *Barometer) GetState() any {
+	return *b
+}
+
+// Update applies the pressure of an observation.
+func (b *Barometer) Update(observation Observation, now time.Time) {
+	if !observation.HasPressure {
+		return
+	}
+
+	pressure := observation.Pressure
+	b.Pressure = pressure
+	b.LastUpdateTime = now
+
+	if !clock.SameDay(now, b.HighPressureTime) || pressure > b.HighPressure {
+		b.HighPressure = pressure
+		b.HighPressureTime = now
+	}
+
+	if !clock.SameDay(now, b.LowPressureTime) || pressure < b.LowPressure {
+		b.LowPressure = pressure
+		b.LowPressureTime = now
+	}
+}
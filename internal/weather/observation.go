@@ -0,0 +1,214 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type Format int
+
+const (
+	FormatEcowitt Format = iota
+	FormatWunderground
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatEcowitt:
+		return "Ecowitt"
+	case FormatWunderground:
+		return "Weather Underground"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// Observation is an upload of a weather station, converted to metric units.
+type Observation struct {
+	Format    Format
+	StationId string
+
+	// Password is only sent by Weather Underground uploads.
+	Password string
+
+	// Temperature is in °C, humidity in %
+	HasTemperature bool
+	Temperature    float32
+	HasHumidity    bool
+	Humidity       float32
+
+	// Pressure is the relative (sea level) pressure in hPa, or the absolute pressure if the station doesn't send
+	// the relative one.
+	HasPressure bool
+	Pressure    float32
+
+	// Wind speeds are in km/h, and the direction in degrees from north.
+	HasWindSpeed     bool
+	WindSpeed        float32
+	HasWindGust      bool
+	WindGust         float32
+	HasWindDirection bool
+	WindDirection    int
+
+	// The rain rate is in mm/h, and the accumulations in mm.  The hourly accumulation covers the past hour, and the
+	// event accumulation the current rain event.  The daily accumulation resets on the station's schedule.
+	HasRainRate   bool
+	RainRate      float32
+	HasHourlyRain bool
+	HourlyRain    float32
+	HasDailyRain  bool
+	DailyRain     float32
+	HasEventRain  bool
+	EventRain     float32
+
+	// Solar radiation is in W/m².
+	HasUVIndex        bool
+	UVIndex           float32
+	HasSolarRadiation bool
+	SolarRadiation    float32
+}
+
+// HasWind reports whether the observation includes any wind readings.
+func (o Observation) HasWind() bool {
+	return o.HasWindSpeed || o.HasWindGust || o.HasWindDirection
+}
+
+// HasRain reports whether the observation includes any rain readings.
+func (o Observation) HasRain() bool {
+	return o.HasRainRate || o.HasHourlyRain || o.HasDailyRain || o.HasEventRain
+}
+
+// Parse parses the upload of a weather station, in the Ecowitt "customized server" format, recognized by its
+// PASSKEY, or the Weather Underground updateweatherstation format, recognized by its ID.  Both send imperial units.
+func Parse(values url.Values) (Observation, error) {
+	if values.Has("PASSKEY") {
+		return parseEcowitt(values)
+	}
+
+	if values.Has("ID") {
+		return parseWunderground(values)
+	}
+
+	return Observation{}, errors.New("unknown weather station upload, expected an Ecowitt PASSKEY or Weather Underground ID")
+}
+
+func parseEcowitt(values url.Values) (Observation, error) {
+	parser := fieldParser{values: values}
+	observation := Observation{
+		Format:    FormatEcowitt,
+		StationId: values.Get("PASSKEY"),
+	}
+
+	parser.parseCommon(&observation)
+	observation.Pressure, observation.HasPressure = parser.float("baromrelin", inchesOfMercuryToHectopascals)
+
+	if !observation.HasPressure {
+		observation.Pressure, observation.HasPressure = parser.float("baromabsin", inchesOfMercuryToHectopascals)
+	}
+
+	observation.RainRate, observation.HasRainRate = parser.float("rainratein", inchesToMillimeters)
+	observation.HourlyRain, observation.HasHourlyRain = parser.float("hourlyrainin", inchesToMillimeters)
+	observation.EventRain, observation.HasEventRain = parser.float("eventrainin", inchesToMillimeters)
+	observation.UVIndex, observation.HasUVIndex = parser.float("uv", nil)
+
+	return observation, parser.err
+}
+
+func parseWunderground(values url.Values) (Observation, error) {
+	parser := fieldParser{values: values}
+	observation := Observation{
+		Format:    FormatWunderground,
+		StationId: values.Get("ID"),
+		Password:  values.Get("PASSWORD"),
+	}
+
+	parser.parseCommon(&observation)
+	observation.Pressure, observation.HasPressure = parser.float("baromin", inchesOfMercuryToHectopascals)
+
+	// rainin is the rain over the past hour
+	observation.HourlyRain, observation.HasHourlyRain = parser.float("rainin", inchesToMillimeters)
+	observation.UVIndex, observation.HasUVIndex = parser.float("UV", nil)
+
+	return observation, parser.err
+}
+
+// fieldParser parses the fields of an upload, keeping the first error.
+type fieldParser struct {
+	values url.Values
+	err    error
+}
+
+// parseCommon parses the fields that both formats name the same.
+func (p *fieldParser) parseCommon(observation *Observation) {
+	observation.Temperature, observation.HasTemperature = p.float("tempf", fahrenheitToCelsius)
+	observation.Humidity, observation.HasHumidity = p.float("humidity", nil)
+	observation.WindSpeed, observation.HasWindSpeed = p.float("windspeedmph", milesToKilometers)
+	observation.WindGust, observation.HasWindGust = p.float("windgustmph", milesToKilometers)
+	observation.DailyRain, observation.HasDailyRain = p.float("dailyrainin", inchesToMillimeters)
+	observation.SolarRadiation, observation.HasSolarRadiation = p.float("solarradiation", nil)
+
+	direction, hasDirection := p.float("winddir", nil)
+	observation.WindDirection, observation.HasWindDirection = normaliseDirection(direction), hasDirection
+}
+
+// normaliseDirection rounds a direction to whole degrees in the range 0 to 359, so directions like -44 or 400 sent by
+// misbehaving stations still map to a point of the compass.
+func normaliseDirection(direction float32) int {
+	degrees := math.Mod(math.Round(float64(direction)), 360)
+
+	if degrees < 0 {
+		degrees = degrees + 360
+	}
+
+	return int(degrees) % 360
+}
+
+// float parses a field, and converts it with convert unless it's nil.  Missing and empty fields, and the -9999 that
+// stations send for unavailable readings, report false.  NaN and infinite values are errors.
+func (p *fieldParser) float(name string, convert func(float64) float64) (float32, bool) {
+	raw := strings.TrimSpace(p.values.Get(name))
+
+	if raw == "" {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		if p.err == nil {
+			p.err = fmt.Errorf("invalid %s %q", name, raw)
+		}
+
+		return 0, false
+	}
+
+	if value <= -9999 {
+		return 0, false
+	}
+
+	if convert != nil {
+		value = convert(value)
+	}
+
+	return float32(value), true
+}
+
+func fahrenheitToCelsius(value float64) float64 {
+	return (value - 32) * 5 / 9
+}
+
+func inchesOfMercuryToHectopascals(value float64) float64 {
+	return value * 33.8639
+}
+
+func inchesToMillimeters(value float64) float64 {
+	return value * 25.4
+}
+
+func milesToKilometers(value float64) float64 {
+	return value * 1.609344
+}
@@ -0,0 +1,185 @@
+package weather
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+// CreateRainGauge creates a rain gauge whose daily total resets resetTime after local midnight.
+func CreateRainGauge(
+	instanceId int,
+	name string,
+	resetTime time.Duration,
+	trackingConfig tracking.Config,
+	c clock.Clock) *RainGauge {
+	return &RainGauge{
+		Id:             fmt.Sprintf("RainGauge_%d", instanceId),
+		Name:           name,
+		ResetTime:      resetTime,
+		trackingConfig: trackingConfig,
+		clock:          c,
+	}
+}
+
+// RainGauge is the rain sensor of a weather station.
+type RainGauge struct {
+	Id            string
+	PmaasEntityId string
+	Name          string
+
+	// The rate is in mm/h and the accumulations in mm.  The hourly and event accumulations are reported by the
+	// station.
+	HasRate   bool
+	Rate      float32
+	HasHourly bool
+	Hourly    float32
+	HasEvent  bool
+	Event     float32
+
+	// Today is the rain since DayStart, the start of the current rain day, which begins ResetTime after local
+	// midnight.  It's accumulated from the increases of the station's daily counter, so it doesn't depend on when
+	// the station resets it, and HasToday once the station reported it.  The day also rolls over when the state is
+	// read, so a station that stopped reporting doesn't keep showing an old total as today's.  Yesterday is the total
+	// of the previous rain day, if the gauge was updated during it.
+	ResetTime      time.Duration
+	DayStart       time.Time
+	HasToday       bool
+	Today          float32
+	HasYesterday   bool
+	Yesterday      float32
+	MaxRate        float32
+	MaxRateTime    time.Time
+	LastUpdateTime time.Time
+	lastDaily      float32
+	trackingConfig tracking.Config
+	clock          clock.Clock
+	stub           *sensorStub[entities.RainGauge]
+}
+
+func (r *RainGauge) GetStub(container spi.IPMAASContainer) entities.RainGauge {
+	if r.stub == nil {
+		r.stub = newSensorStub(
+			r.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.RainGauge]{
+				Container: container,
+				Entity:    r,
+			})
+	}
+
+	return r.stub
+}
+
+func (r *RainGauge) TrackingConfig() tracking.Config {
+	return r.trackingConfig
+}
+
+func (r *RainGauge) Data() tracking.DataSample {
+	r.startDay(r.clock.Now())
+
+	return tracking.DataSample{
+		LastUpdateTime: r.LastUpdateTime,
+		Data: data.RainData{
+			HasRate:        r.HasRate,
+			Rate:           r.Rate,
+			HasHourly:      r.HasHourly,
+			Hourly:         r.Hourly,
+			HasToday:       r.HasToday,
+			Today:          r.Today,
+			HasEvent:       r.HasEvent,
+			Event:          r.Event,
+			LastUpdateTime: r.LastUpdateTime,
+		},
+	}
+}
+
+func (r *RainGauge) GetSortKey() string {
+	return r.Name
+}
+
+func (r *RainGauge) GetState() any {
+	r.startDay(r.clock.Now())
+
+	return *r
+}
+
+// Update applies the rain readings of an observation.
+func (r *RainGauge) Update(observation Observation, now time.Time) {
+	if !observation.HasRain() {
+		return
+	}
+
+	r.startDay(now)
+
+	if observation.HasRainRate {
+		r.HasRate = true
+		r.Rate = observation.RainRate
+
+		if r.MaxRateTime.IsZero() || r.Rate > r.MaxRate {
+			r.MaxRate = r.Rate
+			r.MaxRateTime = now
+		}
+	}
+
+	if observation.HasHourlyRain {
+		r.HasHourly = true
+		r.Hourly = observation.HourlyRain
+	}
+
+	if observation.HasDailyRain {
+		r.accumulate(observation.DailyRain)
+	}
+
+	if observation.HasEventRain {
+		r.HasEvent = true
+		r.Event = observation.EventRain
+	}
+
+	r.LastUpdateTime = now
+}
+
+// startDay starts a new rain day if now is past the end of the current one.
+func (r *RainGauge) startDay(now time.Time) {
+	dayStart := clock.DayStart(now, r.ResetTime)
+
+	if dayStart.Equal(r.DayStart) {
+		return
+	}
+
+	// Yesterday is only known if the gauge was updated during the previous rain day
+	previousDayStart := clock.DayStart(dayStart.Add(-time.Second), r.ResetTime)
+	r.HasYesterday = r.DayStart.Equal(previousDayStart) && !r.LastUpdateTime.Before(previousDayStart)
+	r.Yesterday = 0
+
+	if r.HasYesterday {
+		r.Yesterday = r.Today
+	}
+
+	r.DayStart = dayStart
+	r.Today = 0
+	r.MaxRate = 0
+	r.MaxRateTime = time.Time{}
+}
+
+// accumulate adds the increase of the station's daily counter to today's total.  A counter that went down was reset
+// by the station, so all of its new value fell since.
+func (r *RainGauge) accumulate(daily float32) {
+	increase := daily
+
+	if r.HasToday && daily >= r.lastDaily {
+		increase = daily - r.lastDaily
+	} else if !r.HasToday && r.ResetTime != 0 {
+		// The station resets its counter at midnight, so the first reading may include rain of the previous rain day
+		increase = 0
+	}
+
+	r.Today = r.Today + increase
+	r.HasToday = true
+	r.lastDaily = daily
+}
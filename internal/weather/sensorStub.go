@@ -0,0 +1,46 @@
+package weather
+
+import (
+	"sync/atomic"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+// sensorStub gives other goroutines access to a weather sensor through the plugin goroutine.  The sensors share
+// their methods, so one stub serves all of them.
+type sensorStub[T entities.WeatherSensor] struct {
+	id                     string
+	entityWrapperReference atomic.Pointer[common.ThreadSafeEntityWrapper[T]]
+}
+
+func newSensorStub[T entities.WeatherSensor](
+	id string,
+	entityWrapper *common.ThreadSafeEntityWrapper[T]) *sensorStub[T] {
+	instance := &sensorStub[T]{
+		id: id,
+	}
+
+	instance.entityWrapperReference.Store(entityWrapper)
+
+	return instance
+}
+
+func (s *sensorStub[T]) TrackingConfig() tracking.Config {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target T) tracking.Config { return target.TrackingConfig() })
+}
+
+func (s *sensorStub[T]) Data() tracking.DataSample {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target T) tracking.DataSample { return target.Data() })
+}
+
+func (s *sensorStub[T]) GetSortKey() string {
+	return common.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target T) string { return target.GetSortKey() })
+}
@@ -0,0 +1,89 @@
+package weather
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+func CreateSolarRadiationSensor(
+	instanceId int,
+	name string,
+	trackingConfig tracking.Config) *SolarRadiationSensor {
+	return &SolarRadiationSensor{
+		Id:             fmt.Sprintf("SolarRadiationSensor_%d", instanceId),
+		Name:           name,
+		trackingConfig: trackingConfig,
+	}
+}
+
+// SolarRadiationSensor is the light sensor of a weather station.
+type SolarRadiationSensor struct {
+	Id            string
+	PmaasEntityId string
+	Name          string
+
+	// Radiation is in W/m².  MaxRadiation is the highest of the current local day.
+	Radiation        float32
+	MaxRadiation     float32
+	MaxRadiationTime time.Time
+	LastUpdateTime   time.Time
+	trackingConfig   tracking.Config
+	stub             *sensorStub[entities.SolarRadiationSensor]
+}
+
+func (s *SolarRadiationSensor) GetStub(container spi.IPMAASContainer) entities.SolarRadiationSensor {
+	if s.stub == nil {
+		s.stub = newSensorStub(
+			s.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.SolarRadiationSensor]{
+				Container: container,
+				Entity:    s,
+			})
+	}
+
+	return s.stub
+}
+
+func (s *SolarRadiationSensor) TrackingConfig() tracking.Config {
+	return s.trackingConfig
+}
+
+func (s *SolarRadiationSensor) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: s.LastUpdateTime,
+		Data: data.SolarRadiationData{
+			Radiation:      s.Radiation,
+			LastUpdateTime: s.LastUpdateTime,
+		},
+	}
+}
+
+func (s *SolarRadiationSensor) GetSortKey() string {
+	return s.Name
+}
+
+func (s *SolarRadiationSensor) GetState() any {
+	return *s
+}
+
+// Update applies the solar radiation of an observation.
+func (s *SolarRadiationSensor) Update(observation Observation, now time.Time) {
+	if !observation.HasSolarRadiation {
+		return
+	}
+
+	s.Radiation = observation.SolarRadiation
+	s.LastUpdateTime = now
+
+	if !clock.SameDay(now, s.MaxRadiationTime) || s.Radiation > s.MaxRadiation {
+		s.MaxRadiation = s.Radiation
+		s.MaxRadiationTime = now
+	}
+}
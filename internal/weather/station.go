@@ -0,0 +1,64 @@
+package weather
+
+import (
+	"strings"
+)
+
+// Station groups the entities created for the uploads of one weather station.  Its sensors are created when the
+// station first reports their readings, so they're nil until then.
+type Station struct {
+	Id   string
+	Name string
+
+	HasOutdoorThermometer bool
+	Barometer             *Barometer
+	Wind                  *WindSensor
+	Rain                  *RainGauge
+	UV                    *UVSensor
+	Solar                 *SolarRadiationSensor
+}
+
+// OutdoorThermometerId returns the source id of the station's outdoor thermometer.
+func (s *Station) OutdoorThermometerId() string {
+	var builder strings.Builder
+	builder.WriteString("weather_")
+
+	for _, r := range s.Id {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune('_')
+		}
+	}
+
+	builder.WriteString("_outdoor")
+
+	return builder.String()
+}
+
+// States returns the states of the station's sensors, other than its outdoor thermometer.
+func (s *Station) States() []any {
+	result := make([]any, 0, 5)
+
+	if s.Barometer != nil {
+		result = append(result, s.Barometer.GetState())
+	}
+
+	if s.Wind != nil {
+		result = append(result, s.Wind.GetState())
+	}
+
+	if s.Rain != nil {
+		result = append(result, s.Rain.GetState())
+	}
+
+	if s.UV != nil {
+		result = append(result, s.UV.GetState())
+	}
+
+	if s.Solar != nil {
+		result = append(result, s.Solar.GetState())
+	}
+
+	return result
+}
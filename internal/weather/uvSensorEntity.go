@@ -0,0 +1,102 @@
+package weather
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+func CreateUVSensor(instanceId int, name string, trackingConfig tracking.Config) *UVSensor {
+	return &UVSensor{
+		Id:             fmt.Sprintf("UVSensor_%d", instanceId),
+		Name:           name,
+		trackingConfig: trackingConfig,
+	}
+}
+
+// UVSensor is the ultraviolet sensor of a weather station.
+type UVSensor struct {
+	Id            string
+	PmaasEntityId string
+	Name          string
+
+	// MaxUVIndex is the highest index of the current local day.
+	UVIndex        float32
+	MaxUVIndex     float32
+	MaxUVIndexTime time.Time
+	LastUpdateTime time.Time
+	trackingConfig tracking.Config
+	stub           *sensorStub[entities.UVSensor]
+}
+
+func (u *UVSensor) GetStub(container spi.IPMAASContainer) entities.UVSensor {
+	if u.stub == nil {
+		u.stub = newSensorStub(
+			u.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.UVSensor]{
+				Container: container,
+				Entity:    u,
+			})
+	}
+
+	return u.stub
+}
+
+func (u *UVSensor) TrackingConfig() tracking.Config {
+	return u.trackingConfig
+}
+
+func (u *UVSensor) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: u.LastUpdateTime,
+		Data: data.UVData{
+			UVIndex:        u.UVIndex,
+			LastUpdateTime: u.LastUpdateTime,
+		},
+	}
+}
+
+func (u *UVSensor) GetSortKey() string {
+	return u.Name
+}
+
+func (u *UVSensor) GetState() any {
+	return *u
+}
+
+// RiskLevel returns the exposure category of the UV index, per the WHO scale.
+func (u *UVSensor) RiskLevel() string {
+	switch {
+	case u.UVIndex < 3:
+		return "Low"
+	case u.UVIndex < 6:
+		return "Moderate"
+	case u.UVIndex < 8:
+		return "High"
+	case u.UVIndex < 11:
+		return "Very High"
+	default:
+		return "Extreme"
+	}
+}
+
+// Update applies the UV index of an observation.
+func (u *UVSensor) Update(observation Observation, now time.Time) {
+	if !observation.HasUVIndex {
+		return
+	}
+
+	u.UVIndex = observation.UVIndex
+	u.LastUpdateTime = now
+
+	if !clock.SameDay(now, u.MaxUVIndexTime) || u.UVIndex > u.MaxUVIndex {
+		u.MaxUVIndex = u.UVIndex
+		u.MaxUVIndexTime = now
+	}
+}
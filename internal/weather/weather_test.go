@@ -0,0 +1,350 @@
+package weather
+
+import (
+	"math"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+func near(a float32, b float32) bool {
+	return math.Abs(float64(a-b)) < 0.01
+}
+
+// An upload of an Ecowitt GW1100 with a WS69 sensor array
+const ecowittUpload = "PASSKEY=0123456789ABCDEF0123456789ABCDEF&stationtype=GW1100A_V2.1.4&runtime=4&" +
+	"dateutc=2024-06-01+12:00:00&tempinf=72.1&humidityin=45&baromrelin=29.921&baromabsin=29.065&tempf=68.0&" +
+	"humidity=60&winddir=225&windspeedmph=6.71&windgustmph=10.29&maxdailygust=15.88&solarradiation=512.34&uv=5&" +
+	"rainratein=0.118&eventrainin=0.051&hourlyrainin=0.039&dailyrainin=0.512&weeklyrainin=0.512&" +
+	"monthlyrainin=1.201&yearlyrainin=12.244&totalrainin=12.244&wh65batt=0&freq=915M&model=GW1100A"
+
+const wundergroundUpload = "ID=KCASANFR123&PASSWORD=secret&action=updateraw&dateutc=now&tempf=-9999&" +
+	"humidity=55&baromin=30.12&winddir=10&windspeedmph=0&rainin=0.02&dailyrainin=0.1&UV=3&softwaretype=test"
+
+func parse(t *testing.T, upload string) (Observation, error) {
+	values, err := url.ParseQuery(upload)
+
+	if err != nil {
+		t.Fatalf("invalid upload %s: %v", upload, err)
+	}
+
+	return Parse(values)
+}
+
+func TestParse_Ecowitt(t *testing.T) {
+	// Act
+	observation, err := parse(t, ecowittUpload)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if observation.Format != FormatEcowitt || observation.StationId != "0123456789ABCDEF0123456789ABCDEF" {
+		t.Fatalf("unexpected station %+v", observation)
+	}
+
+	if !observation.HasTemperature || !near(observation.Temperature, 20) ||
+		!observation.HasHumidity || observation.Humidity != 60 {
+		t.Fatalf("unexpected temperature or humidity %+v", observation)
+	}
+
+	if !observation.HasPressure || !near(observation.Pressure, 1013.25) {
+		t.Fatalf("expected the relative pressure, got %v", observation.Pressure)
+	}
+
+	if !near(observation.WindSpeed, 10.8) || !near(observation.WindGust, 16.56) || observation.WindDirection != 225 {
+		t.Fatalf("unexpected wind %+v", observation)
+	}
+
+	if !near(observation.RainRate, 3) || !near(observation.EventRain, 1.3) || !near(observation.HourlyRain, 0.99) ||
+		!near(observation.DailyRain, 13) {
+		t.Fatalf("unexpected rain %+v", observation)
+	}
+
+	if observation.UVIndex != 5 || !near(observation.SolarRadiation, 512.34) {
+		t.Fatalf("unexpected UV or solar radiation %+v", observation)
+	}
+}
+
+func TestParse_Wunderground(t *testing.T) {
+	// Act
+	observation, err := parse(t, wundergroundUpload)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if observation.Format != FormatWunderground || observation.StationId != "KCASANFR123" ||
+		observation.Password != "secret" {
+		t.Fatalf("unexpected station %+v", observation)
+	}
+
+	if observation.HasTemperature || !observation.HasHumidity {
+		t.Fatalf("expected -9999 to mean no temperature, got %+v", observation)
+	}
+
+	if !near(observation.Pressure, 1019.98) || observation.WindSpeed != 0 || !observation.HasWindSpeed ||
+		!near(observation.HourlyRain, 0.508) || !near(observation.DailyRain, 2.54) || observation.HasRainRate ||
+		observation.UVIndex != 3 {
+		t.Fatalf("unexpected readings %+v", observation)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, upload := range []string{
+		"tempf=68",
+		"PASSKEY=1&tempf=warm",
+		"PASSKEY=1&tempf=NaN",
+		"PASSKEY=1&baromrelin=Inf",
+		"ID=1&windspeedmph=-Inf",
+	} {
+		// Act
+		_, err := parse(t, upload)
+
+		// Assert
+		if err == nil {
+			t.Fatalf("expected an error for %s", upload)
+		}
+	}
+}
+
+func TestParse_IgnoresStationTime(t *testing.T) {
+	// Act
+	observation, err := parse(t, "ID=KCASANFR123&dateutc=yesterday&baromin=30.12")
+
+	// Assert
+	if err != nil || !observation.HasPressure {
+		t.Fatalf("expected the upload to be accepted, got %+v, error %v", observation, err)
+	}
+}
+
+func TestParse_NormalisesWindDirection(t *testing.T) {
+	for query, expected := range map[string]int{"winddir=-44": 316, "winddir=359.6": 0, "winddir=725": 5} {
+		// Act
+		observation, err := parse(t, "ID=1&"+query)
+
+		// Assert
+		if err != nil || !observation.HasWindDirection || observation.WindDirection != expected {
+			t.Fatalf("expected %d for %s, got %+v, error %v", expected, query, observation, err)
+		}
+	}
+}
+
+func TestWindSensor_CompassPoint(t *testing.T) {
+	for direction, expected := range map[int]string{
+		0: "N", 11: "N", 12: "NNE", 225: "SW", 350: "N", 340: "NNW", -43: "NW", -360: "N", 405: "NE",
+	} {
+		// Arrange
+		sensor := CreateWindSensor(1, "Wind", tracking.Config{})
+		sensor.Direction = direction
+
+		// Act
+		result := sensor.CompassPoint()
+
+		// Assert
+		if result != expected {
+			t.Fatalf("expected %s for %d, got %s", expected, direction, result)
+		}
+	}
+}
+
+func TestRainGauge_KeepsLastAccumulations(t *testing.T) {
+	// Arrange
+	gauge := CreateRainGauge(1, "Rain", 0, tracking.Config{}, clock.Real)
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 2, HasEventRain: true, EventRain: 1}, now)
+
+	// Act
+	gauge.Update(Observation{HasRainRate: true, RainRate: 4}, now.Add(time.Minute))
+
+	// Assert
+	if gauge.Today != 2 || gauge.Event != 1 || gauge.Rate != 4 || !gauge.LastUpdateTime.Equal(now.Add(time.Minute)) {
+		t.Fatalf("unexpected gauge %+v", gauge)
+	}
+}
+
+func TestRainGauge_AccumulatesDailyCounterIncreases(t *testing.T) {
+	// Arrange
+	gauge := CreateRainGauge(1, "Rain", 0, tracking.Config{}, clock.Real)
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 2}, now)
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 5}, now.Add(time.Hour))
+
+	// Act, the station resets its counter on its own schedule
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 1}, now.Add(2*time.Hour))
+
+	// Assert
+	if gauge.Today != 6 {
+		t.Fatalf("expected 6 mm today, got %v", gauge.Today)
+	}
+}
+
+func TestRainGauge_ResetsAtConfiguredTime(t *testing.T) {
+	// Arrange
+	location, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// The rain day starts at 07:00, and the station's counter at midnight
+	gauge := CreateRainGauge(1, "Rain", 7*time.Hour, tracking.Config{}, clock.Real)
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 3}, time.Date(2024, time.March, 9, 8, 0, 0, 0, location))
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 5}, time.Date(2024, time.March, 9, 23, 0, 0, 0, location))
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 1}, time.Date(2024, time.March, 10, 3, 0, 0, 0, location))
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 2}, time.Date(2024, time.March, 10, 6, 59, 0, 0, location))
+
+	// Act, 07:00 on the day the clocks spring forward
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 2.5}, time.Date(2024, time.March, 10, 7, 0, 0, 0, location))
+
+	// Assert
+	expectedDayStart := time.Date(2024, time.March, 10, 7, 0, 0, 0, location)
+
+	if !gauge.DayStart.Equal(expectedDayStart) || gauge.Today != 0.5 || !gauge.HasYesterday || gauge.Yesterday != 4 {
+		t.Fatalf("unexpected gauge %+v", gauge)
+	}
+}
+
+func TestRainGauge_YesterdayNeedsPreviousDay(t *testing.T) {
+	// Arrange
+	gauge := CreateRainGauge(1, "Rain", 0, tracking.Config{}, clock.Real)
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 2}, now)
+
+	// Act
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 1}, now.AddDate(0, 0, 2))
+
+	// Assert
+	if gauge.HasYesterday || gauge.Today != 1 {
+		t.Fatalf("unexpected gauge %+v", gauge)
+	}
+}
+
+func TestRainGauge_RollsDayWhenRead(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	gauge := CreateRainGauge(1, "Rain", 0, tracking.Config{}, fakeClock)
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 2}, now)
+	fakeClock.Advance(24 * time.Hour)
+
+	// Act
+	state := gauge.GetState().(RainGauge)
+	sample := gauge.Data().Data.(data.RainData)
+
+	// Assert
+	if state.Today != 0 || !state.HasYesterday || state.Yesterday != 2 {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	if !sample.HasToday || sample.Today != 0 {
+		t.Fatalf("unexpected sample %+v", sample)
+	}
+}
+
+func TestRainGauge_YesterdayNeedsUpdateDuringPreviousDay(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	gauge := CreateRainGauge(1, "Rain", 0, tracking.Config{}, fakeClock)
+	gauge.Update(Observation{HasDailyRain: true, DailyRain: 2}, now)
+
+	// Act, the state is read every day, but the station stopped reporting
+	fakeClock.Advance(24 * time.Hour)
+	gauge.GetState()
+	fakeClock.Advance(24 * time.Hour)
+	state := gauge.GetState().(RainGauge)
+
+	// Assert
+	if state.HasYesterday {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
+
+func TestRainDataToInsertArgs_TodayIsNullable(t *testing.T) {
+	// Arrange
+	var sample any = data.RainData{HasRate: true, Rate: 1}
+
+	// Act
+	args, err := data.RainDataToInsertArgs(&sample)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if args[2] != nil {
+		t.Fatalf("expected today to be null, got %v", args[2])
+	}
+}
+
+func TestWindSensor_MaxGustResetsAtMidnight(t *testing.T) {
+	// Arrange
+	sensor := CreateWindSensor(1, "Wind", tracking.Config{})
+	now := time.Date(2024, time.June, 1, 23, 0, 0, 0, time.UTC)
+	sensor.Update(Observation{HasWindSpeed: true, WindSpeed: 10, HasWindGust: true, WindGust: 40,
+		HasWindDirection: true, WindDirection: 270}, now)
+	sensor.Update(Observation{HasWindSpeed: true, WindSpeed: 5, HasWindGust: true, WindGust: 20}, now.Add(time.Minute))
+
+	if sensor.MaxGust != 40 || sensor.MaxGustCompassPoint() != "W" {
+		t.Fatalf("unexpected sensor %+v", sensor)
+	}
+
+	// Act
+	sensor.Update(Observation{HasWindSpeed: true, WindSpeed: 5, HasWindGust: true, WindGust: 15}, now.Add(time.Hour))
+
+	// Assert
+	if sensor.MaxGust != 15 || !sensor.MaxGustTime.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected sensor %+v", sensor)
+	}
+}
+
+func TestSensors_Data(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	observation := Observation{
+		HasPressure: true, Pressure: 1013, HasWindSpeed: true, WindSpeed: 12, HasDailyRain: true, DailyRain: 3,
+		HasUVIndex: true, UVIndex: 6.5, HasSolarRadiation: true, SolarRadiation: 640,
+	}
+	barometer := CreateBarometer(1, "Barometer", tracking.Config{})
+	wind := CreateWindSensor(2, "Wind", tracking.Config{})
+	rain := CreateRainGauge(3, "Rain", 0, tracking.Config{}, clock.NewFake(now))
+	uv := CreateUVSensor(4, "UV", tracking.Config{})
+	solar := CreateSolarRadiationSensor(5, "Solar", tracking.Config{})
+
+	// Act
+	barometer.Update(observation, now)
+	wind.Update(observation, now)
+	rain.Update(observation, now)
+	uv.Update(observation, now)
+	solar.Update(observation, now)
+
+	// Assert
+	if barometer.Data().Data.(data.BarometerData).Pressure != 1013 {
+		t.Fatalf("unexpected barometer data %+v", barometer.Data())
+	}
+
+	windData := wind.Data().Data.(data.WindData)
+
+	if windData.Speed != 12 || windData.HasGust || windData.MaxGustToday != 12 {
+		t.Fatalf("unexpected wind data %+v", windData)
+	}
+
+	if rain.Data().Data.(data.RainData).Today != 3 || !rain.Data().LastUpdateTime.Equal(now) {
+		t.Fatalf("unexpected rain data %+v", rain.Data())
+	}
+
+	if uv.Data().Data.(data.UVData).UVIndex != 6.5 || uv.RiskLevel() != "High" {
+		t.Fatalf("unexpected UV data %+v", uv.Data())
+	}
+
+	if solar.Data().Data.(data.SolarRadiationData).Radiation != 640 || solar.MaxRadiation != 640 {
+		t.Fatalf("unexpected solar radiation data %+v", solar.Data())
+	}
+}
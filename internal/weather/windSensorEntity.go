@@ -0,0 +1,136 @@
+package weather
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-spi"
+	spicommon "github.com/avanha/pmaas-spi/common"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+var compassPoints = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+func CreateWindSensor(instanceId int, name string, trackingConfig tracking.Config) *WindSensor {
+	return &WindSensor{
+		Id:             fmt.Sprintf("WindSensor_%d", instanceId),
+		Name:           name,
+		trackingConfig: trackingConfig,
+	}
+}
+
+// WindSensor is the anemometer and wind vane of a weather station.
+type WindSensor struct {
+	Id            string
+	PmaasEntityId string
+	Name          string
+
+	// Speeds are in km/h, and the direction in degrees from north.
+	Speed        float32
+	HasGust      bool
+	Gust         float32
+	HasDirection bool
+	Direction    int
+
+	// MaxGust is the strongest gust of the current local day, or the highest speed for sensors without gusts.
+	MaxGust          float32
+	MaxGustTime      time.Time
+	MaxGustDirection int
+	LastUpdateTime   time.Time
+	trackingConfig   tracking.Config
+	stub             *sensorStub[entities.WindSensor]
+}
+
+func (w *WindSensor) GetStub(container spi.IPMAASContainer) entities.WindSensor {
+	if w.stub == nil {
+		w.stub = newSensorStub(
+			w.Id,
+			&spicommon.ThreadSafeEntityWrapper[entities.WindSensor]{
+				Container: container,
+				Entity:    w,
+			})
+	}
+
+	return w.stub
+}
+
+func (w *WindSensor) TrackingConfig() tracking.Config {
+	return w.trackingConfig
+}
+
+func (w *WindSensor) Data() tracking.DataSample {
+	return tracking.DataSample{
+		LastUpdateTime: w.LastUpdateTime,
+		Data: data.WindData{
+			Speed:          w.Speed,
+			HasGust:        w.HasGust,
+			Gust:           w.Gust,
+			HasDirection:   w.HasDirection,
+			Direction:      int32(w.Direction),
+			MaxGustToday:   w.MaxGust,
+			LastUpdateTime: w.LastUpdateTime,
+		},
+	}
+}
+
+func (w *WindSensor) GetSortKey() string {
+	return w.Name
+}
+
+func (w *WindSensor) GetState() any {
+	return *w
+}
+
+// CompassPoint returns the wind direction as one of the 16 points of the compass, like "NNE".
+func (w *WindSensor) CompassPoint() string {
+	return compassPoint(w.Direction)
+}
+
+// MaxGustCompassPoint returns the direction of the strongest gust as a point of the compass.
+func (w *WindSensor) MaxGustCompassPoint() string {
+	return compassPoint(w.MaxGustDirection)
+}
+
+func compassPoint(direction int) string {
+	direction = (direction%360 + 360) % 360
+
+	return compassPoints[((direction*2+22)/45)%16]
+}
+
+// Update applies the wind readings of an observation.
+func (w *WindSensor) Update(observation Observation, now time.Time) {
+	if !observation.HasWind() {
+		return
+	}
+
+	if observation.HasWindSpeed {
+		w.Speed = observation.WindSpeed
+	}
+
+	if observation.HasWindGust {
+		w.HasGust = true
+		w.Gust = observation.WindGust
+	}
+
+	if observation.HasWindDirection {
+		w.HasDirection = true
+		w.Direction = observation.WindDirection
+	}
+
+	w.LastUpdateTime = now
+	gust := w.Speed
+
+	if w.HasGust && w.Gust > gust {
+		gust = w.Gust
+	}
+
+	if !clock.SameDay(now, w.MaxGustTime) || gust > w.MaxGust {
+		w.MaxGust = gust
+		w.MaxGustTime = now
+		w.MaxGustDirection = w.Direction
+	}
+}
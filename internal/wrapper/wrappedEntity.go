@@ -11,13 +11,14 @@ func CreateWrappedEntity(
 	instanceId int,
 	targetEntityId string,
 	name string,
-	entityType reflect.Type) WrappedEntity {
+	entityType reflect.Type,
+	now time.Time) WrappedEntity {
 	return WrappedEntity{
 		Id:             fmt.Sprintf("%s_%d", idPrefix, instanceId),
 		TargetEntityId: targetEntityId,
 		Name:           name,
 		EntityType:     entityType,
-		LastUpdateTime: time.Now(),
+		LastUpdateTime: now,
 	}
 }
 
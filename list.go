@@ -0,0 +1,367 @@
+package environment
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/coldstorage"
+	"github.com/avanha/pmaas-plugin-environment/internal/humidistat"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermostat"
+	"github.com/avanha/pmaas-plugin-environment/internal/ventilation"
+	"github.com/avanha/pmaas-plugin-environment/internal/weather"
+	"github.com/avanha/pmaas-spi"
+)
+
+var ListControlsTemplate = spi.TemplateInfo{
+	Name:    "environment_list_controls",
+	FuncMap: template.FuncMap{},
+	Paths:   []string{"templates/list_controls.htmlt"},
+	Styles:  []string{"css/list_controls.css"},
+}
+
+var listSortFields = []string{"name", "temperature", "humidity", "updated", "battery", "rssi"}
+var listTypes = []string{"thermometer", "thermostat", "humidistat", "ventilation", "coldstorage", "weather"}
+var listStatuses = []string{"online", "offline"}
+
+// ListQuery is the sorting, filtering and search state of the list page, parsed from the query parameters of the
+// same names in lower case.
+type ListQuery struct {
+	Search string
+	Sort   string
+	Order  string
+	Type   string
+	Zone   string
+	Tag    string
+	Status string
+	Alert  bool
+}
+
+// ListControls is rendered as the header of the list page.
+type ListControls struct {
+	ListQuery
+	SortFields []string
+	Types      []string
+	Statuses   []string
+	Zones      []string
+	Tags       []string
+	Total      int
+	Shown      int
+}
+
+// listItemInfo holds the values of a list item that can be sorted and filtered on.  Values that an item doesn't
+// have are left as nil, and sort after the ones that do.
+type listItemInfo struct {
+	item         any
+	name         string
+	itemType     string
+	zone         string
+	tags         []string
+	online       bool
+	alert        bool
+	temperature  *float64
+	humidity     *float64
+	updated      *float64
+	batteryLevel *float64
+	rssi         *float64
+}
+
+func parseListQuery(values url.Values) (ListQuery, error) {
+	query := ListQuery{
+		Search: strings.TrimSpace(values.Get("q")),
+		Sort:   strings.ToLower(values.Get("sort")),
+		Order:  strings.ToLower(values.Get("order")),
+		Type:   strings.ToLower(values.Get("type")),
+		Zone:   values.Get("zone"),
+		Tag:    values.Get("tag"),
+		Status: strings.ToLower(values.Get("status")),
+	}
+
+	if query.Sort == "" {
+		query.Sort = "name"
+	} else if !slices.Contains(listSortFields, query.Sort) {
+		return query, fmt.Errorf("unsupported sort %q", query.Sort)
+	}
+
+	if query.Order == "" {
+		query.Order = "asc"
+	} else if query.Order != "asc" && query.Order != "desc" {
+		return query, fmt.Errorf("unsupported order %q", query.Order)
+	}
+
+	if query.Type != "" && !slices.Contains(listTypes, query.Type) {
+		return query, fmt.Errorf("unsupported type %q", query.Type)
+	}
+
+	if query.Status != "" && !slices.Contains(listStatuses, query.Status) {
+		return query, fmt.Errorf("unsupported status %q", query.Status)
+	}
+
+	switch strings.ToLower(values.Get("alert")) {
+	case "", "0", "false":
+	case "1", "true":
+		query.Alert = true
+	default:
+		return query, fmt.Errorf("unsupported alert %q", values.Get("alert"))
+	}
+
+	return query, nil
+}
+
+// listItemInfoOf extracts the sortable and filterable values of a list item.  Items are pointers to entity states.
+func (p *plugin) listItemInfoOf(item any, now time.Time) listItemInfo {
+	info := listItemInfo{item: item}
+	value := func(v float64) *float64 { return &v }
+
+	switch typedItem := item.(type) {
+	case *thermometer.WirelessThermometer:
+		info.name = typedItem.GetSortKey()
+		info.itemType = "thermometer"
+		info.online = !typedItem.SensorData.IsEmpty() &&
+			now.Sub(typedItem.WrappedEntity.LastUpdateTime) <= p.config.OfflineAfter
+		info.updated = value(float64(typedItem.WrappedEntity.LastUpdateTime.Unix()))
+
+		if !typedItem.SensorData.IsEmpty() {
+			info.temperature = value(float64(typedItem.SensorData.Temperature))
+
+			if typedItem.SensorData.HasHumidity {
+				info.humidity = value(float64(typedItem.SensorData.Humidity))
+			}
+		}
+
+		if !typedItem.BatteryData.IsEmpty() {
+			info.batteryLevel = value(float64(typedItem.BatteryData.Level))
+			info.alert = typedItem.BatteryData.Level <= p.config.LowBatteryLevel
+		}
+
+		if !typedItem.RSSIData.IsEmpty() {
+			info.rssi = value(float64(typedItem.RSSIData.RSSI))
+		}
+
+		info.alert = info.alert || !info.online
+	case *thermostat.Thermostat:
+		info.name = typedItem.GetSortKey()
+		info.itemType = "thermostat"
+		info.online = typedItem.HasTemperature
+		info.alert = !info.online
+		info.updated = value(float64(typedItem.LastUpdateTime.Unix()))
+
+		if typedItem.HasTemperature {
+			info.temperature = value(float64(typedItem.Temperature))
+		}
+	case *humidistat.Humidistat:
+		info.name = typedItem.GetSortKey()
+		info.itemType = "humidistat"
+		info.online = typedItem.HasInput
+		info.alert = !info.online
+		info.updated = value(float64(typedItem.LastUpdateTime.Unix()))
+
+		if typedItem.HasInput {
+			info.temperature = value(float64(typedItem.Temperature))
+			info.humidity = value(float64(typedItem.Humidity))
+		}
+	case *ventilation.VentilationAdvisor:
+		info.name = typedItem.GetSortKey()
+		info.itemType = "ventilation"
+		info.online = typedItem.HasInput
+		info.alert = !info.online
+		info.updated = value(float64(typedItem.LastUpdateTime.Unix()))
+
+		if typedItem.HasInput {
+			info.temperature = value(float64(typedItem.IndoorTemperature))
+		}
+	case *coldstorage.ColdStorageMonitor:
+		info.name = typedItem.GetSortKey()
+		info.itemType = "coldstorage"
+		info.online = typedItem.HasTemperature
+		info.alert = !info.online || !typedItem.InRange || typedItem.DoorOpen
+		info.updated = value(float64(typedItem.LastUpdateTime.Unix()))
+
+		if typedItem.HasTemperature {
+			info.temperature = value(float64(typedItem.Temperature))
+		}
+	case *weather.Barometer:
+		p.setWeatherSensorInfo(&info, typedItem.GetSortKey(), typedItem.LastUpdateTime, now)
+	case *weather.WindSensor:
+		p.setWeatherSensorInfo(&info, typedItem.GetSortKey(), typedItem.LastUpdateTime, now)
+	case *weather.RainGauge:
+		p.setWeatherSensorInfo(&info, typedItem.GetSortKey(), typedItem.LastUpdateTime, now)
+	case *weather.UVSensor:
+		p.setWeatherSensorInfo(&info, typedItem.GetSortKey(), typedItem.LastUpdateTime, now)
+	case *weather.SolarRadiationSensor:
+		p.setWeatherSensorInfo(&info, typedItem.GetSortKey(), typedItem.LastUpdateTime, now)
+	default:
+		info.name = fmt.Sprintf("%v", item)
+	}
+
+	info.zone = p.config.Zones[info.name]
+	info.tags = p.config.Tags[info.name]
+
+	return info
+}
+
+func (p *plugin) setWeatherSensorInfo(info *listItemInfo, name string, lastUpdateTime time.Time, now time.Time) {
+	info.name = name
+	info.itemType = "weather"
+	info.online = now.Sub(lastUpdateTime) <= p.config.OfflineAfter
+	info.alert = !info.online
+	updated := float64(lastUpdateTime.Unix())
+	info.updated = &updated
+}
+
+func (info *listItemInfo) matches(query *ListQuery) bool {
+	if query.Search != "" && !strings.Contains(strings.ToLower(info.name), strings.ToLower(query.Search)) {
+		return false
+	}
+
+	if query.Type != "" && info.itemType != query.Type {
+		return false
+	}
+
+	if query.Zone != "" && !strings.EqualFold(info.zone, query.Zone) {
+		return false
+	}
+
+	if query.Tag != "" && !slices.ContainsFunc(info.tags, func(tag string) bool {
+		return strings.EqualFold(tag, query.Tag)
+	}) {
+		return false
+	}
+
+	if query.Status != "" && info.online != (query.Status == "online") {
+		return false
+	}
+
+	if query.Alert && !info.alert {
+		return false
+	}
+
+	return true
+}
+
+func (info *listItemInfo) sortValue(field string) *float64 {
+	switch field {
+	case "temperature":
+		return info.temperature
+	case "humidity":
+		return info.humidity
+	case "updated":
+		return info.updated
+	case "battery":
+		return info.batteryLevel
+	case "rssi":
+		return info.rssi
+	default:
+		return nil
+	}
+}
+
+// filterAndSortListItems returns the items that match the query, in the requested order.  Items without a value for
+// the sort field are always last, and items with equal values are sorted by name.
+func (p *plugin) filterAndSortListItems(items []any, query *ListQuery, now time.Time) []any {
+	infos := make([]listItemInfo, 0, len(items))
+
+	for _, item := range items {
+		info := p.listItemInfoOf(item, now)
+
+		if info.matches(query) {
+			infos = append(infos, info)
+		}
+	}
+
+	slices.SortStableFunc(infos, func(left listItemInfo, right listItemInfo) int {
+		leftValue, rightValue := left.sortValue(query.Sort), right.sortValue(query.Sort)
+		result := 0
+
+		if leftValue != nil && rightValue != nil {
+			result = cmp.Compare(*leftValue, *rightValue)
+		} else if leftValue != nil {
+			return -1
+		} else if rightValue != nil {
+			return 1
+		}
+
+		if result == 0 {
+			result = cmp.Compare(left.name, right.name)
+		}
+
+		if query.Order == "desc" {
+			return -result
+		}
+
+		return result
+	})
+
+	result := make([]any, len(infos))
+
+	for i := 0; i < len(infos); i = i + 1 {
+		result[i] = infos[i].item
+	}
+
+	return result
+}
+
+func (p *plugin) buildListControls(query ListQuery, total int, shown int) ListControls {
+	controls := ListControls{
+		ListQuery:  query,
+		SortFields: listSortFields,
+		Types:      listTypes,
+		Statuses:   listStatuses,
+		Zones:      make([]string, 0),
+		Tags:       make([]string, 0),
+		Total:      total,
+		Shown:      shown,
+	}
+
+	for _, zone := range p.config.Zones {
+		if !slices.Contains(controls.Zones, zone) {
+			controls.Zones = append(controls.Zones, zone)
+		}
+	}
+
+	for _, tags := range p.config.Tags {
+		for _, tag := range tags {
+			if !slices.Contains(controls.Tags, tag) {
+				controls.Tags = append(controls.Tags, tag)
+			}
+		}
+	}
+
+	slices.Sort(controls.Zones)
+	slices.Sort(controls.Tags)
+
+	return controls
+}
+
+func (p *plugin) listControlsRendererFactory() (spi.EntityRenderer, error) {
+	t, err := p.state.container.GetTemplate(&ListControlsTemplate)
+
+	if err != nil {
+		return spi.EntityRenderer{}, fmt.Errorf("unable to load list_controls template: %v", err)
+	}
+
+	renderer := func(w io.Writer, entity any) error {
+		controls, ok := entity.(*ListControls)
+
+		if !ok {
+			return errors.New("item is not an instance of *ListControls")
+		}
+
+		err := t.Instance.Execute(w, controls)
+
+		if err != nil {
+			return fmt.Errorf("unable to execute list_controls template: %w", err)
+		}
+
+		return nil
+	}
+
+	return spi.EntityRenderer{StreamingRenderFunc: renderer, Styles: t.Styles, Scripts: t.Scripts}, nil
+}
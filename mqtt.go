@@ -0,0 +1,137 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/mqtt"
+)
+
+const mqttOnlinePayload = "online"
+const mqttOfflinePayload = "offline"
+
+func (p *plugin) startMqtt() {
+	mqttConfig := p.config.Mqtt
+
+	if mqttConfig.Broker == "" {
+		return
+	}
+
+	if mqttConfig.QoS > mqtt.MaxQoS {
+		fmt.Printf("%T MQTT QoS %d is not supported, using %d\n", p, mqttConfig.QoS, mqtt.MaxQoS)
+	}
+
+	statusTopic := mqttConfig.TopicPrefix + "/status"
+	options := mqtt.Options{
+		Broker:            mqttConfig.Broker,
+		ClientId:          mqttConfig.ClientId,
+		Username:          mqttConfig.Username,
+		Password:          mqttConfig.Password,
+		KeepAlive:         mqttConfig.KeepAlive,
+		MinReconnectDelay: mqttConfig.MinReconnectDelay,
+		MaxReconnectDelay: mqttConfig.MaxReconnectDelay,
+		WillTopic:         statusTopic,
+		WillPayload:       []byte(mqttOfflinePayload),
+		WillQoS:           mqttConfig.QoS,
+		WillRetain:        true,
+		OnConnect: func(client *mqtt.Client) {
+			err := client.Publish(mqtt.Message{
+				Topic:   statusTopic,
+				Payload: []byte(mqttOnlinePayload),
+				QoS:     mqttConfig.QoS,
+				Retain:  true,
+			})
+
+			if err != nil {
+				fmt.Printf("%T Unable to publish MQTT status: %v\n", p, err)
+			}
+
+			if mqttConfig.HomeAssistantDiscovery {
+				err = p.state.container.EnqueueOnPluginGoRoutine(p.announceAllToHomeAssistant)
+
+				if err != nil {
+					fmt.Printf("%T Unable to enqueue Home Assistant announcement: %v\n", p, err)
+				}
+			}
+		},
+	}
+
+	if mqttConfig.HomeAssistantDiscovery {
+		options.Subscriptions = []string{mqttConfig.DiscoveryPrefix + "/status"}
+		options.SubscriptionQoS = mqttConfig.QoS
+		options.OnMessage = p.onHomeAssistantStatus
+	}
+
+	client := mqtt.NewClient(options)
+	client.Start()
+	p.state.mqttClient = client
+}
+
+func (p *plugin) stopMqtt() {
+	if p.state.mqttClient == nil {
+		return
+	}
+
+	// The client publishes the offline status and disconnects on its own goroutine, so don't wait for it.
+	client := p.state.mqttClient
+	p.state.mqttClient = nil
+	_ = client.Publish(mqtt.Message{
+		Topic:   p.config.Mqtt.TopicPrefix + "/status",
+		Payload: []byte(mqttOfflinePayload),
+		QoS:     p.config.Mqtt.QoS,
+		Retain:  true,
+	})
+	client.Stop()
+}
+
+// publishMqttEvent publishes an event that was broadcast to the container.  Events use the name of the entity
+// they're about, which may differ from the entity's current name for EntityNameChangedEvent.
+func (p *plugin) publishMqttEvent(event any) {
+	if p.state.mqttClient == nil {
+		return
+	}
+
+	eventType, payload, err := mqtt.EventPayload(event)
+
+	if err != nil {
+		fmt.Printf("%T Unable to encode %T for MQTT: %v\n", p, event, err)
+		return
+	}
+
+	var header struct{ Name string }
+	_ = json.Unmarshal(payload, &header)
+	p.publishMqtt(fmt.Sprintf("%s/%s/event/%s",
+		p.config.Mqtt.TopicPrefix, mqtt.TopicLevel(header.Name), eventType), payload, false)
+}
+
+// publishMqttState publishes the tracking data of an entity as its state.  The topic is named after the entity, so
+// entities without a name aren't published.
+func (p *plugin) publishMqttState(entity entities.Thermometer) {
+	if p.state.mqttClient == nil || entity.GetSortKey() == "" {
+		return
+	}
+
+	payload, err := json.Marshal(entity.Data().Data)
+
+	if err != nil {
+		fmt.Printf("%T Unable to encode state of %s for MQTT: %v\n", p, entity.GetSortKey(), err)
+		return
+	}
+
+	p.publishMqtt(fmt.Sprintf("%s/%s/state",
+		p.config.Mqtt.TopicPrefix, mqtt.TopicLevel(entity.GetSortKey())), payload, p.config.Mqtt.RetainState)
+}
+
+func (p *plugin) publishMqtt(topic string, payload []byte, retain bool) {
+	err := p.state.mqttClient.Publish(mqtt.Message{
+		Topic:   topic,
+		Payload: payload,
+		QoS:     p.config.Mqtt.QoS,
+		Retain:  retain,
+	})
+
+	if err != nil {
+		fmt.Printf("%T Unable to publish to MQTT: %v\n", p, err)
+	}
+}
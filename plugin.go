@@ -8,14 +8,24 @@ import (
 	"io"
 	"net/http"
 	"reflect"
-	"sort"
 	"strings"
 	"time"
 
+	"github.com/avanha/pmaas-plugin-environment/clock"
 	"github.com/avanha/pmaas-plugin-environment/data"
 	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/coldstorage"
 	"github.com/avanha/pmaas-plugin-environment/internal/common"
+	"github.com/avanha/pmaas-plugin-environment/internal/display"
+	"github.com/avanha/pmaas-plugin-environment/internal/history"
+	"github.com/avanha/pmaas-plugin-environment/internal/humidistat"
+	"github.com/avanha/pmaas-plugin-environment/internal/influx"
+	"github.com/avanha/pmaas-plugin-environment/internal/mqtt"
+	"github.com/avanha/pmaas-plugin-environment/internal/recording"
 	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermostat"
+	"github.com/avanha/pmaas-plugin-environment/internal/ventilation"
+	"github.com/avanha/pmaas-plugin-environment/internal/weather"
 	environmental "github.com/avanha/pmaas-spi/environment"
 	"github.com/avanha/pmaas-spi/events"
 	"github.com/avanha/pmaas-spi/tracking"
@@ -33,6 +43,7 @@ var WirelessThermometerTemplate = spi.TemplateInfo{
 	FuncMap: template.FuncMap{
 		"CelsiusToFahrenheit": CelsiusToFahrenheit,
 		"RelativeTime":        RelativeTime,
+		"Sparkline":           Sparkline,
 	},
 	Paths:  []string{"templates/wireless_thermometer.htmlt"},
 	Styles: []string{"css/wireless_thermometer.css"},
@@ -41,8 +52,19 @@ var WirelessThermometerTemplate = spi.TemplateInfo{
 type state struct {
 	container            spi.IPMAASContainer
 	entities             map[string]common.IStateTracker
+	controllers          map[string]common.IController
 	entityCounter        int
 	eventReceiverHandles map[string]int
+	stopControllerTimer  func()
+	mqttClient           *mqtt.Client
+	hassAnnouncements    map[string]hassAnnouncement
+	influxWriter         *influx.Writer
+	histories            map[string]*history.History
+	recorder             *recording.Recorder
+	clock                clock.Clock
+	stopSimulator        func()
+	stopRtl433           func()
+	weatherStations      map[string]*weather.Station
 }
 
 func (s *state) nextEntityId() int {
@@ -61,13 +83,24 @@ type Plugin interface {
 
 func NewPlugin(config PluginConfig) Plugin {
 	fmt.Printf("New, config: %v\n", config)
+	var pluginClock clock.Clock = clock.Real
+
+	if config.Clock != nil {
+		pluginClock = config.Clock
+	}
+
 	instance := &plugin{
 		config: config,
 		state: state{
 			container:            nil,
 			entities:             make(map[string]common.IStateTracker),
+			controllers:          make(map[string]common.IController),
 			entityCounter:        0,
 			eventReceiverHandles: make(map[string]int),
+			hassAnnouncements:    make(map[string]hassAnnouncement),
+			histories:            make(map[string]*history.History),
+			clock:                pluginClock,
+			weatherStations:      make(map[string]*weather.Station),
 		},
 	}
 
@@ -82,21 +115,67 @@ func (p *plugin) Init(container spi.IPMAASContainer) {
 	container.ProvideContentFS(&contentFS, "content")
 	container.EnableStaticContent("static")
 	container.AddRoute("/plugins/environment/", p.handleHttpListRequest)
-
+	container.AddRoute("/plugins/environment/thermostat", p.handleHttpThermostatRequest)
+	container.AddRoute("/plugins/environment/humidistat", p.handleHttpHumidistatRequest)
+	container.AddRoute("/plugins/environment/schedule", p.handleHttpScheduleRequest)
+	container.AddRoute("/plugins/environment/coldstorage/log", p.handleHttpColdStorageLogRequest)
+	container.AddRoute("/plugins/environment/export", p.handleHttpExportRequest)
+	container.AddRoute("/plugins/environment/history", p.handleHttpHistoryRequest)
+	container.AddRoute("/plugins/environment/weather", p.handleHttpWeatherRequest)
+	container.AddRoute(entityDetailPath, p.handleHttpEntityRequest)
 }
 
 func (p *plugin) Start() {
 	fmt.Printf("%T Starting...\n", *p)
 	p.state.container.RegisterEntityRenderer(
 		reflect.TypeOf((*thermometer.WirelessThermometer)(nil)).Elem(), p.wirelessThermometerRendererFactory)
-
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*WirelessThermometerDetail)(nil)).Elem(), p.wirelessThermometerDetailRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*thermostat.Thermostat)(nil)).Elem(), p.thermostatRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*humidistat.Humidistat)(nil)).Elem(), p.humidistatRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*ventilation.VentilationAdvisor)(nil)).Elem(), p.ventilationAdvisorRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*coldstorage.ColdStorageMonitor)(nil)).Elem(), p.coldStorageMonitorRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*ListControls)(nil)).Elem(), p.listControlsRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*weather.Barometer)(nil)).Elem(), p.barometerRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*weather.WindSensor)(nil)).Elem(), p.windSensorRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*weather.RainGauge)(nil)).Elem(), p.rainGaugeRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*weather.UVSensor)(nil)).Elem(), p.uvSensorRendererFactory)
+	p.state.container.RegisterEntityRenderer(
+		reflect.TypeOf((*weather.SolarRadiationSensor)(nil)).Elem(), p.solarRadiationSensorRendererFactory)
+
+	schedules := p.buildSchedules()
+	p.createThermostats(schedules)
+	p.createHumidistats(schedules)
+	p.createVentilationAdvisors()
+	p.createColdStorageMonitors()
+	p.startMqtt()
+	p.startInflux()
+	p.startRecording()
 	p.registerEventHandlers()
+	p.startControllerTimer()
+	p.startSimulator()
+	p.startRtl433()
 	// TODO: Retrieve the list of possible entities to add to our map.
 	// Without it, we depend on the plugin ordering to ensure we get any devices in existence prior to our registration.
 }
 
 func (p *plugin) Stop() chan func() {
 	fmt.Printf("%T Stopping...\n", *p)
+	p.stopControllerTimer()
+	p.stopSimulator()
+	p.stopRtl433()
+	p.stopMqtt()
+	p.stopInflux()
+	p.stopRecording()
 
 	return p.state.container.ClosedCallbackChannel()
 }
@@ -143,6 +222,25 @@ func (p *plugin) registerEventHandlers() {
 	}
 
 	p.state.eventReceiverHandles["onEntityStateChange"] = handle
+
+	handle, err = p.state.container.RegisterEventReceiver(
+		func(eventInfo *events.EventInfo) bool {
+			entityDeregisteredEvent, ok := eventInfo.Event.(events.EntityDeregisteredEvent)
+
+			if !ok {
+				return false
+			}
+
+			return isCompatibleEntityType(entityDeregisteredEvent.EntityType)
+		},
+		p.onEntityDeregistered,
+	)
+
+	if err != nil {
+		panic(fmt.Sprintf("Unable to register for entity deregistration events: %v", err))
+	}
+
+	p.state.eventReceiverHandles["onEntityDeregistered"] = handle
 }
 
 var listRenderOptions spi.RenderListOptions = spi.RenderListOptions{
@@ -150,12 +248,26 @@ var listRenderOptions spi.RenderListOptions = spi.RenderListOptions{
 }
 
 func (p *plugin) handleHttpListRequest(w http.ResponseWriter, r *http.Request) {
+	preferences, err := p.displayPreferences(w, r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query, err := parseListQuery(r.URL.Query())
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// First, get the current state of all entities.  HTTP requests come in on arbitrary Go routines,
 	// so execute getEntities on the main plugin Go routine to get all states atomically.
 	resultCh := make(chan []any)
-	err := p.state.container.EnqueueOnPluginGoRoutine(
+	err = p.state.container.EnqueueOnPluginGoRoutine(
 		func() {
-			resultCh <- p.getEntities()
+			resultCh <- p.getEntities(preferences)
 			close(resultCh)
 		})
 	var items []any = nil
@@ -178,6 +290,14 @@ func (p *plugin) handleHttpListRequest(w http.ResponseWriter, r *http.Request) {
 			// This is the type-specific way to get a pointer to a struct.  It should be faster
 			// than the reflection-based approach below.
 			itemRefs[i] = &typedItem
+		case thermostat.Thermostat:
+			itemRefs[i] = &typedItem
+		case humidistat.Humidistat:
+			itemRefs[i] = &typedItem
+		case ventilation.VentilationAdvisor:
+			itemRefs[i] = &typedItem
+		case coldstorage.ColdStorageMonitor:
+			itemRefs[i] = &typedItem
 		default:
 			itemType := reflect.TypeOf(typedItem)
 			itemTypeKind := itemType.Kind()
@@ -195,38 +315,36 @@ func (p *plugin) handleHttpListRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Third, sort the entities using their sort keys
-	sort.Slice(
-		itemRefs,
-		func(i int, j int) bool {
-			leftValue, leftOk := itemRefs[i].(common.ISortable)
-
-			if leftOk {
-				rightValue, rightOk := itemRefs[j].(common.ISortable)
-
-				if rightOk {
-					//fmt.Printf("Comparing %s < %s: %v\n",
-					//	leftValue.GetSortKey(), rightValue.GetSortKey(), leftValue.GetSortKey() < rightValue.GetSortKey())
-					return leftValue.GetSortKey() < rightValue.GetSortKey()
-				}
-			} else {
-				//fmt.Printf("Unable to cast %T (%v) to ISortable\n", itemRefs[i], itemRefs[i])
-			}
-			return true
-		})
+	// Third, filter and sort the entities as requested, by name by default
+	shownItemRefs := p.filterAndSortListItems(itemRefs, &query, p.now())
+	controls := p.buildListControls(query, len(itemRefs), len(shownItemRefs))
+	renderOptions := listRenderOptions
+	renderOptions.Header = &controls
 
 	// Lastly, render the sorted entity list.  The render plugin will choose a matching rendered based on
 	// the entity type.
-	p.state.container.RenderList(w, r, listRenderOptions, itemRefs)
+	p.state.container.RenderList(w, r, renderOptions, shownItemRefs)
 }
 
-func (p *plugin) getEntities() []any {
-	var entityList = make([]any, len(p.state.entities))
+func (p *plugin) getEntities(preferences display.Preferences) []any {
+	var entityList = make([]any, len(p.state.entities)+len(p.state.controllers))
 	i := 0
-	for _, stateTrackingEntity := range p.state.entities {
-		entityList[i] = stateTrackingEntity.GetState()
+	now := p.now()
+	for entityId, stateTrackingEntity := range p.state.entities {
+		entityState := stateTrackingEntity.GetState()
+		if wt, ok := entityState.(thermometer.WirelessThermometer); ok {
+			wt.RecentReadings = p.getHistory(entityId, now.Add(-sparklineDuration), time.Time{}, sparklineStep)
+			wt.Display = preferences
+			entityState = wt
+		}
+		entityList[i] = entityState
+		i = i + 1
+	}
+	for _, controllerEntity := range p.state.controllers {
+		entityList[i] = withDisplayPreferences(controllerEntity.GetState(), preferences)
 		i = i + 1
 	}
+	entityList = append(entityList, p.weatherSensorStates()...)
 	//fmt.Printf("getEntities(), list: %v\n", entityList)
 	return entityList
 }
@@ -258,7 +376,8 @@ func (p *plugin) onEntityRegistered(eventInfo *events.EventInfo) error {
 	}
 
 	instance := thermometer.CreateWirelessThermometer(
-		p.state.nextEntityId(), event.Id, event.Name, entities.WirelessThermometerType, trackingConfig)
+		p.state.nextEntityId(), event.Id, event.Name, entities.WirelessThermometerType, trackingConfig, p.state.clock)
+	p.config.configureThermometer(instance)
 
 	// This lambda captures both the plugin instance and the thermometer instance
 	// and passes it to the entity manager.  However, since entities are deregistered on plugin
@@ -282,6 +401,37 @@ func (p *plugin) onEntityRegistered(eventInfo *events.EventInfo) error {
 	return nil
 }
 
+func (p *plugin) onEntityDeregistered(eventInfo *events.EventInfo) error {
+	fmt.Printf("%T onEntityDeregistered(%v)\n", *p, eventInfo)
+	event := eventInfo.Event.(events.EntityDeregisteredEvent)
+	entity, ok := p.state.entities[event.Id]
+
+	if !ok {
+		return errors.New(fmt.Sprintf("Entity %s is not tracked", event.Id))
+	}
+
+	delete(p.state.entities, event.Id)
+	delete(p.state.histories, event.Id)
+
+	wt, ok := entity.(*thermometer.WirelessThermometer)
+
+	if !ok {
+		return nil
+	}
+
+	p.removeFromHomeAssistant(wt)
+
+	if wt.PmaasEntityId != "" {
+		err := p.state.container.DeregisterEntity(wt.PmaasEntityId)
+
+		if err != nil {
+			return fmt.Errorf("unable to deregister %s: %w", wt.PmaasEntityId, err)
+		}
+	}
+
+	return nil
+}
+
 func buildTrackingName(prefix string, name string) string {
 	result := fmt.Sprintf("%s_%s", prefix, name)
 	result = strings.ReplaceAll(result, " ", "_")
@@ -300,17 +450,41 @@ func (p *plugin) onEntityStateChanged(eventInfo *events.EventInfo) error {
 		return errors.New(fmt.Sprintf("Entity %s is not tracked", event.Id))
 	}
 
-	return entity.ProcessNewState(event.NewState, func(pmassEntityId string, event any) {
-		err := p.state.container.BroadcastEvent(pmassEntityId, event)
-		if err != nil {
-			fmt.Printf("%T Error broadcasting event %v", p, event)
-		}
-	})
+	p.recordStateChange(event)
+	err := entity.ProcessNewState(event.NewState, p.broadcastEvent)
+
+	if err != nil {
+		return err
+	}
+
+	if thermometerEntity, ok := entity.(entities.Thermometer); ok {
+		p.publishMqttState(thermometerEntity)
+	}
+
+	if wt, ok := entity.(*thermometer.WirelessThermometer); ok {
+		p.recordHistory(event.Id, wt)
+		p.announceToHomeAssistant(wt)
+		p.publishHomeAssistantState(wt)
+		p.exportToInflux(wt)
+	}
+
+	p.evaluateControllers()
+
+	return nil
+}
+
+func (p *plugin) broadcastEvent(pmassEntityId string, event any) {
+	err := p.state.container.BroadcastEvent(pmassEntityId, event)
+	if err != nil {
+		fmt.Printf("%T Error broadcasting event %v", p, event)
+	}
+
+	p.publishMqttEvent(event)
 }
 
 func (p *plugin) wirelessThermometerRendererFactory() (spi.EntityRenderer, error) {
 	// Load the template
-	t, err := p.state.container.GetTemplate(&WirelessThermometerTemplate)
+	t, err := p.state.container.GetTemplate(p.withClock(&WirelessThermometerTemplate))
 
 	if err != nil {
 		return spi.EntityRenderer{}, fmt.Errorf("unable to load wireless_thermometer template: %v", err)
@@ -346,8 +520,21 @@ func CelsiusToFahrenheit(celsiusValue float32) float32 {
 	return celsiusValue*float32(9)/float32(5) + float32(32)
 }
 
+// RelativeTime formats the time elapsed since timeValue, like "5m", using the system clock.  The plugin renders its
+// templates with RelativeTimeFunc of PluginConfig.Clock instead.
 func RelativeTime(timeValue time.Time) string {
-	elapsed := time.Now().Sub(timeValue).Truncate(time.Second)
+	return relativeTime(clock.Real.Now(), timeValue)
+}
+
+// RelativeTimeFunc returns a RelativeTime template function that reads the current time from c.
+func RelativeTimeFunc(c Clock) func(time.Time) string {
+	return func(timeValue time.Time) string {
+		return relativeTime(c.Now(), timeValue)
+	}
+}
+
+func relativeTime(now time.Time, timeValue time.Time) string {
+	elapsed := now.Sub(timeValue).Truncate(time.Second)
 
 	if elapsed.Seconds() < 30 {
 		return "< 30s"
@@ -367,3 +554,16 @@ func RelativeTime(timeValue time.Time) string {
 
 	return fmt.Sprintf("%vh", elapsed.Hours())
 }
+
+// FormatDuration formats a duration in hours and minutes, for example "1h05m".
+func FormatDuration(duration time.Duration) string {
+	duration = duration.Truncate(time.Minute)
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) - hours*60
+
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh%02dm", hours, minutes)
+}
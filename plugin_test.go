@@ -0,0 +1,628 @@
+package environment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/recording"
+	"github.com/avanha/pmaas-plugin-environment/internal/schedule"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	"github.com/avanha/pmaas-plugin-environment/plugintest"
+	"github.com/avanha/pmaas-spi/entity"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+// sourceThermometer stands in for a wireless thermometer entity of another plugin.
+type sourceThermometer struct {
+	state spienvironment.WirelessThermometer
+}
+
+func (s *sourceThermometer) GetWirelessThermometerData() spienvironment.WirelessThermometer {
+	return s.state
+}
+
+var sourceThermometerType = reflect.TypeOf((*sourceThermometer)(nil))
+
+func startPlugin(t *testing.T, config PluginConfig) *plugintest.Container {
+	container := plugintest.NewContainer()
+	instance := NewPlugin(config)
+	container.StartPlugin(instance)
+	t.Cleanup(func() {
+		container.StopPlugin(instance)
+		container.Close()
+
+		if errs := container.Errors(); len(errs) != 0 {
+			t.Errorf("unexpected container errors %v", errs)
+		}
+	})
+
+	return container
+}
+
+func addThermometer(container *plugintest.Container, name string, temperature float32) string {
+	id := container.AddEntity(sourceThermometerType, name, &sourceThermometer{})
+	state := spienvironment.WirelessThermometer{Name: name}
+	state.SensorData.Temperature = temperature
+	_ = container.UpdateEntityState(id, state)
+
+	return id
+}
+
+func listThermometerNames(t *testing.T, container *plugintest.Container, target string) []string {
+	response, err := container.Get("/plugins/environment/", target)
+
+	if err != nil || response.Code != http.StatusOK {
+		t.Fatalf("unexpected list response %v %v", response, err)
+	}
+
+	renders := container.Renders()
+	names := make([]string, 0)
+
+	for _, item := range renders[len(renders)-1].Items {
+		if wt, ok := item.(*thermometer.WirelessThermometer); ok {
+			names = append(names, wt.Name)
+		}
+	}
+
+	return names
+}
+
+func TestPlugin_RegistersWirelessThermometers(t *testing.T) {
+	// Arrange
+	container := startPlugin(t, NewPluginConfig())
+
+	// Act
+	container.AddEntity(sourceThermometerType, "Kitchen", &sourceThermometer{})
+	container.Sync()
+
+	// Assert
+	registered, _ := container.GetEntities(func(info *entity.RegisteredEntityInfo) bool {
+		return info.EntityType == entities.WirelessThermometerType
+	})
+
+	if len(registered) != 1 || registered[0].Name != "Kitchen" {
+		t.Fatalf("expected the plugin to register a thermometer, got %v", registered)
+	}
+
+	if errs := container.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestPlugin_ProcessesStateChanges(t *testing.T) {
+	// Arrange
+	container := startPlugin(t, NewPluginConfig())
+
+	// Act
+	addThermometer(container, "Kitchen", 21.5)
+	container.Sync()
+
+	// Assert
+	var changeEvent *spienvironment.TemperatureChangeEvent = nil
+
+	for _, broadcast := range container.Events() {
+		if event, ok := broadcast.Event.(spienvironment.TemperatureChangeEvent); ok {
+			changeEvent = &event
+		}
+	}
+
+	if changeEvent == nil || changeEvent.NewValue != 21.5 || changeEvent.Name != "Kitchen" {
+		t.Fatalf("expected a temperature change event, got %v", changeEvent)
+	}
+
+	response, _ := container.Get("/plugins/environment/", "/plugins/environment/")
+
+	if body := response.Body.String(); !strings.Contains(body, "Kitchen") || !strings.Contains(body, "21.50") {
+		t.Fatalf("expected the thermometer to be rendered, got %s", body)
+	}
+}
+
+func TestPlugin_UsesConfiguredClock(t *testing.T) {
+	// Arrange
+	fakeClock := clock.NewFake(time.Date(2024, time.June, 1, 23, 55, 0, 0, time.UTC))
+	config := NewPluginConfig()
+	config.Clock = fakeClock
+	container := startPlugin(t, config)
+	id := addThermometer(container, "Kitchen", 30)
+	container.Sync()
+
+	// Act
+	fakeClock.Advance(10 * time.Minute)
+	state := spienvironment.WirelessThermometer{Name: "Kitchen"}
+	state.SensorData.Temperature = 20
+	_ = container.UpdateEntityState(id, state)
+	container.Sync()
+	fakeClock.Advance(5 * time.Minute)
+	response, _ := container.Get("/plugins/environment/", "/plugins/environment/")
+
+	// Assert
+	if body := response.Body.String(); !strings.Contains(body, `<span class="value">5m</span>`) {
+		t.Fatalf("expected the relative time by the configured clock, got %s", body)
+	}
+
+	renders := container.Renders()
+	wt := renders[len(renders)-1].Items[0].(*thermometer.WirelessThermometer)
+
+	if wt.HighTemperature != 20 || !wt.HighTemperatureTime.Equal(time.Date(2024, time.June, 2, 0, 5, 0, 0, time.UTC)) {
+		t.Fatalf("expected the extremes to reset after midnight, got %v at %v", wt.HighTemperature, wt.HighTemperatureTime)
+	}
+}
+
+func TestPlugin_DisplayCookiesExpireByConfiguredClock(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	config := NewPluginConfig()
+	config.Clock = clock.NewFake(now)
+	container := startPlugin(t, config)
+
+	// Act
+	response, _ := container.Get("/plugins/environment/", "/plugins/environment/?unit=F&save=1")
+
+	// Assert
+	cookies := response.Result().Cookies()
+
+	if len(cookies) != 1 || !cookies[0].Expires.Equal(now.AddDate(1, 0, 0)) {
+		t.Fatalf("expected a cookie expiring a year after the configured time, got %v", cookies)
+	}
+}
+
+func TestPlugin_ListSortsAndFilters(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.SetZone("Upstairs", "Bedroom")
+	container := startPlugin(t, config)
+	addThermometer(container, "Bedroom", 19)
+	addThermometer(container, "Kitchen", 23)
+	addThermometer(container, "Attic", 27)
+	container.Sync()
+
+	// Act
+	byName := listThermometerNames(t, container, "/plugins/environment/")
+	byTemperature := listThermometerNames(t, container, "/plugins/environment/?sort=temperature&order=desc")
+	searched := listThermometerNames(t, container, "/plugins/environment/?q=kit")
+	zoned := listThermometerNames(t, container, "/plugins/environment/?zone=Upstairs")
+
+	// Assert
+	if strings.Join(byName, ",") != "Attic,Bedroom,Kitchen" {
+		t.Fatalf("expected sorting by name, got %v", byName)
+	}
+
+	if strings.Join(byTemperature, ",") != "Attic,Kitchen,Bedroom" {
+		t.Fatalf("expected sorting by temperature, got %v", byTemperature)
+	}
+
+	if strings.Join(searched, ",") != "Kitchen" {
+		t.Fatalf("expected the search to match Kitchen, got %v", searched)
+	}
+
+	if strings.Join(zoned, ",") != "Bedroom" {
+		t.Fatalf("expected the zone filter to match Bedroom, got %v", zoned)
+	}
+}
+
+func TestPlugin_ListRejectsInvalidQuery(t *testing.T) {
+	// Arrange
+	container := startPlugin(t, NewPluginConfig())
+
+	// Act
+	response, _ := container.Get("/plugins/environment/", "/plugins/environment/?sort=colour")
+
+	// Assert
+	if response.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, response.Code)
+	}
+}
+
+func TestPlugin_DeregistersWirelessThermometers(t *testing.T) {
+	// Arrange
+	container := startPlugin(t, NewPluginConfig())
+	id := addThermometer(container, "Kitchen", 21.5)
+	container.Sync()
+
+	// Act
+	_ = container.RemoveEntity(id)
+	container.Sync()
+
+	// Assert
+	if names := listThermometerNames(t, container, "/plugins/environment/"); len(names) != 0 {
+		t.Fatalf("expected no thermometers, got %v", names)
+	}
+
+	registered, _ := container.GetEntities(func(info *entity.RegisteredEntityInfo) bool { return true })
+
+	if len(registered) != 0 {
+		t.Fatalf("expected the plugin's thermometer to be deregistered, got %v", registered)
+	}
+}
+
+func TestPlugin_RecordsStateChanges(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.RecordingPath = filepath.Join(t.TempDir(), "recording.jsonl")
+	container := plugintest.NewContainer()
+	defer container.Close()
+	instance := NewPlugin(config)
+	container.StartPlugin(instance)
+
+	// Act
+	addThermometer(container, "Kitchen", 21.5)
+	container.Sync()
+	container.StopPlugin(instance)
+
+	// Assert
+	entries, err := recording.ReadFile(config.RecordingPath)
+
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected a recorded entry, got %v %v", entries, err)
+	}
+
+	if entries[0].Name != "Kitchen" || entries[0].State.SensorData.Temperature != 21.5 {
+		t.Fatalf("unexpected entry %+v", entries[0])
+	}
+
+	result := recording.Replay(entries, recording.ReplayOptions{})
+
+	if wt := result.Thermometers[entries[0].EntityId]; wt.SensorData.Temperature != 21.5 {
+		t.Fatalf("expected the replay to reproduce the state, got %+v", wt.SensorData)
+	}
+}
+
+func TestPlugin_SimulatorRegistersSyntheticThermometers(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.Clock = clock.NewFake(time.Date(2024, time.June, 1, 15, 0, 0, 0, time.UTC))
+	config.Simulator.Count = 3
+	config.Simulator.Seed = 1
+	config.Simulator.DropoutRate = 0
+
+	// Act
+	container := startPlugin(t, config)
+	container.Sync()
+
+	// Assert
+	names := listThermometerNames(t, container, "/plugins/environment/?sort=name")
+
+	if strings.Join(names, ",") != "Simulated 1,Simulated 2,Simulated 3" {
+		t.Fatalf("unexpected thermometers %v", names)
+	}
+
+	renders := container.Renders()
+
+	for _, item := range renders[len(renders)-1].Items {
+		if wt, ok := item.(*thermometer.WirelessThermometer); ok && (wt.SensorData.IsEmpty() || wt.BatteryData.IsEmpty()) {
+			t.Fatalf("expected %s to have readings, got %+v", wt.Name, wt)
+		}
+	}
+
+	if errs := container.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestPlugin_IngestsRtl433Messages(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "rtl_433.json")
+	content := `{"model" : "Acurite-Tower", "id" : 11111, "channel" : "A", "battery_ok" : 1, "temperature_C" : 21.4, ` +
+		`"humidity" : 48}` + "\n" +
+		`{"model" : "Acurite-Tower", "id" : 11111, "channel" : "A", "temperature_C" : 21.9}` + "\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+
+	config := NewPluginConfig()
+	config.Rtl433.AddInput(path)
+	config.Rtl433.SetName("Acurite-Tower/11111/A", "Garden")
+
+	// Act
+	container := startPlugin(t, config)
+	var wt *thermometer.WirelessThermometer = nil
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		container.Sync()
+		_, _ = container.Get("/plugins/environment/", "/plugins/environment/")
+		renders := container.Renders()
+
+		if items := renders[len(renders)-1].Items; len(items) == 1 {
+			wt = items[0].(*thermometer.WirelessThermometer)
+
+			if wt.SensorData.Temperature == 21.9 {
+				break
+			}
+		}
+	}
+
+	// Assert
+	if wt == nil || wt.Name != "Garden" || wt.SensorData.Temperature != 21.9 || wt.SensorData.Humidity != 48 ||
+		wt.BatteryData.Level != 100 {
+		t.Fatalf("expected the rtl_433 thermometer, got %+v", wt)
+	}
+}
+
+func TestPlugin_StopsReadingRtl433StandardInput(t *testing.T) {
+	// Arrange
+	reader, writer, err := os.Pipe()
+
+	if err != nil {
+		t.Fatalf("unable to create pipe: %v", err)
+	}
+
+	defer reader.Close()
+	defer writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+	config := NewPluginConfig()
+	config.Rtl433.AddInput("-")
+	container := plugintest.NewContainer()
+	defer container.Close()
+	instance := NewPlugin(config)
+	container.StartPlugin(instance)
+	stopped := make(chan struct{})
+
+	// Act
+	go func() {
+		container.StopPlugin(instance)
+		close(stopped)
+	}()
+
+	// Assert
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the plugin to stop while standard input is open")
+	}
+
+	if errs := container.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected container errors %v", errs)
+	}
+}
+
+func TestPlugin_AlertsOnRtl433DeviceFirstReportingLowBattery(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "rtl_433.json")
+	content := `{"model" : "Acurite-Tower", "id" : 22222, "channel" : "B", "battery_ok" : 0, "temperature_C" : 5.0}` + "\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+
+	config := NewPluginConfig()
+	config.Rtl433.AddInput(path)
+	config.Rtl433.SetName("Acurite-Tower/22222/B", "Shed")
+
+	// Act
+	container := startPlugin(t, config)
+	names := make([]string, 0)
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		container.Sync()
+
+		if names = listThermometerNames(t, container, "/plugins/environment/?alert=1"); len(names) > 0 {
+			break
+		}
+	}
+
+	// Assert
+	if strings.Join(names, ",") != "Shed" {
+		t.Fatalf("expected the low battery to be an alert, got %v", names)
+	}
+}
+
+func TestPlugin_IngestsWeatherStationUploads(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.WeatherStation.Names["ABCDEF"] = "Garden"
+	container := startPlugin(t, config)
+	form := "PASSKEY=ABCDEF&stationtype=GW1100A&dateutc=2024-06-01+12:00:00&tempf=68.0&humidity=60&" +
+		"baromrelin=29.921&winddir=225&windspeedmph=6.71&windgustmph=10.29&rainratein=0.118&dailyrainin=0.512"
+	request := httptest.NewRequest(http.MethodPost, "/plugins/environment/weather", strings.NewReader(form))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// Act
+	response, _ := container.Do("/plugins/environment/weather", request)
+	container.Sync()
+
+	// Assert
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected response %v %s", response.Code, response.Body.String())
+	}
+
+	names := listThermometerNames(t, container, "/plugins/environment/?type=thermometer")
+
+	if strings.Join(names, ",") != "Garden Outdoor" {
+		t.Fatalf("expected the outdoor thermometer, got %v", names)
+	}
+
+	listResponse, _ := container.Get("/plugins/environment/", "/plugins/environment/?type=weather")
+	body := listResponse.Body.String()
+
+	for _, expected := range []string{"Garden Barometer", "1013.2 hPa", "Garden Wind", "10.8 km/h", "SW",
+		"Garden Rain", "3.0 mm/h", "13.0 mm"} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("expected %q to be rendered, got %s", expected, body)
+		}
+	}
+
+	for _, entityType := range []reflect.Type{entities.BarometerType, entities.WindSensorType, entities.RainGaugeType} {
+		registered, _ := container.GetEntities(func(info *entity.RegisteredEntityInfo) bool {
+			return info.EntityType == entityType
+		})
+
+		if len(registered) != 1 {
+			t.Fatalf("expected a registered %v, got %v", entityType, registered)
+		}
+	}
+}
+
+func TestPlugin_ChecksWeatherUndergroundPassword(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.WeatherStation.Password = "secret"
+	container := startPlugin(t, config)
+	upload := "/plugins/environment/weather?ID=KCASANFR123&action=updateraw&dateutc=now&baromin=30.12&PASSWORD="
+
+	// Act
+	rejected, _ := container.Get("/plugins/environment/weather", upload+"wrong")
+	accepted, _ := container.Get("/plugins/environment/weather", upload+"secret")
+	container.Sync()
+
+	// Assert
+	if rejected.Code != http.StatusForbidden {
+		t.Fatalf("expected the wrong password to be rejected, got %v", rejected.Code)
+	}
+
+	if accepted.Code != http.StatusOK || strings.TrimSpace(accepted.Body.String()) != "success" {
+		t.Fatalf("unexpected response %v %s", accepted.Code, accepted.Body.String())
+	}
+
+	listResponse, _ := container.Get("/plugins/environment/", "/plugins/environment/?type=weather")
+
+	if body := listResponse.Body.String(); !strings.Contains(body, "Weather Station Barometer") ||
+		!strings.Contains(body, "1020.0 hPa") {
+		t.Fatalf("expected the barometer to be rendered, got %s", body)
+	}
+}
+
+func TestPlugin_IngestsWeatherStationUVAndSolarRadiation(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	container := startPlugin(t, config)
+	upload := "/plugins/environment/weather?ID=KCASANFR123&action=updateraw&dateutc=now&UV=6&solarradiation=640.4"
+
+	// Act
+	response, _ := container.Get("/plugins/environment/weather", upload)
+	container.Sync()
+
+	// Assert
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected response %v %s", response.Code, response.Body.String())
+	}
+
+	listResponse, _ := container.Get("/plugins/environment/", "/plugins/environment/?type=weather")
+	body := listResponse.Body.String()
+
+	for _, expected := range []string{"Weather Station UV", "UV 6.0", "High", "Weather Station Solar Radiation",
+		"640 W/m²"} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("expected %q to be rendered, got %s", expected, body)
+		}
+	}
+
+	for _, entityType := range []reflect.Type{entities.UVSensorType, entities.SolarRadiationSensorType} {
+		registered, _ := container.GetEntities(func(info *entity.RegisteredEntityInfo) bool {
+			return info.EntityType == entityType
+		})
+
+		if len(registered) != 1 {
+			t.Fatalf("expected a registered %v, got %v", entityType, registered)
+		}
+	}
+}
+
+// scheduledController is a minimal schedule.IScheduled for exercising schedule actions.
+type scheduledController struct {
+	scheduler schedule.Scheduler
+}
+
+func (c *scheduledController) GetScheduler() *schedule.Scheduler {
+	return &c.scheduler
+}
+
+func (c *scheduledController) ValidateSetpoints(setpoints schedule.Setpoints) error {
+	return nil
+}
+
+func TestApplyScheduleAction_ParsesTimesInTheClockLocation(t *testing.T) {
+	// Arrange
+	location := time.FixedZone("UTC-5", -5*60*60)
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, location)
+	cases := []struct {
+		until         string
+		expectedError bool
+	}{
+		{"2024-06-01T13:00", false},
+		{"2024-06-01T11:30", true},
+		{"2024-06-01T12:00", true},
+	}
+
+	for _, c := range cases {
+		controller := &scheduledController{
+			scheduler: schedule.CreateScheduler(schedule.CreateSchedule("test", nil, nil, nil)),
+		}
+		request := httptest.NewRequest(http.MethodPost, "/plugins/environment/schedule",
+			strings.NewReader("action=hold&heat=20&until="+c.until))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		_ = request.ParseForm()
+
+		// Act
+		err := applyScheduleAction(controller, "hold", request, now)
+
+		// Assert
+		if (err != nil) != c.expectedError {
+			t.Fatalf("until %s: expected error %v, got %v", c.until, c.expectedError, err)
+		}
+
+		expectedUntil := time.Date(2024, time.June, 1, 13, 0, 0, 0, location)
+
+		if err == nil && !controller.scheduler.Hold.Until.Equal(expectedUntil) {
+			t.Fatalf("until %s: expected the hold to end at %v, got %v", c.until, expectedUntil,
+				controller.scheduler.Hold.Until)
+		}
+	}
+}
+
+func TestPlugin_HomeAssistantDeviceKeepsStateTopicOnRename(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.Mqtt = NewMqttConfig("localhost:1883")
+	p := NewPlugin(config).(*plugin)
+	wt := thermometer.CreateWirelessThermometer(
+		1, "source_1", "Kitchen", entities.WirelessThermometerType, tracking.Config{}, clock.Real)
+	before := p.homeAssistantDevice(wt)
+
+	// Act
+	wt.Name = ""
+	after := p.homeAssistantDevice(wt)
+
+	// Assert
+	if after.StateTopic != before.StateTopic || after.NodeId != before.NodeId ||
+		after.StateTopic != "pmaas/environment/device/pmaas_environment_source_1/state" {
+		t.Fatalf("expected a stable state topic, got %s and %s", before.StateTopic, after.StateTopic)
+	}
+
+	if after == before {
+		t.Fatalf("expected the renamed device to be announced again")
+	}
+}
+
+func TestPlugin_ThermostatCardUsesDisplayPreferences(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.Display.TemperatureUnit = "F"
+	config.Display.Decimals = 1
+	config.AddThermostat(NewThermostatConfig("Hall", "Kitchen"))
+	container := startPlugin(t, config)
+	addThermometer(container, "Kitchen", 20)
+	container.Sync()
+
+	// Act
+	response, _ := container.Get("/plugins/environment/", "/plugins/environment/")
+
+	// Assert
+	body := response.Body.String()
+
+	if !strings.Contains(body, `<div class="temperature">68.0 °F</div>`) ||
+		!strings.Contains(body, `name="heat" step="0.5"`) || !strings.Contains(body, `value="68.0"`) {
+		t.Fatalf("expected the thermostat in degrees Fahrenheit, got %s", body)
+	}
+}
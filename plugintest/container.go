@@ -0,0 +1,697 @@
+// Package plugintest provides an in-memory spi.IPMAASContainer for end-to-end tests of PMAAS plugins.
+//
+// The container runs a single goroutine that stands in for the plugin goroutine.  Enqueued functions, event receivers
+// and entity invocations all run on it, in order.  Events are delivered asynchronously, like in the server, so call
+// Sync to wait for them to be processed before asserting.
+package plugintest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/avanha/pmaas-spi"
+	"github.com/avanha/pmaas-spi/entity"
+	"github.com/avanha/pmaas-spi/events"
+)
+
+// RenderedList records a call to RenderList.
+type RenderedList struct {
+	Options spi.RenderListOptions
+	Items   []any
+
+	// Body is the rendered HTML of the header and items, using the registered renderers.
+	Body string
+}
+
+// BroadcastEvent records a call to BroadcastEvent.
+type BroadcastEvent struct {
+	SourceEntityId string
+	Event          any
+}
+
+type registeredEntity struct {
+	info       entity.RegisteredEntityInfo
+	uniqueData string
+
+	// target is passed to InvokeOnEntity functions.
+	target any
+}
+
+type eventReceiver struct {
+	predicate events.EventPredicate
+	receiver  events.EventReceiver
+}
+
+// Container is an in-memory spi.IPMAASContainer.  Create instances with NewContainer.
+type Container struct {
+	mutex         sync.Mutex
+	queue         []func()
+	wake          chan struct{}
+	stop          chan struct{}
+	done          chan struct{}
+	stopOnce      sync.Once
+	routes        map[string]http.HandlerFunc
+	rendererTypes map[reflect.Type]spi.EntityRendererFactory
+	contentFS     fs.FS
+	entities      map[string]*registeredEntity
+	entityCounter int
+	receivers     map[int]eventReceiver
+	handleCounter int
+	renders       []RenderedList
+	events        []BroadcastEvent
+	errors        []error
+
+	// goroutineId identifies the plugin goroutine, to catch calls that would deadlock the server.
+	goroutineId atomic.Uint64
+}
+
+// Force implementation of spi.IPMAASContainer
+var _ spi.IPMAASContainer = (*Container)(nil)
+
+// NewContainer creates a container and starts its plugin goroutine.  Call Close when done.
+func NewContainer() *Container {
+	c := &Container{
+		queue:         make([]func(), 0),
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		routes:        make(map[string]http.HandlerFunc),
+		rendererTypes: make(map[reflect.Type]spi.EntityRendererFactory),
+		entities:      make(map[string]*registeredEntity),
+		receivers:     make(map[int]eventReceiver),
+		renders:       make([]RenderedList, 0),
+		events:        make([]BroadcastEvent, 0),
+		errors:        make([]error, 0),
+	}
+
+	go c.run()
+
+	return c
+}
+
+// StartPlugin calls Init and Start of the plugin on the plugin goroutine, and waits for them to return.
+func (c *Container) StartPlugin(plugin spi.IPMAASPlugin) {
+	c.execute(func() {
+		plugin.Init(c)
+		plugin.Start()
+	})
+}
+
+// StopPlugin calls Stop of the plugin on the plugin goroutine, runs the callbacks it sends until the returned channel
+// is closed, and waits for all of it to complete.
+func (c *Container) StopPlugin(plugin spi.IPMAASPlugin) {
+	var callbacks chan func()
+	c.execute(func() { callbacks = plugin.Stop() })
+
+	for callback := range callbacks {
+		c.execute(callback)
+	}
+
+	c.Sync()
+}
+
+// Close stops the plugin goroutine.  Functions that are still queued are dropped.
+func (c *Container) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	<-c.done
+}
+
+// Sync waits until the plugin goroutine is idle, including the processing of events broadcast while waiting.
+func (c *Container) Sync() {
+	for {
+		idle := false
+
+		// Nothing else runs while this does, so an empty queue means the plugin goroutine is idle.
+		c.execute(func() {
+			c.mutex.Lock()
+			idle = len(c.queue) == 0
+			c.mutex.Unlock()
+		})
+
+		if idle {
+			return
+		}
+	}
+}
+
+// AddEntity registers an entity owned by the test, and broadcasts an events.EntityRegisteredEvent for it.  The target
+// is passed to InvokeOnEntity functions and returned by the stub factory.  Returns the ID of the entity.
+func (c *Container) AddEntity(entityType reflect.Type, name string, target any) string {
+	c.mutex.Lock()
+	id := c.nextEntityId()
+	info := entity.RegisteredEntityInfo{
+		Id:            id,
+		EntityType:    entityType,
+		Name:          name,
+		StubFactoryFn: func() (any, error) { return target, nil },
+	}
+	c.entities[id] = &registeredEntity{info: info, target: target}
+	c.mutex.Unlock()
+
+	_ = c.BroadcastEvent(id, events.EntityRegisteredEvent{
+		EntityEvent: events.EntityEvent{
+			Id:         id,
+			EntityType: entityType,
+			Name:       name,
+		},
+		StubFactoryFn: info.StubFactoryFn,
+	})
+
+	return id
+}
+
+// UpdateEntityState broadcasts an events.EntityStateChangedEvent for an entity.
+func (c *Container) UpdateEntityState(id string, newState any) error {
+	registered, ok := c.lookupEntity(id)
+
+	if !ok {
+		return fmt.Errorf("entity %s not found", id)
+	}
+
+	return c.BroadcastEvent(id, events.EntityStateChangedEvent{
+		EntityEvent: events.EntityEvent{
+			Id:         id,
+			EntityType: registered.info.EntityType,
+			Name:       registered.info.Name,
+		},
+		NewState: newState,
+	})
+}
+
+// RemoveEntity deregisters an entity added with AddEntity.
+func (c *Container) RemoveEntity(id string) error {
+	return c.DeregisterEntity(id)
+}
+
+// Entity returns the registration of an entity, including the ones registered by the plugin.
+func (c *Container) Entity(id string) (entity.RegisteredEntityInfo, bool) {
+	registered, ok := c.lookupEntity(id)
+
+	if !ok {
+		return entity.RegisteredEntityInfo{}, false
+	}
+
+	return registered.info, true
+}
+
+// Routes returns the paths of the routes added by the plugin.
+func (c *Container) Routes() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	result := make([]string, 0, len(c.routes))
+
+	for routePath := range c.routes {
+		result = append(result, routePath)
+	}
+
+	return result
+}
+
+// Get invokes the route handler added for the path with a GET request for the target, which may include a query.
+// The handler runs on the calling goroutine, like HTTP requests in the server.
+func (c *Container) Get(routePath string, target string) (*httptest.ResponseRecorder, error) {
+	return c.Do(routePath, httptest.NewRequest(http.MethodGet, target, nil))
+}
+
+// Do invokes the route handler added for the path with any request, like a form POST.
+func (c *Container) Do(routePath string, request *http.Request) (*httptest.ResponseRecorder, error) {
+	c.mutex.Lock()
+	handler, ok := c.routes[routePath]
+	c.mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no route for %s", routePath)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+
+	return recorder, nil
+}
+
+// Renders returns the RenderList calls made so far.
+func (c *Container) Renders() []RenderedList {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]RenderedList(nil), c.renders...)
+}
+
+// Events returns the events broadcast so far, by the plugin and the test.
+func (c *Container) Events() []BroadcastEvent {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]BroadcastEvent(nil), c.events...)
+}
+
+// Errors returns the errors returned by event receivers so far, and the calls to EnqueueOnPluginGoRoutine made from
+// the plugin goroutine.
+func (c *Container) Errors() []error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]error(nil), c.errors...)
+}
+
+func (c *Container) AddRoute(path string, handlerFunc http.HandlerFunc) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.routes[path] = handlerFunc
+}
+
+// BroadcastEvent records the event and delivers it to the matching receivers on the plugin goroutine.
+func (c *Container) BroadcastEvent(entityEventId string, event any) error {
+	c.mutex.Lock()
+	c.events = append(c.events, BroadcastEvent{SourceEntityId: entityEventId, Event: event})
+	receivers := make([]eventReceiver, 0, len(c.receivers))
+
+	for handle := 1; handle <= c.handleCounter; handle = handle + 1 {
+		if receiver, ok := c.receivers[handle]; ok {
+			receivers = append(receivers, receiver)
+		}
+	}
+
+	c.mutex.Unlock()
+	eventInfo := &events.EventInfo{SourceEntityId: entityEventId, Event: event}
+
+	for _, receiver := range receivers {
+		if !receiver.predicate(eventInfo) {
+			continue
+		}
+
+		err := c.enqueue(func() {
+			if err := receiver.receiver(eventInfo); err != nil {
+				c.mutex.Lock()
+				c.errors = append(c.errors, err)
+				c.mutex.Unlock()
+			}
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenderList records the call, and renders the header and items with the registered renderers.  Items without a
+// renderer are written with fmt's %v.
+func (c *Container) RenderList(w http.ResponseWriter, r *http.Request, options spi.RenderListOptions, items []any) {
+	var body bytes.Buffer
+	err := c.renderItem(&body, options.Header)
+
+	for i := 0; err == nil && i < len(items); i = i + 1 {
+		err = c.renderItem(&body, items[i])
+	}
+
+	c.mutex.Lock()
+	c.renders = append(c.renders, RenderedList{Options: options, Items: items, Body: body.String()})
+	c.mutex.Unlock()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, "<h1>%s</h1>\n", template.HTMLEscapeString(options.Title))
+	_, _ = w.Write(body.Bytes())
+}
+
+func (c *Container) renderItem(body *bytes.Buffer, item any) error {
+	if item == nil {
+		return nil
+	}
+
+	itemType := reflect.TypeOf(item)
+
+	if itemType.Kind() == reflect.Ptr {
+		itemType = itemType.Elem()
+	}
+
+	renderer, err := c.GetEntityRenderer(itemType)
+
+	if err != nil {
+		_, err = fmt.Fprintf(body, "%v\n", item)
+		return err
+	}
+
+	if renderer.StreamingRenderFunc != nil {
+		return renderer.StreamingRenderFunc(body, item)
+	}
+
+	if renderer.RenderFunc == nil {
+		return fmt.Errorf("renderer for %v has no render function", itemType)
+	}
+
+	result, err := renderer.RenderFunc(item)
+
+	if err != nil {
+		return err
+	}
+
+	body.WriteString(result)
+
+	return nil
+}
+
+// GetTemplate parses the template with html/template from the content provided with ProvideContentFS, or the
+// template's SourceFS.
+func (c *Container) GetTemplate(templateInfo *spi.TemplateInfo) (spi.CompiledTemplate, error) {
+	c.mutex.Lock()
+	sourceFS := c.contentFS
+	c.mutex.Unlock()
+
+	if templateInfo.SourceFS != nil {
+		sourceFS = templateInfo.SourceFS
+	}
+
+	if sourceFS == nil {
+		return spi.CompiledTemplate{}, errors.New("no content provided")
+	}
+
+	if len(templateInfo.Paths) == 0 {
+		return spi.CompiledTemplate{}, fmt.Errorf("template %s has no paths", templateInfo.Name)
+	}
+
+	instance, err := template.New(templateInfo.Name).
+		Funcs(template.FuncMap(templateInfo.FuncMap)).
+		ParseFS(sourceFS, templateInfo.Paths...)
+
+	if err != nil {
+		return spi.CompiledTemplate{}, err
+	}
+
+	// ParseFS names the templates after the files, so execute the first one.
+	instance = instance.Lookup(path.Base(templateInfo.Paths[0]))
+
+	return spi.CompiledTemplate{
+		Instance: instance,
+		Styles:   templateInfo.Styles,
+		Scripts:  templateInfo.Scripts,
+	}, nil
+}
+
+func (c *Container) GetEntityRenderer(entityType reflect.Type) (spi.EntityRenderer, error) {
+	c.mutex.Lock()
+	factory, ok := c.rendererTypes[entityType]
+	c.mutex.Unlock()
+
+	if !ok {
+		return spi.EntityRenderer{}, fmt.Errorf("no renderer registered for %v", entityType)
+	}
+
+	return factory()
+}
+
+func (c *Container) RegisterEntityRenderer(entityType reflect.Type, renderFactory spi.EntityRendererFactory) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rendererTypes[entityType] = renderFactory
+}
+
+func (c *Container) EnableStaticContent(staticContentDir string) {
+}
+
+// ProvideContentFS makes the content under prefix available to GetTemplate.
+func (c *Container) ProvideContentFS(contentFS fs.FS, prefix string) {
+	subFS, err := fs.Sub(contentFS, prefix)
+
+	if err != nil {
+		panic(fmt.Sprintf("invalid content prefix %s: %v", prefix, err))
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.contentFS = subFS
+}
+
+// RegisterEntity registers an entity of the plugin.  Unlike the server, the container doesn't broadcast an
+// events.EntityRegisteredEvent for it.
+func (c *Container) RegisterEntity(
+	uniqueData string,
+	entityType reflect.Type,
+	name string,
+	stubFactoryFn spi.EntityStubFactoryFunc) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, registered := range c.entities {
+		if registered.uniqueData != "" && registered.uniqueData == uniqueData {
+			return "", fmt.Errorf("entity %s is already registered", uniqueData)
+		}
+	}
+
+	id := c.nextEntityId()
+	c.entities[id] = &registeredEntity{
+		info: entity.RegisteredEntityInfo{
+			Id:            id,
+			EntityType:    entityType,
+			Name:          name,
+			StubFactoryFn: stubFactoryFn,
+		},
+		uniqueData: uniqueData,
+	}
+
+	return id, nil
+}
+
+// DeregisterEntity removes an entity, and broadcasts an events.EntityDeregisteredEvent for it.
+func (c *Container) DeregisterEntity(id string) error {
+	c.mutex.Lock()
+	registered, ok := c.entities[id]
+	delete(c.entities, id)
+	c.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("entity %s not found", id)
+	}
+
+	return c.BroadcastEvent(id, events.EntityDeregisteredEvent{
+		EntityEvent: events.EntityEvent{
+			Id:         id,
+			EntityType: registered.info.EntityType,
+			Name:       registered.info.Name,
+		},
+	})
+}
+
+func (c *Container) AssertEntityType(pmaasEntityId string, entityType reflect.Type) error {
+	registered, ok := c.lookupEntity(pmaasEntityId)
+
+	if !ok {
+		return fmt.Errorf("entity %s not found", pmaasEntityId)
+	}
+
+	if registered.info.EntityType != entityType {
+		return fmt.Errorf("entity %s is a %v, not a %v", pmaasEntityId, registered.info.EntityType, entityType)
+	}
+
+	return nil
+}
+
+func (c *Container) GetEntities(predicate func(info *entity.RegisteredEntityInfo) bool) (
+	[]entity.RegisteredEntityInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	result := make([]entity.RegisteredEntityInfo, 0)
+
+	for _, registered := range c.entities {
+		info := registered.info
+
+		if predicate(&info) {
+			result = append(result, info)
+		}
+	}
+
+	return result, nil
+}
+
+// InvokeOnEntity runs the function on the plugin goroutine, with the target of an entity added with AddEntity, or
+// the stub of an entity registered by the plugin.
+func (c *Container) InvokeOnEntity(id string, function func(entity any)) error {
+	registered, ok := c.lookupEntity(id)
+
+	if !ok {
+		return fmt.Errorf("entity %s not found", id)
+	}
+
+	target := registered.target
+
+	if target == nil {
+		stub, err := registered.info.StubFactoryFn()
+
+		if err != nil {
+			return fmt.Errorf("unable to create stub of %s: %w", id, err)
+		}
+
+		target = stub
+	}
+
+	return c.enqueue(func() { function(target) })
+}
+
+func (c *Container) RegisterEventReceiver(predicate events.EventPredicate, receiver events.EventReceiver) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handleCounter = c.handleCounter + 1
+	c.receivers[c.handleCounter] = eventReceiver{predicate: predicate, receiver: receiver}
+
+	return c.handleCounter, nil
+}
+
+func (c *Container) DeregisterEventReceiver(receiverHandle int) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.receivers[receiverHandle]; !ok {
+		return fmt.Errorf("event receiver %d not found", receiverHandle)
+	}
+
+	delete(c.receivers, receiverHandle)
+
+	return nil
+}
+
+// EnqueueOnPluginGoRoutine queues the function for the plugin goroutine.  Calling it from the plugin goroutine
+// deadlocks the server, so here the call is recorded in Errors and fails without queueing the function.
+func (c *Container) EnqueueOnPluginGoRoutine(f func()) error {
+	if currentGoroutineId() == c.goroutineId.Load() {
+		err := errors.New("EnqueueOnPluginGoRoutine called from the plugin goroutine")
+		c.mutex.Lock()
+		c.errors = append(c.errors, err)
+		c.mutex.Unlock()
+
+		return err
+	}
+
+	return c.enqueue(f)
+}
+
+// enqueue queues the function for the plugin goroutine.  Unlike the server's, the queue is unbounded.
+func (c *Container) enqueue(f func()) error {
+	select {
+	case <-c.stop:
+		return errors.New("container is closed")
+	default:
+	}
+
+	c.mutex.Lock()
+	c.queue = append(c.queue, f)
+	c.mutex.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// EnqueueOnServerGoRoutine runs the functions on the plugin goroutine, since the container has no server goroutine.
+func (c *Container) EnqueueOnServerGoRoutine(invocations []func()) error {
+	for _, invocation := range invocations {
+		err := c.enqueue(invocation)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Container) ClosedCallbackChannel() chan func() {
+	ch := make(chan func())
+	close(ch)
+
+	return ch
+}
+
+func (c *Container) run() {
+	defer close(c.done)
+	c.goroutineId.Store(currentGoroutineId())
+
+	for {
+		c.mutex.Lock()
+		var f func() = nil
+
+		if len(c.queue) > 0 {
+			f = c.queue[0]
+			c.queue = c.queue[1:]
+		}
+
+		c.mutex.Unlock()
+
+		if f != nil {
+			f()
+			continue
+		}
+
+		select {
+		case <-c.wake:
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// execute runs the function on the plugin goroutine, and waits for it and everything queued before it to complete.
+func (c *Container) execute(f func()) {
+	executed := make(chan struct{})
+	err := c.enqueue(func() {
+		defer close(executed)
+		f()
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	<-executed
+}
+
+func (c *Container) lookupEntity(id string) (*registeredEntity, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	registered, ok := c.entities[id]
+
+	return registered, ok
+}
+
+// nextEntityId must be called with the mutex held.
+func (c *Container) nextEntityId() string {
+	c.entityCounter = c.entityCounter + 1
+
+	return fmt.Sprintf("entity_%d", c.entityCounter)
+}
+
+// currentGoroutineId returns the id of the calling goroutine, parsed from the "goroutine N [running]:" header of its
+// stack trace.  Go doesn't otherwise expose it.
+func currentGoroutineId() uint64 {
+	buffer := make([]byte, 64)
+	buffer = buffer[:runtime.Stack(buffer, false)]
+	fields := strings.Fields(strings.TrimPrefix(string(buffer), "goroutine "))
+
+	if len(fields) == 0 {
+		return 0
+	}
+
+	id, _ := strconv.ParseUint(fields[0], 10, 64)
+
+	return id
+}
@@ -0,0 +1,150 @@
+package plugintest
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/avanha/pmaas-spi"
+	"github.com/avanha/pmaas-spi/events"
+)
+
+type testEntity struct {
+	Name string
+}
+
+var testEntityType = reflect.TypeOf((*testEntity)(nil)).Elem()
+
+func TestContainer_DeliversEventsOnPluginGoRoutine(t *testing.T) {
+	// Arrange
+	container := NewContainer()
+	defer container.Close()
+	received := make([]string, 0)
+	_, _ = container.RegisterEventReceiver(
+		func(eventInfo *events.EventInfo) bool {
+			_, ok := eventInfo.Event.(events.EntityRegisteredEvent)
+			return ok
+		},
+		func(eventInfo *events.EventInfo) error {
+			received = append(received, eventInfo.Event.(events.EntityRegisteredEvent).Name)
+
+			// Events broadcast by receivers are delivered before Sync returns
+			return container.BroadcastEvent(eventInfo.SourceEntityId, "follow-up")
+		})
+
+	// Act
+	id := container.AddEntity(testEntityType, "first", &testEntity{Name: "first"})
+	container.Sync()
+
+	// Assert
+	if len(received) != 1 || received[0] != "first" {
+		t.Fatalf("expected the registration to be received, got %v", received)
+	}
+
+	broadcast := container.Events()
+
+	if len(broadcast) != 2 || broadcast[0].SourceEntityId != id || broadcast[1].Event != "follow-up" {
+		t.Fatalf("unexpected events %v", broadcast)
+	}
+}
+
+func TestContainer_RecordsReceiverErrors(t *testing.T) {
+	// Arrange
+	container := NewContainer()
+	defer container.Close()
+	_, _ = container.RegisterEventReceiver(
+		func(eventInfo *events.EventInfo) bool { return true },
+		func(eventInfo *events.EventInfo) error { return errors.New("failed") })
+
+	// Act
+	_ = container.BroadcastEvent("source", "event")
+	container.Sync()
+
+	// Assert
+	if errs := container.Errors(); len(errs) != 1 || errs[0].Error() != "failed" {
+		t.Fatalf("expected the receiver error, got %v", errs)
+	}
+}
+
+func TestContainer_RejectsEnqueueFromPluginGoRoutine(t *testing.T) {
+	// Arrange
+	container := NewContainer()
+	defer container.Close()
+	nestedRan := false
+	var nestedErr error
+	outerErr := container.EnqueueOnPluginGoRoutine(func() {
+		nestedErr = container.EnqueueOnPluginGoRoutine(func() { nestedRan = true })
+	})
+
+	// Act
+	container.Sync()
+
+	// Assert
+	if outerErr != nil || nestedErr == nil || nestedRan {
+		t.Fatalf("expected only the nested call to fail, got %v %v %v", outerErr, nestedErr, nestedRan)
+	}
+
+	if errs := container.Errors(); len(errs) != 1 || errs[0] != nestedErr {
+		t.Fatalf("expected the nested call to be recorded, got %v", errs)
+	}
+}
+
+func TestContainer_InvokeOnEntity(t *testing.T) {
+	// Arrange
+	container := NewContainer()
+	defer container.Close()
+	target := &testEntity{Name: "before"}
+	id := container.AddEntity(testEntityType, "entity", target)
+
+	// Act
+	err := container.InvokeOnEntity(id, func(entity any) { entity.(*testEntity).Name = "after" })
+	container.Sync()
+
+	// Assert
+	if err != nil || target.Name != "after" {
+		t.Fatalf("expected the function to be invoked, got %v %v", err, target.Name)
+	}
+}
+
+func TestContainer_RenderListUsesRegisteredRenderers(t *testing.T) {
+	// Arrange
+	container := NewContainer()
+	defer container.Close()
+	container.ProvideContentFS(fstest.MapFS{
+		"content/templates/test.htmlt": &fstest.MapFile{Data: []byte(`<div>{{.Name | upper}}</div>`)},
+	}, "content")
+	templateInfo := spi.TemplateInfo{
+		Name:    "test",
+		FuncMap: map[string]any{"upper": strings.ToUpper},
+		Paths:   []string{"templates/test.htmlt"},
+	}
+	container.RegisterEntityRenderer(testEntityType, func() (spi.EntityRenderer, error) {
+		return spi.TemplateBasedRendererFactory(
+			container,
+			&templateInfo,
+			func(entity any) bool { _, ok := entity.(*testEntity); return ok },
+			"*testEntity")
+	})
+	container.AddRoute("/list", func(w http.ResponseWriter, r *http.Request) {
+		container.RenderList(w, r, spi.RenderListOptions{Title: "Entities"}, []any{&testEntity{Name: "a"}})
+	})
+
+	// Act
+	response, err := container.Get("/list", "/list")
+
+	// Assert
+	if err != nil || response.Code != http.StatusOK {
+		t.Fatalf("unexpected response %v %v", response, err)
+	}
+
+	if body := response.Body.String(); !strings.Contains(body, "<h1>Entities</h1>") || !strings.Contains(body, "<div>A</div>") {
+		t.Fatalf("unexpected body %s", body)
+	}
+
+	if renders := container.Renders(); len(renders) != 1 || len(renders[0].Items) != 1 {
+		t.Fatalf("unexpected renders %v", renders)
+	}
+}
@@ -0,0 +1,114 @@
+package environment
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/recording"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/events"
+)
+
+func (p *plugin) startRecording() {
+	if p.config.RecordingPath == "" {
+		return
+	}
+
+	recorder, err := recording.NewRecorder(p.config.RecordingPath)
+
+	if err != nil {
+		fmt.Printf("%T Unable to open recording %s: %v\n", p, p.config.RecordingPath, err)
+		return
+	}
+
+	p.state.recorder = recorder
+}
+
+func (p *plugin) stopRecording() {
+	if p.state.recorder == nil {
+		return
+	}
+
+	err := p.state.recorder.Close()
+
+	if err != nil {
+		fmt.Printf("%T Unable to close recording %s: %v\n", p, p.config.RecordingPath, err)
+	}
+
+	p.state.recorder = nil
+}
+
+// recordStateChange appends a received thermometer state change to the recording, if enabled.
+func (p *plugin) recordStateChange(event events.EntityStateChangedEvent) {
+	if p.state.recorder == nil {
+		return
+	}
+
+	state, ok := event.NewState.(spienvironment.WirelessThermometer)
+
+	if !ok {
+		return
+	}
+
+	err := p.state.recorder.Record(recording.Entry{
+		Time:     p.now(),
+		EntityId: event.Id,
+		Name:     event.Name,
+		State:    state,
+	})
+
+	if err != nil {
+		fmt.Printf("%T Unable to record state change of %s: %v\n", p, event.Id, err)
+	}
+}
+
+// ReplayRecording replays a recording made with RecordingPath, with the input filters, smoothing and change event
+// gates of the configuration, and writes the published events, if printEvents is set, followed by the final state of
+// each thermometer to out.
+func (c *PluginConfig) ReplayRecording(path string, printEvents bool, out io.Writer) error {
+	entries, err := recording.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	result := recording.Replay(entries, recording.ReplayOptions{Configure: c.configureThermometer})
+
+	if printEvents {
+		for _, event := range result.Events {
+			fmt.Fprintf(out, "%s %s %T %+v\n", event.Time.Format(time.RFC3339), event.EntityId, event.Event, event.Event)
+		}
+	}
+
+	for _, err := range result.Errors {
+		fmt.Fprintf(out, "Error: %v\n", err)
+	}
+
+	entityIds := make([]string, 0, len(result.Thermometers))
+
+	for entityId := range result.Thermometers {
+		entityIds = append(entityIds, entityId)
+	}
+
+	slices.Sort(entityIds)
+	fmt.Fprintf(out, "Replayed %d entries\n", len(entries))
+
+	for _, entityId := range entityIds {
+		wt := result.Thermometers[entityId]
+		fmt.Fprintf(out, "%s (%s): temperature %.2f, low %.2f at %s, high %.2f at %s",
+			wt.Name, entityId, wt.SensorData.Temperature,
+			wt.LowTemperature, wt.LowTemperatureTime.Format(time.RFC3339),
+			wt.HighTemperature, wt.HighTemperatureTime.Format(time.RFC3339))
+
+		if wt.SensorData.HasHumidity {
+			fmt.Fprintf(out, ", humidity %.1f, low %.1f, high %.1f", wt.SensorData.Humidity, wt.LowHumidity,
+				wt.HighHumidity)
+		}
+
+		fmt.Fprintln(out)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/rtl433"
+)
+
+// startRtl433 reads the configured rtl_433 inputs, and feeds the thermometers they receive to the plugin.
+func (p *plugin) startRtl433() {
+	cfg := p.config.Rtl433
+
+	if len(cfg.Inputs) == 0 {
+		return
+	}
+
+	retryInterval := cfg.RetryInterval
+
+	if retryInterval <= 0 {
+		retryInterval = 10 * time.Second
+	}
+
+	mapper := rtl433.NewMapper(cfg.Names)
+	ctx, cancel := context.WithCancel(context.Background())
+	readers := sync.WaitGroup{}
+	p.state.stopRtl433 = func() {
+		cancel()
+		readers.Wait()
+	}
+
+	handle := func(message rtl433.Message) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := p.state.container.EnqueueOnPluginGoRoutine(func() {
+			// Drop messages that were queued before the inputs were stopped
+			if ctx.Err() != nil {
+				return
+			}
+
+			id, state, isNew := mapper.Apply(message, p.now())
+
+			if isNew {
+				p.addBuiltInSource(id, state.Name)
+			}
+
+			p.updateBuiltInSource(id, state)
+		})
+
+		if err != nil {
+			fmt.Printf("%T Unable to enqueue rtl_433 message: %v\n", p, err)
+		}
+	}
+
+	handleError := func(err error) {
+		fmt.Printf("%T %v\n", p, err)
+	}
+
+	for _, input := range cfg.Inputs {
+		readers.Add(1)
+
+		go func() {
+			defer readers.Done()
+			rtl433.Run(ctx, rtl433.Input(input), retryInterval, handle, handleError)
+		}()
+	}
+}
+
+// stopRtl433 stops reading the inputs, and waits for their goroutines to exit.
+func (p *plugin) stopRtl433() {
+	if p.state.stopRtl433 != nil {
+		p.state.stopRtl433()
+		p.state.stopRtl433 = nil
+	}
+}
@@ -0,0 +1,236 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/schedule"
+	"github.com/avanha/pmaas-spi"
+)
+
+// scheduleTimeLayout matches the value of an HTML datetime-local input.
+const scheduleTimeLayout = "2006-01-02T15:04"
+
+func (p *plugin) buildSchedules() map[string]*schedule.Schedule {
+	schedules := make(map[string]*schedule.Schedule)
+
+	for _, scheduleConfig := range p.config.Schedules {
+		days := make(map[time.Weekday][]schedule.Period)
+
+		for day, periods := range scheduleConfig.Days {
+			days[day] = toSchedulePeriods(periods)
+		}
+
+		schedules[scheduleConfig.Name] = schedule.CreateSchedule(
+			scheduleConfig.Name,
+			toSchedulePeriods(scheduleConfig.Weekday),
+			toSchedulePeriods(scheduleConfig.Weekend),
+			days)
+	}
+
+	return schedules
+}
+
+func toSchedulePeriods(periods []SchedulePeriod) []schedule.Period {
+	result := make([]schedule.Period, len(periods))
+
+	for i, period := range periods {
+		result[i] = schedule.Period{
+			Name:  period.Name,
+			Start: period.Start,
+			Setpoints: schedule.Setpoints{
+				Heat:     period.HeatSetpoint,
+				Cool:     period.CoolSetpoint,
+				Humidity: period.HumiditySetpoint,
+			},
+		}
+	}
+
+	return result
+}
+
+func lookupSchedule(schedules map[string]*schedule.Schedule, name string, controllerName string) *schedule.Schedule {
+	if name == "" {
+		return nil
+	}
+
+	result, ok := schedules[name]
+
+	if !ok {
+		fmt.Printf("Schedule %s of %s is not defined, setpoints will not be scheduled\n", name, controllerName)
+	}
+
+	return result
+}
+
+// handleHttpScheduleRequest overrides the schedule of a controller.  The action form value selects between a
+// temporary hold, resuming the schedule, and starting or cancelling a vacation.  Setpoints that aren't supplied keep
+// their current values.
+func (p *plugin) handleHttpScheduleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.PostForm.Get("id")
+	action := r.PostForm.Get("action")
+
+	err, _ = spi.ExecValueFunctionOnPluginGoRoutine(
+		p.state.container,
+		func() error {
+			c, ok := p.state.controllers[id]
+
+			if !ok {
+				return fmt.Errorf("controller %s not found", id)
+			}
+
+			scheduled, ok := c.(schedule.IScheduled)
+
+			if !ok || !scheduled.GetScheduler().Enabled() {
+				return fmt.Errorf("controller %s does not have a schedule", id)
+			}
+
+			now := p.now()
+			err := applyScheduleAction(scheduled, action, r, now)
+
+			if err != nil {
+				return err
+			}
+
+			c.Evaluate(now, p.lookupSensorData, p.broadcastEvent)
+
+			return nil
+		},
+		func() error { return errors.New("unable to update schedule") },
+		"handleHttpScheduleRequest")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/plugins/environment/", http.StatusSeeOther)
+}
+
+func applyScheduleAction(scheduled schedule.IScheduled, action string, r *http.Request, now time.Time) error {
+	scheduler := scheduled.GetScheduler()
+
+	switch action {
+	case "resume":
+		scheduler.Resume()
+		return nil
+	case "cancel-vacation":
+		scheduler.CancelVacation()
+		return nil
+	case "hold":
+		setpoints, err := parseSetpoints(r, scheduler.Setpoints, scheduled)
+
+		if err != nil {
+			return err
+		}
+
+		until, err := parseScheduleTime(r.PostForm.Get("until"), now.Location())
+
+		if err != nil {
+			return err
+		}
+
+		if !until.IsZero() && !until.After(now) {
+			return errors.New("hold must end in the future")
+		}
+
+		scheduler.HoldUntil(setpoints, until, now)
+		return nil
+	case "vacation":
+		setpoints, err := parseSetpoints(r, scheduler.Setpoints, scheduled)
+
+		if err != nil {
+			return err
+		}
+
+		from, err := parseScheduleTime(r.PostForm.Get("from"), now.Location())
+
+		if err != nil {
+			return err
+		}
+
+		to, err := parseScheduleTime(r.PostForm.Get("to"), now.Location())
+
+		if err != nil {
+			return err
+		}
+
+		if from.IsZero() {
+			from = now
+		}
+
+		if !to.After(from) {
+			return errors.New("vacation must end after it starts")
+		}
+
+		if !to.After(now) {
+			return errors.New("vacation must end in the future")
+		}
+
+		scheduler.SetVacation(from, to, setpoints)
+		return nil
+	}
+
+	return fmt.Errorf("unknown schedule action %q", action)
+}
+
+func parseSetpoints(
+	r *http.Request,
+	current schedule.Setpoints,
+	scheduled schedule.IScheduled) (schedule.Setpoints, error) {
+	result := current
+	fields := map[string]*float32{
+		"heat":     &result.Heat,
+		"cool":     &result.Cool,
+		"humidity": &result.Humidity,
+	}
+
+	for name, target := range fields {
+		value := r.PostForm.Get(name)
+
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseFloat(value, 32)
+
+		if err != nil {
+			return current, fmt.Errorf("invalid %s setpoint %q", name, value)
+		}
+
+		*target = float32(parsed)
+	}
+
+	return result, scheduled.ValidateSetpoints(result)
+}
+
+// parseScheduleTime parses a form value as a time in the passed location, which is the location of the plugin's
+// clock, so it matches the time zone schedules are evaluated in.  An empty value results in the zero time.
+func parseScheduleTime(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	result, err := time.ParseInLocation(scheduleTimeLayout, value, location)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package environment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/internal/simulator"
+)
+
+// startSimulator registers the synthetic thermometers of simulator mode, and periodically feeds them readings.
+func (p *plugin) startSimulator() {
+	cfg := p.config.Simulator
+
+	if cfg.Count <= 0 {
+		return
+	}
+
+	seed := cfg.Seed
+
+	if seed == 0 {
+		seed = uint64(p.now().UnixNano())
+	}
+
+	sim := simulator.NewSimulator(simulator.Config{
+		Count:              cfg.Count,
+		Seed:               seed,
+		DropoutRate:        cfg.DropoutRate,
+		BatteryDrainPerDay: cfg.BatteryDrainPerDay,
+	}, p.now())
+
+	for _, sensor := range sim.Sensors() {
+		p.addBuiltInSource(sensor.Id, sensor.Name)
+	}
+
+	sample := func() {
+		for _, reading := range sim.Sample(p.now()) {
+			p.updateBuiltInSource(reading.Id, reading.State)
+		}
+	}
+	sample()
+
+	interval := cfg.Interval
+
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	p.state.stopSimulator = func() {
+		ticker.Stop()
+		close(done)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				err := p.state.container.EnqueueOnPluginGoRoutine(sample)
+
+				if err != nil {
+					fmt.Printf("%T Unable to enqueue simulator sample: %v\n", p, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *plugin) stopSimulator() {
+	if p.state.stopSimulator != nil {
+		p.state.stopSimulator()
+		p.state.stopSimulator = nil
+	}
+}
@@ -0,0 +1,24 @@
+package environment
+
+import (
+	"github.com/avanha/pmaas-plugin-environment/internal/smoothing"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+)
+
+// setSmoothing configures the smoothing of a thermometer, if it has any.
+func (c *PluginConfig) setSmoothing(wt *thermometer.WirelessThermometer) {
+	config, ok := c.Smoothing[wt.Name]
+
+	if !ok || config.Method == SmoothingNone {
+		return
+	}
+
+	smoothingConfig := smoothing.Config{
+		Method:           smoothing.Method(config.Method),
+		Alpha:            config.Alpha,
+		Window:           config.Window,
+		ProcessNoise:     config.ProcessNoise,
+		MeasurementNoise: config.MeasurementNoise,
+	}
+	wt.SetSmoothing(smoothingConfig, smoothingConfig, config.UseSmoothedValues)
+}
@@ -0,0 +1,39 @@
+package environment
+
+import (
+	"fmt"
+
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/events"
+)
+
+// Built-in sources, like the simulator, feed thermometer readings to the plugin directly rather than through another
+// plugin's entities.  They pass through the same handlers as external thermometers, so filtering, smoothing, history
+// and exports apply to them too.  These functions must be called on the plugin goroutine.
+
+func (p *plugin) addBuiltInSource(id string, name string) {
+	err := p.onEntityRegistered(&events.EventInfo{
+		SourceEntityId: id,
+		Event: events.EntityRegisteredEvent{
+			EntityEvent: events.EntityEvent{Id: id, EntityType: IWirelessThermometerType, Name: name},
+		},
+	})
+
+	if err != nil {
+		fmt.Printf("%T Unable to add source %s: %v\n", p, id, err)
+	}
+}
+
+func (p *plugin) updateBuiltInSource(id string, state spienvironment.WirelessThermometer) {
+	err := p.onEntityStateChanged(&events.EventInfo{
+		SourceEntityId: id,
+		Event: events.EntityStateChangedEvent{
+			EntityEvent: events.EntityEvent{Id: id, EntityType: IWirelessThermometerType, Name: state.Name},
+			NewState:    state,
+		},
+	})
+
+	if err != nil {
+		fmt.Printf("%T Unable to update source %s: %v\n", p, id, err)
+	}
+}
@@ -0,0 +1,93 @@
+package environment
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/internal/chart"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+)
+
+const sparklineDuration = 24 * time.Hour
+const sparklineStep = 15 * time.Minute
+
+// Sparkline renders the last 24 hours of a thermometer's temperature or humidity, selected by metric, as a small
+// SVG in the thermometer's display units.  Today's highs and lows are marked.  It uses the system clock; the plugin
+// renders its templates with SparklineFunc of PluginConfig.Clock instead.
+func Sparkline(wt *thermometer.WirelessThermometer, metric string) template.HTML {
+	return sparkline(wt, metric, clock.Real.Now())
+}
+
+// SparklineFunc returns a Sparkline template function that reads the current time from c.
+func SparklineFunc(c Clock) func(*thermometer.WirelessThermometer, string) template.HTML {
+	return func(wt *thermometer.WirelessThermometer, metric string) template.HTML {
+		return sparkline(wt, metric, c.Now())
+	}
+}
+
+func sparkline(wt *thermometer.WirelessThermometer, metric string, now time.Time) template.HTML {
+	series := chart.Series{Class: metric, Points: make([]chart.Point, 0, len(wt.RecentReadings))}
+	markers := make([]chart.Marker, 0, 2)
+
+	switch metric {
+	case "temperature":
+		series.Name = "Temperature, last 24 hours"
+
+		unit := wt.Display.TemperatureUnit
+
+		for _, reading := range wt.RecentReadings {
+			series.Points = append(series.Points,
+				chart.Point{Time: reading.Time, Value: float64(unit.FromCelsius(reading.Temperature))})
+		}
+
+		if !wt.HighTemperatureTime.IsZero() {
+			markers = append(markers,
+				sparklineMarker(wt.HighTemperatureTime, unit.FromCelsius(wt.HighTemperature), "high",
+					"High "+wt.Display.Temperature(wt.HighTemperature)),
+				sparklineMarker(wt.LowTemperatureTime, unit.FromCelsius(wt.LowTemperature), "low",
+					"Low "+wt.Display.Temperature(wt.LowTemperature)))
+		}
+	case "humidity":
+		series.Name = "Humidity, last 24 hours"
+
+		for _, reading := range wt.RecentReadings {
+			if reading.HasHumidity {
+				series.Points = append(series.Points, chart.Point{Time: reading.Time, Value: float64(reading.Humidity)})
+			}
+		}
+
+		if !wt.HighHumidityTime.IsZero() {
+			markers = append(markers,
+				sparklineMarker(wt.HighHumidityTime, wt.HighHumidity, "high",
+					"High "+wt.Display.Humidity(wt.HighHumidity)),
+				sparklineMarker(wt.LowHumidityTime, wt.LowHumidity, "low",
+					"Low "+wt.Display.Humidity(wt.LowHumidity)))
+		}
+	default:
+		return template.HTML(fmt.Sprintf("<!-- unknown sparkline metric %s -->", template.HTMLEscapeString(metric)))
+	}
+
+	if len(series.Points) < 2 {
+		return ""
+	}
+
+	return template.HTML(chart.Chart{
+		Width:   240,
+		Height:  32,
+		From:    now.Add(-sparklineDuration),
+		To:      now,
+		Series:  []chart.Series{series},
+		Markers: markers,
+		MaxGap:  4 * sparklineStep,
+	}.RenderSparkline())
+}
+
+func sparklineMarker(t time.Time, value float32, class string, label string) chart.Marker {
+	return chart.Marker{
+		Point: chart.Point{Time: t, Value: float64(value)},
+		Class: class,
+		Label: label,
+	}
+}
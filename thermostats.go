@@ -0,0 +1,149 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/controller"
+	"github.com/avanha/pmaas-plugin-environment/internal/schedule"
+	"github.com/avanha/pmaas-plugin-environment/internal/thermostat"
+	"github.com/avanha/pmaas-plugin-environment/internal/units"
+	"github.com/avanha/pmaas-spi"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+var ThermostatTemplate = spi.TemplateInfo{
+	Name: "environment_thermostat",
+	FuncMap: template.FuncMap{
+		"RelativeTime": RelativeTime,
+	},
+	Paths:  []string{"templates/thermostat.htmlt"},
+	Styles: []string{"css/thermostat.css"},
+}
+
+func (p *plugin) createThermostats(schedules map[string]*schedule.Schedule) {
+	for _, thermostatConfig := range p.config.Thermostats {
+		instance := thermostat.CreateThermostat(
+			p.state.nextEntityId(),
+			thermostat.Settings{
+				Name:               thermostatConfig.Name,
+				Inputs:             thermostatConfig.InputThermometers,
+				Aggregation:        controller.Aggregation(thermostatConfig.InputAggregation),
+				Mode:               thermostatConfig.Mode,
+				HeatSetpoint:       thermostatConfig.HeatSetpoint,
+				CoolSetpoint:       thermostatConfig.CoolSetpoint,
+				Deadband:           thermostatConfig.Deadband,
+				MinOnTime:          thermostatConfig.MinOnTime,
+				MinOffTime:         thermostatConfig.MinOffTime,
+				HeatSwitchEntityId: thermostatConfig.HeatSwitchEntityId,
+				CoolSwitchEntityId: thermostatConfig.CoolSwitchEntityId,
+				Schedule:           lookupSchedule(schedules, thermostatConfig.Schedule, thermostatConfig.Name),
+			},
+			p.invokeSwitch,
+			tracking.Config{
+				TrackingMode:        tracking.ModePoll,
+				PollIntervalSeconds: 300,
+				Name:                buildTrackingName("Thermostat", thermostatConfig.Name),
+				Schema: tracking.Schema{
+					DataStructType:     data.ThermostatDataType,
+					InsertArgFactoryFn: data.ThermostatDataToInsertArgs,
+				},
+			})
+
+		var stubFactoryFn spi.EntityStubFactoryFunc = func() (any, error) {
+			return instance.GetStub(p.state.container), nil
+		}
+		p.state.controllers[instance.Id] = instance
+		pmaasEntityId, err := p.state.container.RegisterEntity(
+			instance.Id,
+			entities.ThermostatType,
+			instance.Name,
+			stubFactoryFn)
+
+		if err == nil {
+			instance.PmaasEntityId = pmaasEntityId
+		} else {
+			fmt.Printf("Thermostat %s could not be registered: %v\n", instance.Id, err)
+		}
+	}
+}
+
+func (p *plugin) thermostatRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&ThermostatTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*thermostat.Thermostat)
+			return ok
+		},
+		"*Thermostat")
+}
+
+// handleHttpThermostatRequest processes the setpoint control form on the thermostat card.
+func (p *plugin) handleHttpThermostatRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.PostForm.Get("id")
+	mode, modeOk := entities.ParseThermostatMode(r.PostForm.Get("mode"))
+	heatSetpoint, heatErr := strconv.ParseFloat(r.PostForm.Get("heat"), 32)
+	coolSetpoint, coolErr := strconv.ParseFloat(r.PostForm.Get("cool"), 32)
+	unit := units.Celsius
+	var unitErr error
+
+	// The setpoints are entered in the display unit of the card.
+	if value := r.PostForm.Get("unit"); value != "" {
+		unit, unitErr = units.ParseTemperatureUnit(value)
+	}
+
+	if !modeOk || heatErr != nil || coolErr != nil || unitErr != nil {
+		http.Error(w, "Invalid mode or setpoint", http.StatusBadRequest)
+		return
+	}
+
+	err, _ = spi.ExecValueFunctionOnPluginGoRoutine(
+		p.state.container,
+		func() error {
+			instance, err := findController[*thermostat.Thermostat](p, id)
+
+			if err != nil {
+				return err
+			}
+
+			now := p.now()
+			err = instance.SetSetpoints(
+				unit.ToCelsius(float32(heatSetpoint)), unit.ToCelsius(float32(coolSetpoint)), now, p.broadcastEvent)
+
+			if err != nil {
+				return err
+			}
+
+			instance.SetMode(mode, p.broadcastEvent)
+			instance.Evaluate(now, p.lookupSensorData, p.broadcastEvent)
+
+			return nil
+		},
+		func() error { return errors.New("unable to update thermostat") },
+		"handleHttpThermostatRequest")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/plugins/environment/", http.StatusSeeOther)
+}
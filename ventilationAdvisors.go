@@ -0,0 +1,78 @@
+package environment
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/ventilation"
+	"github.com/avanha/pmaas-spi"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+var VentilationAdvisorTemplate = spi.TemplateInfo{
+	Name: "environment_ventilation_advisor",
+	FuncMap: template.FuncMap{
+		"RelativeTime": RelativeTime,
+	},
+	Paths:  []string{"templates/ventilation_advisor.htmlt"},
+	Styles: []string{"css/ventilation_advisor.css"},
+}
+
+func (p *plugin) createVentilationAdvisors() {
+	for _, advisorConfig := range p.config.VentilationAdvisors {
+		instance := ventilation.CreateVentilationAdvisor(
+			p.state.nextEntityId(),
+			ventilation.Settings{
+				Name:              advisorConfig.Name,
+				Indoor:            advisorConfig.IndoorThermometer,
+				Outdoor:           advisorConfig.OutdoorThermometer,
+				ReduceHumidity:    advisorConfig.ReduceHumidity,
+				ReduceTemperature: advisorConfig.ReduceTemperature,
+				TargetTemperature: advisorConfig.TargetTemperature,
+				HumidityMargin:    advisorConfig.HumidityMargin,
+				TemperatureMargin: advisorConfig.TemperatureMargin,
+				FanSwitchEntityId: advisorConfig.FanSwitchEntityId,
+				MinOnTime:         advisorConfig.MinOnTime,
+				MinOffTime:        advisorConfig.MinOffTime,
+			},
+			p.invokeSwitch,
+			tracking.Config{
+				TrackingMode:        tracking.ModePoll,
+				PollIntervalSeconds: 300,
+				Name:                buildTrackingName("VentilationAdvisor", advisorConfig.Name),
+				Schema: tracking.Schema{
+					DataStructType:     data.VentilationAdvisorDataType,
+					InsertArgFactoryFn: data.VentilationAdvisorDataToInsertArgs,
+				},
+			})
+
+		var stubFactoryFn spi.EntityStubFactoryFunc = func() (any, error) {
+			return instance.GetStub(p.state.container), nil
+		}
+		p.state.controllers[instance.Id] = instance
+		pmaasEntityId, err := p.state.container.RegisterEntity(
+			instance.Id,
+			entities.VentilationAdvisorType,
+			instance.Name,
+			stubFactoryFn)
+
+		if err == nil {
+			instance.PmaasEntityId = pmaasEntityId
+		} else {
+			fmt.Printf("VentilationAdvisor %s could not be registered: %v\n", instance.Id, err)
+		}
+	}
+}
+
+func (p *plugin) ventilationAdvisorRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&VentilationAdvisorTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*ventilation.VentilationAdvisor)
+			return ok
+		},
+		"*VentilationAdvisor")
+}
@@ -0,0 +1,356 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/avanha/pmaas-plugin-environment/data"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/weather"
+	"github.com/avanha/pmaas-spi"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
+	"github.com/avanha/pmaas-spi/tracking"
+)
+
+var BarometerTemplate = spi.TemplateInfo{
+	Name: "environment_barometer",
+	FuncMap: template.FuncMap{
+		"RelativeTime": RelativeTime,
+	},
+	Paths:  []string{"templates/barometer.htmlt"},
+	Styles: []string{"css/weather.css"},
+}
+
+var WindSensorTemplate = spi.TemplateInfo{
+	Name: "environment_wind_sensor",
+	FuncMap: template.FuncMap{
+		"RelativeTime": RelativeTime,
+	},
+	Paths:  []string{"templates/wind_sensor.htmlt"},
+	Styles: []string{"css/weather.css"},
+}
+
+var RainGaugeTemplate = spi.TemplateInfo{
+	Name: "environment_rain_gauge",
+	FuncMap: template.FuncMap{
+		"RelativeTime": RelativeTime,
+	},
+	Paths:  []string{"templates/rain_gauge.htmlt"},
+	Styles: []string{"css/weather.css"},
+}
+
+var UVSensorTemplate = spi.TemplateInfo{
+	Name: "environment_uv_sensor",
+	FuncMap: template.FuncMap{
+		"RelativeTime": RelativeTime,
+	},
+	Paths:  []string{"templates/uv_sensor.htmlt"},
+	Styles: []string{"css/weather.css"},
+}
+
+var SolarRadiationSensorTemplate = spi.TemplateInfo{
+	Name: "environment_solar_radiation_sensor",
+	FuncMap: template.FuncMap{
+		"RelativeTime": RelativeTime,
+	},
+	Paths:  []string{"templates/solar_radiation_sensor.htmlt"},
+	Styles: []string{"css/weather.css"},
+}
+
+// handleHttpWeatherRequest ingests the uploads of weather stations, in the Ecowitt and Weather Underground formats.
+// Ecowitt stations post a form, Weather Underground ones send a GET with query parameters.
+func (p *plugin) handleHttpWeatherRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	observation, err := weather.Parse(r.Form)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !p.isWeatherStationAllowed(observation) {
+		http.Error(w, "Unknown station or wrong password", http.StatusForbidden)
+		return
+	}
+
+	err, _ = spi.ExecValueFunctionOnPluginGoRoutine(
+		p.state.container,
+		func() error {
+			p.ingestWeatherObservation(observation)
+			return nil
+		},
+		func() error { return errors.New("unable to process weather observation") },
+		"handleHttpWeatherRequest")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	if observation.Format == weather.FormatWunderground {
+		// Weather Underground clients expect this response
+		_, _ = fmt.Fprintln(w, "success")
+	}
+}
+
+func (p *plugin) isWeatherStationAllowed(observation weather.Observation) bool {
+	cfg := p.config.WeatherStation
+
+	if len(cfg.StationIds) > 0 && !slices.Contains(cfg.StationIds, observation.StationId) {
+		return false
+	}
+
+	return observation.Format != weather.FormatWunderground || cfg.Password == "" ||
+		observation.Password == cfg.Password
+}
+
+// ingestWeatherObservation updates the entities of the station that sent observation, creating the station's
+// entities as it first reports their readings.  The outdoor thermometer is fed like any other thermometer.
+func (p *plugin) ingestWeatherObservation(observation weather.Observation) {
+	now := p.now()
+	station := p.weatherStation(observation.StationId)
+
+	if observation.HasTemperature {
+		id := station.OutdoorThermometerId()
+
+		if !station.HasOutdoorThermometer {
+			station.HasOutdoorThermometer = true
+			p.addBuiltInSource(id, station.Name+" Outdoor")
+		}
+
+		state := spienvironment.WirelessThermometer{Name: station.Name + " Outdoor"}
+		state.SensorData = spienvironment.SensorData{
+			Temperature:    observation.Temperature,
+			HasHumidity:    observation.HasHumidity,
+			Humidity:       observation.Humidity,
+			LastUpdateTime: now,
+		}
+		p.updateBuiltInSource(id, state)
+	}
+
+	if observation.HasPressure {
+		if station.Barometer == nil {
+			name := station.Name + " Barometer"
+			station.Barometer = weather.CreateBarometer(
+				p.state.nextEntityId(),
+				name,
+				weatherTrackingConfig("Barometer", name, data.BarometerDataType, data.BarometerDataToInsertArgs))
+			station.Barometer.PmaasEntityId = p.registerWeatherSensor(
+				station.Barometer.Id, entities.BarometerType, station.Barometer.Name,
+				func() any { return station.Barometer.GetStub(p.state.container) })
+		}
+
+		station.Barometer.Update(observation, now)
+	}
+
+	if observation.HasWind() {
+		if station.Wind == nil {
+			name := station.Name + " Wind"
+			station.Wind = weather.CreateWindSensor(
+				p.state.nextEntityId(),
+				name,
+				weatherTrackingConfig("WindSensor", name, data.WindDataType, data.WindDataToInsertArgs))
+			station.Wind.PmaasEntityId = p.registerWeatherSensor(
+				station.Wind.Id, entities.WindSensorType, station.Wind.Name,
+				func() any { return station.Wind.GetStub(p.state.container) })
+		}
+
+		station.Wind.Update(observation, now)
+	}
+
+	if observation.HasRain() {
+		if station.Rain == nil {
+			name := station.Name + " Rain"
+			station.Rain = weather.CreateRainGauge(
+				p.state.nextEntityId(),
+				name,
+				p.config.WeatherStation.RainResetTime,
+				weatherTrackingConfig("RainGauge", name, data.RainDataType, data.RainDataToInsertArgs),
+				p.state.clock)
+			station.Rain.PmaasEntityId = p.registerWeatherSensor(
+				station.Rain.Id, entities.RainGaugeType, station.Rain.Name,
+				func() any { return station.Rain.GetStub(p.state.container) })
+		}
+
+		station.Rain.Update(observation, now)
+	}
+
+	if observation.HasUVIndex {
+		if station.UV == nil {
+			name := station.Name + " UV"
+			station.UV = weather.CreateUVSensor(
+				p.state.nextEntityId(),
+				name,
+				weatherTrackingConfig("UVSensor", name, data.UVDataType, data.UVDataToInsertArgs))
+			station.UV.PmaasEntityId = p.registerWeatherSensor(
+				station.UV.Id, entities.UVSensorType, station.UV.Name,
+				func() any { return station.UV.GetStub(p.state.container) })
+		}
+
+		station.UV.Update(observation, now)
+	}
+
+	if observation.HasSolarRadiation {
+		if station.Solar == nil {
+			name := station.Name + " Solar Radiation"
+			station.Solar = weather.CreateSolarRadiationSensor(
+				p.state.nextEntityId(),
+				name,
+				weatherTrackingConfig(
+					"SolarRadiationSensor", name, data.SolarRadiationDataType, data.SolarRadiationDataToInsertArgs))
+			station.Solar.PmaasEntityId = p.registerWeatherSensor(
+				station.Solar.Id, entities.SolarRadiationSensorType, station.Solar.Name,
+				func() any { return station.Solar.GetStub(p.state.container) })
+		}
+
+		station.Solar.Update(observation, now)
+	}
+}
+
+// weatherStation returns the station with stationId, creating it on its first upload.  Stations are named by
+// PluginConfig.WeatherStation.Names, or numbered in the order they first report.
+func (p *plugin) weatherStation(stationId string) *weather.Station {
+	station, ok := p.state.weatherStations[stationId]
+
+	if ok {
+		return station
+	}
+
+	name, ok := p.config.WeatherStation.Names[stationId]
+
+	if !ok {
+		name = "Weather Station"
+
+		if len(p.state.weatherStations) > 0 {
+			name = fmt.Sprintf("Weather Station %d", len(p.state.weatherStations)+1)
+		}
+	}
+
+	station = &weather.Station{Id: stationId, Name: name}
+	p.state.weatherStations[stationId] = station
+
+	return station
+}
+
+func weatherTrackingConfig(
+	prefix string,
+	name string,
+	dataStructType reflect.Type,
+	insertArgFactoryFn tracking.InsertArgFactoryFunc) tracking.Config {
+	return tracking.Config{
+		TrackingMode:        tracking.ModePoll,
+		PollIntervalSeconds: 300,
+		Name:                buildTrackingName(prefix, name),
+		Schema: tracking.Schema{
+			DataStructType:     dataStructType,
+			InsertArgFactoryFn: insertArgFactoryFn,
+		},
+	}
+}
+
+func (p *plugin) registerWeatherSensor(
+	id string,
+	entityType reflect.Type,
+	name string,
+	stubFactoryFn func() any) string {
+	pmaasEntityId, err := p.state.container.RegisterEntity(
+		id,
+		entityType,
+		name,
+		func() (any, error) { return stubFactoryFn(), nil })
+
+	if err != nil {
+		fmt.Printf("%T Weather sensor %s could not be registered: %v\n", p, id, err)
+		return ""
+	}
+
+	return pmaasEntityId
+}
+
+// weatherSensorStates returns the states of the weather stations' sensors, ordered by station.
+func (p *plugin) weatherSensorStates() []any {
+	stationIds := make([]string, 0, len(p.state.weatherStations))
+
+	for stationId := range p.state.weatherStations {
+		stationIds = append(stationIds, stationId)
+	}
+
+	slices.Sort(stationIds)
+	result := make([]any, 0)
+
+	for _, stationId := range stationIds {
+		result = append(result, p.state.weatherStations[stationId].States()...)
+	}
+
+	return result
+}
+
+func (p *plugin) barometerRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&BarometerTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*weather.Barometer)
+			return ok
+		},
+		"*Barometer")
+}
+
+func (p *plugin) windSensorRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&WindSensorTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*weather.WindSensor)
+			return ok
+		},
+		"*WindSensor")
+}
+
+func (p *plugin) rainGaugeRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&RainGaugeTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*weather.RainGauge)
+			return ok
+		},
+		"*RainGauge")
+}
+
+func (p *plugin) uvSensorRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&UVSensorTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*weather.UVSensor)
+			return ok
+		},
+		"*UVSensor")
+}
+
+func (p *plugin) solarRadiationSensorRendererFactory() (spi.EntityRenderer, error) {
+	return spi.TemplateBasedRendererFactory(
+		p.state.container,
+		p.withClock(&SolarRadiationSensorTemplate),
+		func(entity any) bool {
+			_, ok := entity.(*weather.SolarRadiationSensor)
+			return ok
+		},
+		"*SolarRadiationSensor")
+}
@@ -1,9 +1,16 @@
 package environment
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/avanha/pmaas-plugin-environment/clock"
+	"github.com/avanha/pmaas-plugin-environment/entities"
+	"github.com/avanha/pmaas-plugin-environment/internal/recording"
 	"github.com/avanha/pmaas-plugin-environment/internal/thermometer"
+	spienvironment "github.com/avanha/pmaas-spi/environment"
 	"github.com/avanha/pmaas-spi/tracking"
 )
 
@@ -12,7 +19,79 @@ func TestWirelessThermometer_ImplementsExpectedInterfaces(t *testing.T) {
 		1,
 		"targetEntityId",
 		"name",
-		WirelessThermometerType,
-		tracking.Config{})
-	var _ Thermometer = tm
+		entities.WirelessThermometerType,
+		tracking.Config{},
+		clock.Real)
+	var _ entities.WirelessThermometer = tm
+}
+
+func TestPluginConfig_ConfigureThermometerAppliesToReplays(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.InputFilters["Kitchen"] = InputFilterConfig{MinTemperature: -40, MaxTemperature: 60}
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := make([]recording.Entry, 0)
+
+	for i, temperature := range []float32{20, 85, 21} {
+		entry := recording.Entry{
+			Time:     start.Add(time.Duration(i) * time.Minute),
+			EntityId: "source_1",
+			Name:     "Kitchen",
+			State:    spienvironment.WirelessThermometer{Name: "Kitchen"},
+		}
+		entry.State.SensorData.Temperature = temperature
+		entry.State.SensorData.LastUpdateTime = entry.Time
+		entries = append(entries, entry)
+	}
+
+	// Act
+	result := recording.Replay(entries, recording.ReplayOptions{Configure: config.configureThermometer})
+
+	// Assert
+	if wt := result.Thermometers["source_1"]; wt.HighTemperature != 21 || wt.SensorData.Temperature != 21 {
+		t.Fatalf("expected the out of range reading to be filtered, got %+v", wt.Thermometer)
+	}
+}
+
+func TestPluginConfig_ReplayRecording(t *testing.T) {
+	// Arrange
+	config := NewPluginConfig()
+	config.InputFilters["Kitchen"] = InputFilterConfig{MinTemperature: -40, MaxTemperature: 60}
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recorder, err := recording.NewRecorder(path)
+
+	if err != nil {
+		t.Fatalf("unable to create recorder: %v", err)
+	}
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, temperature := range []float32{20, 85} {
+		entry := recording.Entry{
+			Time:     start.Add(time.Duration(i) * time.Minute),
+			EntityId: "source_1",
+			Name:     "Kitchen",
+			State:    spienvironment.WirelessThermometer{Name: "Kitchen"},
+		}
+		entry.State.SensorData.Temperature = temperature
+		entry.State.SensorData.LastUpdateTime = entry.Time
+
+		if err := recorder.Record(entry); err != nil {
+			t.Fatalf("unable to record: %v", err)
+		}
+	}
+
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("unable to close recorder: %v", err)
+	}
+
+	output := strings.Builder{}
+
+	// Act
+	err = config.ReplayRecording(path, false, &output)
+
+	// Assert
+	if err != nil || !strings.Contains(output.String(), "Kitchen (source_1): temperature 20.00") {
+		t.Fatalf("expected the filtered final state, got %q, error %v", output.String(), err)
+	}
 }